@@ -0,0 +1,402 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/app"
+)
+
+// stringList is a repeatable string flag: each occurrence appends a value.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// commaList is a comma-separated string flag.
+type commaList []string
+
+func (c *commaList) String() string { return strings.Join(*c, ",") }
+
+func (c *commaList) Set(v string) error {
+	*c = nil
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*c = append(*c, s)
+		}
+	}
+	return nil
+}
+
+// depthLimit is an int flag for a depth limit and the switch that turns it
+// on; a negative value turns it off.
+type depthLimit struct {
+	depth *int
+	on    *bool
+}
+
+func (d depthLimit) String() string {
+	if d.on == nil || !*d.on {
+		return "-1"
+	}
+	return strconv.Itoa(*d.depth)
+}
+
+func (d depthLimit) Set(v string) error {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid depth %q", v)
+	}
+	*d.depth, *d.on = n, n >= 0
+	return nil
+}
+
+// statusList is a comma-separated list of HTTP status codes.
+type statusList []int
+
+func (s *statusList) String() string {
+	out := make([]string, len(*s))
+	for i, code := range *s {
+		out[i] = strconv.Itoa(code)
+	}
+	return strings.Join(out, ",")
+}
+
+// Set takes comma-separated codes and ranges, e.g. "401,403,500-503".
+func (s *statusList) Set(v string) error {
+	*s = []int{}
+	for _, f := range strings.Split(v, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(f, "-")
+		from, err := parseStatus(lo)
+		to := from
+		if err == nil && isRange {
+			to, err = parseStatus(hi)
+		}
+		if err != nil || to < from {
+			return fmt.Errorf("invalid status code %q", f)
+		}
+		for code := from; code <= to; code++ {
+			*s = append(*s, code)
+		}
+	}
+	return nil
+}
+
+func parseStatus(s string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || code < 100 || code > 599 {
+		return 0, fmt.Errorf("invalid status code %q", s)
+	}
+	return code, nil
+}
+
+// headerList is a repeatable comma-separated flag: each occurrence adds
+// its names.
+type headerList []string
+
+func (h *headerList) String() string { return strings.Join(*h, ",") }
+
+func (h *headerList) Set(v string) error {
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			*h = append(*h, s)
+		}
+	}
+	return nil
+}
+
+// delayRange is a "min-max" duration flag, e.g. 100ms-500ms; a single
+// duration is a fixed delay.
+type delayRange struct{ min, max *time.Duration }
+
+func (d delayRange) String() string {
+	if d.min == nil || *d.max == 0 {
+		return ""
+	}
+	return d.min.String() + "-" + d.max.String()
+}
+
+func (d delayRange) Set(v string) error {
+	lo, hi, ok := strings.Cut(v, "-")
+	if !ok {
+		hi = lo
+	}
+	min, err := time.ParseDuration(strings.TrimSpace(lo))
+	if err != nil {
+		return fmt.Errorf("want min-max durations, got %q", v)
+	}
+	max, err := time.ParseDuration(strings.TrimSpace(hi))
+	if err != nil || min < 0 || max < min {
+		return fmt.Errorf("want min-max durations with 0 <= min <= max, got %q", v)
+	}
+	*d.min, *d.max = min, max
+	return nil
+}
+
+// hostRates is a repeatable "pattern=rate" flag.
+type hostRates map[string]int
+
+func (h *hostRates) String() string {
+	var parts []string
+	for pattern, rate := range *h {
+		parts = append(parts, fmt.Sprintf("%s=%d", pattern, rate))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (h *hostRates) Set(v string) error {
+	pattern, raw, ok := strings.Cut(v, "=")
+	if !ok || pattern == "" {
+		return fmt.Errorf("want host=rate, got %q", v)
+	}
+	rate, err := strconv.Atoi(raw)
+	if err != nil || rate <= 0 {
+		return fmt.Errorf("invalid rate %q for %s", raw, pattern)
+	}
+	if *h == nil {
+		*h = make(hostRates)
+	}
+	(*h)[pattern] = rate
+	return nil
+}
+
+// resolveMap is a repeatable "host:port:addr" flag, as in curl's
+// --resolve. addr may carry its own port ("127.0.0.1:8080"); otherwise the
+// connection keeps port.
+type resolveMap map[string]string
+
+func (r *resolveMap) String() string {
+	var parts []string
+	for from, to := range *r {
+		parts = append(parts, from+":"+to)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
+}
+
+func (r *resolveMap) Set(v string) error {
+	from, to, err := parseResolve(v)
+	if err != nil {
+		return err
+	}
+	if *r == nil {
+		*r = make(resolveMap)
+	}
+	(*r)[from] = to
+	return nil
+}
+
+// parseResolve splits "host:port:addr" into the "host:port" to override
+// and the address to dial instead.
+func parseResolve(v string) (from, to string, err error) {
+	parts := strings.SplitN(v, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", "", fmt.Errorf("want host:port:addr, got %q", v)
+	}
+	host, port, addr := strings.ToLower(parts[0]), parts[1], parts[2]
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "", "", fmt.Errorf("invalid port %q in %q", port, v)
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), port)
+	}
+	return net.JoinHostPort(host, port), addr, nil
+}
+
+// regionList is a repeatable "name=host:port:addr[,host:port:addr...]"
+// flag.
+type regionList []app.Region
+
+func (l *regionList) String() string {
+	var names []string
+	for _, r := range *l {
+		names = append(names, r.Name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *regionList) Set(v string) error {
+	name, overrides, ok := strings.Cut(v, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("want name=host:port:addr[,...], got %q", v)
+	}
+	region := app.Region{Name: name, Resolve: map[string]string{}}
+	for _, o := range strings.Split(overrides, ",") {
+		from, to, err := parseResolve(strings.TrimSpace(o))
+		if err != nil {
+			return fmt.Errorf("region %s: %w", name, err)
+		}
+		region.Resolve[from] = to
+	}
+	*l = append(*l, region)
+	return nil
+}
+
+// bodyRules is a repeatable "urlpattern=regexp" flag. The URL pattern ends
+// at the first '=', so it cannot contain one.
+type bodyRules []app.BodyRule
+
+func (b *bodyRules) String() string {
+	var parts []string
+	for _, r := range *b {
+		parts = append(parts, r.URL+"="+r.Body.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (b *bodyRules) Set(v string) error {
+	pattern, expr, ok := strings.Cut(v, "=")
+	if !ok || pattern == "" || expr == "" {
+		return fmt.Errorf("want urlpattern=regexp, got %q", v)
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("body regexp for %s: %w", pattern, err)
+	}
+	*b = append(*b, app.BodyRule{URL: pattern, Body: re})
+	return nil
+}
+
+// progressFlag is a boolean flag that points cfg.Progress at w or turns
+// progress off.
+type progressFlag struct {
+	cfg *app.Config
+	w   io.Writer
+}
+
+func (p progressFlag) IsBoolFlag() bool { return true }
+
+func (p progressFlag) String() string {
+	return strconv.FormatBool(p.cfg != nil && p.cfg.Progress != nil)
+}
+
+func (p progressFlag) Set(v string) error {
+	on, err := strconv.ParseBool(v)
+	if err != nil {
+		return err
+	}
+	p.cfg.Progress = nil
+	if on {
+		p.cfg.Progress = p.w
+	}
+	return nil
+}
+
+// commonFlags registers the flags every networked subcommand shares and
+// returns the overall runtime limit.
+func commonFlags(fs *flag.FlagSet, cfg *app.Config) *time.Duration {
+	fs.DurationVar(&cfg.Timeout, "timeout", 10*time.Second, "HTTP timeout (e.g. 10s)")
+	fs.StringVar(&cfg.UserAgent, "user-agent", app.DefaultUserAgent, "User-Agent sent with every request; robots.txt rules are read for its product token (the part before the /)")
+	fs.IntVar(&cfg.Concurrency, "concurrency", 20, "Number of concurrent link checks (0 = scale with the hosts to check: 4 per host, at least 4 and at most the larger of 20 and 8 per CPU)")
+	fs.BoolVar(&cfg.AdaptiveConcurrency, "adaptive-concurrency", false, "Check fewer links at once while errors, 429s and 503s pile up, and more again once healthy (between -min-concurrency and -concurrency)")
+	fs.IntVar(&cfg.MinConcurrency, "min-concurrency", 1, "Lowest number of concurrent link checks with -adaptive-concurrency")
+	fs.IntVar(&cfg.Rate, "rate", 10, "Global request rate across all hosts (req/sec); each request waits for its host's rate, then this one")
+	fs.IntVar(&cfg.PerHostRate, "per-host-rate", 2, "Per-host request rate (req/sec)")
+	fs.Var((*hostRates)(&cfg.HostRates), "host-rate", "Per-host rate override as host=rate, e.g. api.partner.com=1 or *.partner.com=1 (repeatable)")
+	fs.Var(delayRange{&cfg.RandomDelayMin, &cfg.RandomDelayMax}, "random-delay", "Wait a random time in this range before each request, on top of rate limits, e.g. 100ms-500ms")
+	fs.DurationVar(&cfg.RetryBackoff, "retry-backoff", 500*time.Millisecond, "Wait before a retry (multiplied by the attempt number)")
+	fs.StringVar(&cfg.UnixSocket, "unix-socket", "", "Send all HTTP requests to this Unix domain socket (URLs keep a placeholder host, e.g. http://localhost/)")
+	fs.Var((*resolveMap)(&cfg.Resolve), "resolve", "Connect to host:port at addr instead of resolving it, as host:port:addr (repeatable)")
+	fs.BoolVar(&cfg.ShareCookies, "share-cookies", false, "Keep cookies that responses set and send them with later crawl and check requests, e.g. a session cookie the start page sets")
+	fs.StringVar(&cfg.MinTLS, "min-tls", "", "Refuse connections below this TLS version: 1.0, 1.1, 1.2 or 1.3 (default: Go's minimum, 1.2); links served only over older versions fail with a TLS error")
+	fs.Var((*commaList)(&cfg.Schemes), "schemes", "URL schemes to check, comma-separated: ftp, ws, wss (http and https are always checked)")
+	cfg.Progress = fs.Output()
+	cfg.Log = fs.Output()
+	fs.Var(progressFlag{cfg: cfg, w: fs.Output()}, "progress", "Print [crawl]/[check] progress lines to stderr")
+	fs.DurationVar(&cfg.ProgressEvery, "progress-interval", time.Second, "Time between progress lines")
+	return fs.Duration("max-runtime", 2*time.Minute, "Overall max runtime")
+}
+
+// crawlFlags registers where and how far to crawl.
+func crawlFlags(fs *flag.FlagSet, cfg *app.Config) {
+	fs.StringVar(&cfg.StartURL, "url", "", "Start URL (single page) e.g. https://example.com")
+	fs.Var((*stringList)(&cfg.Seeds), "seed", "Additional start URL, crawled at depth 0 like -url (repeatable)")
+	fs.DurationVar(&cfg.CrawlTimeout, "crawl-timeout", 0, "Timeout for fetching a page to crawl (default: -timeout)")
+	fs.IntVar(&cfg.MaxDepth, "max-depth", 2, "Max crawl depth (0 = only fetch the start pages; their links are still checked; -1 = check the single -url itself, without fetching it as a page)")
+	fs.StringVar(&cfg.Scope, "scope", "host", "Which hosts are internal: host (the start hosts) or etld1 (any subdomain of a start host's registrable domain, e.g. shop.example.co.uk for www.example.co.uk)")
+	fs.BoolVar(&cfg.ScopeQuery, "scope-query", false, "Crawl only pages that carry the start URL's query parameters with the same values, e.g. ?lang=en for one locale (other pages are still checked)")
+	fs.Var(depthLimit{&cfg.ExternalDepth, &cfg.LimitExternalDepth}, "external-depth", "Record external links only from pages at most `depth` deep; deeper ones are skipped (-1 = no limit, the default)")
+	fs.IntVar(&cfg.MaxPages, "max-pages", 200, "Max number of pages to crawl")
+	fs.IntVar(&cfg.MinPages, "min-pages", 0, "Exit with code 4 when fewer than this many pages were crawled, e.g. after a bad start page or too-tight limits (0 = off)")
+	fs.Var((*stringList)(&cfg.ExcludePages), "exclude-page", "Exact URL of a page never to fetch, even when linked; links to it are still checked (repeatable)")
+	fs.IntVar(&cfg.MaxPagesPerHost, "max-pages-per-host", 0, "Max number of pages to crawl from any one host; its other page links are skipped (0 = no limit)")
+	fs.StringVar(&cfg.LinkGraph, "link-graph", "", "Keep the crawl's link graph in this JSON file: pages unchanged since the last run (304 to a conditional GET) reuse their stored links. Not rewritten when the crawl stops early (-max-pages or an interrupt)")
+	fs.StringVar(&cfg.SavePages, "save-pages", "", "Save every page, stylesheet and robots.txt fetched for crawling (status, final URL, headers, body) to this directory, for -replay")
+	fs.StringVar(&cfg.Replay, "replay", "", "Crawl the pages saved by -save-pages in this directory instead of fetching them; links are still checked over the network")
+	fs.StringVar(&cfg.Inventory, "inventory", "", "Also write every discovered link (checked or skipped) with its kind, depth, skip reason and sources to this JSON file")
+	fs.BoolVar(&cfg.CrawlCSS, "crawl-css", false, "Fetch the same-site stylesheets (<link rel=stylesheet> and @import, served as text/css) that crawled pages link to and check what they reference: url() images and fonts, @import rules")
+	fs.IntVar(&cfg.CSSDepth, "css-depth", 1, "With -crawl-css, how many levels of stylesheets to fetch (1 = linked from pages, 2 = also what those @import, ...)")
+	fs.BoolVar(&cfg.NoSelfLinks, "no-self-links", false, "Count only links found on pages as discovered, not the crawled pages themselves; pages that fail to fetch are listed as broken pages")
+	fs.IntVar(&cfg.MaxLinksPerPage, "max-links-per-page", 0, "Record only the first N links of a page and warn about the rest (0 = no limit)")
+	fs.IntVar(&cfg.StartRetries, "start-retries", 0, "Retry a start page that fails transiently (network errors, 429, 5xx) this many times")
+	fs.BoolVar(&cfg.CheckAssets, "check-assets", true, "Check asset links (img, script, link)")
+	fs.StringVar(&cfg.ContentSelector, "content-selector", "", "Take links only from inside elements matching this CSS selector, e.g. \"main, article\" (tags, #id, .class, [attr=value], descendant and > combinators); pages where it matches nothing are used whole")
+	fs.BoolVar(&cfg.IgnoreQueryForCrawl, "ignore-query-for-crawl", false, "Crawl only one of several pages that differ just in their query string (all are still checked)")
+	fs.BoolVar(&cfg.NormalizeEscapes, "normalize-escapes", false, "Treat URLs that differ only in percent-encoding as one (%7E is ~, %2f is %2F; %2F is not /)")
+	fs.IntVar(&cfg.MaxSourcesPerLink, "max-sources-per-link", 0, "Keep at most this many pages per link as where it was found (0 = no limit); all are still counted, each once. Per-page outputs (lychee, -inventory, json sources) list only the kept ones; -top-broken-sources and -report-dir lift the cap")
+	fs.BoolVar(&cfg.VisitedBloom, "visited-bloom", false, "Remember crawled pages in a fixed-size bloom filter instead of an exact set, for very large crawls; about 1 in 1000 pages may be wrongly taken as crawled and skipped (its links are still checked); implies -no-self-links")
+	fs.IntVar(&cfg.VisitedBloomSize, "visited-bloom-size", 1_000_000, "With -visited-bloom, the number of pages to size the filter for (about 1.8MB per million); beyond it more pages are skipped")
+	fs.Var((*commaList)(&cfg.StripCacheBust), "strip-cachebust", "Cache-busting query parameters to ignore when deduping URLs, comma-separated (e.g. v,ver,hash), so versioned assets match across runs; a link is still checked as first seen")
+	fs.BoolVar(&cfg.CheckJSONLD, "check-jsonld", false, "Also check URLs (url, image, logo, sameAs, ...) in JSON-LD structured data")
+	fs.BoolVar(&cfg.CheckSocial, "check-social", false, "Also check URLs in Open Graph and Twitter card meta tags (og:image, og:url, twitter:image, ...) used for link previews")
+	fs.BoolVar(&cfg.IgnoreRobots, "ignore-robots", false, "Crawl pages robots.txt disallows for the -user-agent (or *) too. By default they are not crawled (links to them are still checked), and a disallowed start URL stops the run")
+	fs.StringVar(&cfg.Sitemap, "sitemap", "", "Compare the crawl with this sitemap (URL, or a path like /sitemap.xml on the start host): report sitemap URLs no crawled page links to, and crawled pages the sitemap does not list. Raise -max-depth/-max-pages to crawl the whole site first")
+	fs.BoolVar(&cfg.IgnoreMetaRobots, "ignore-meta-robots", false, "Crawl the links of pages marked <meta name=\"robots\" content=\"nofollow\"> too")
+}
+
+// checkFlags registers how links are checked and reported.
+func checkFlags(fs *flag.FlagSet, cfg *app.Config) {
+	fs.DurationVar(&cfg.CheckTimeout, "check-timeout", 0, "Timeout for a single link check (default: -timeout)")
+	fs.DurationVar(&cfg.WarmupDelay, "warmup-delay", 0, "Wait this long after crawling before checking links, e.g. 10s after a deploy so caches fill and cold backends start (counts toward -max-runtime)")
+	fs.BoolVar(&cfg.HeadFirst, "head-first", true, "Try HEAD before GET (fallback to GET if needed)")
+	fs.BoolVar(&cfg.ErrorTitles, "error-titles", false, "With -head-first, re-check dead links with GET to report their error page's <title> (checks without -head-first always do)")
+	fs.BoolVar(&cfg.AllowExternal, "allow-external", false, "Also check external links (default: false)")
+	fs.IntVar(&cfg.Retries, "retries", 0, "Retry transient failures (network errors, -retry-status statuses) this many times")
+	fs.Var((*statusList)(&cfg.OKStatusCodes), "ok-status", "Statuses to count as OK rather than dead, comma-separated codes or ranges, e.g. 401,403 for auth-gated pages")
+	fs.Var((*statusList)(&cfg.RetryStatuses), "retry-status", "Statuses worth a retry, comma-separated (default 429,500,502,503,504; an empty list retries network errors only)")
+	fs.IntVar(&cfg.MaxErrors, "max-errors", 0, "Exit with code 2 when more than this many links fail with network errors (0 = off)")
+	fs.StringVar(&cfg.FailOn, "fail-on", "dead", "Exit non-zero when: dead (dead links found) or none")
+	fs.StringVar(&cfg.Format, "format", "text", "Output format: text, ndjson (one JSON object per checked link, then a summary line), json (one document: summary, results with depth and sources, skipped links, warnings), csv (one row per checked link) or lychee (failures and summary as lychee prints them)")
+	fs.StringVar(&cfg.Webhook, "webhook", "", "POST the JSON report to this URL after the run (e.g. a Slack or Teams incoming webhook); delivery failures only print a warning")
+	fs.StringVar(&cfg.WebhookOn, "webhook-on", "failure", "When to call -webhook: failure (the run fails) or always")
+	fs.Var((*commaList)(&cfg.Columns), "columns", "With -format csv, the columns to write and their order, comma-separated: url, status, source, elapsed (ms), depth, kind (default all)")
+	fs.BoolVar(&cfg.SendReferer, "send-referer", false, "Send the page a link was found on as its Referer (for hotlink-protected assets)")
+	fs.BoolVar(&cfg.FlagEmpty, "flag-empty", false, "Warn about assets that return 2xx with an empty body")
+	fs.BoolVar(&cfg.HTTPSAudit, "https-audit", false, "Warn about redirect chains that pass through http:// or downgrade from https to http")
+	fs.BoolVar(&cfg.AuthAsWarning, "auth-as-warning", false, "Count 401/403 links as auth-required warnings instead of dead links")
+	fs.Var((*stringList)(&cfg.TolerateErrors), "tolerate-error", "Count links failing with an error that contains this pattern ('*' = any text) as warnings, e.g. \"connection refused\" (repeatable)")
+	fs.BoolVar(&cfg.VerifySRI, "verify-sri", false, "Download scripts and stylesheets with an integrity attribute and fail those whose hash does not match")
+	fs.IntVar(&cfg.WarnRedirectHops, "warn-redirect-hops", 0, "Warn about links that need more than this many redirect hops (0 = off)")
+	fs.StringVar(&cfg.ReportScope, "report-scope", "all", "Dead links to list: internal (start hosts), external or all (the summary always counts every link)")
+	fs.BoolVar(&cfg.DiscoveredBreakdown, "discovered-breakdown", false, "Split the discovered-links count into pages, assets, self-only (crawled pages no other page links to, like the start page), external and skipped links")
+	fs.BoolVar(&cfg.ReportDuplicates, "report-duplicates", false, "List pages that link to the same destination more than once (each is still checked once)")
+	fs.BoolVar(&cfg.ReportRedirectHosts, "report-redirect-hosts", false, "List external hosts that internal links reach only through redirects (hidden third-party dependencies)")
+	fs.BoolVar(&cfg.ReportRedirectTargets, "report-redirect-targets", false, "Group links that redirect by their final URL, most-linked first")
+	fs.BoolVar(&cfg.ReportStale, "report-stale", false, "List the live links with the oldest Last-Modified header")
+	fs.IntVar(&cfg.StaleTop, "stale-top", 20, "How many links -report-stale lists")
+	fs.IntVar(&cfg.TopBrokenSources, "top-broken-sources", 0, "Rank this many pages by the distinct dead links they contain, to fix the worst first (0 = off); keeps every source of each link, overriding -max-sources-per-link")
+	fs.Var((*regionList)(&cfg.Regions), "region", "Check once per region, as name=host:port:addr[,host:port:addr...], and report links whose outcome differs (repeatable); prints a text report, so it cannot be combined with other -format values, -low-memory, -report-dir, -inventory or -link-graph")
+	fs.BoolVar(&cfg.StopOnFirstDead, "stop-on-first-dead", false, "Stop checking at the first dead link and report just what was checked so far")
+	fs.Var((*headerList)(&cfg.CaptureHeaders), "capture-header", "Response headers to include in JSON results, comma-separated (repeatable), e.g. Server,CF-Cache-Status")
+	fs.StringVar(&cfg.ReportDir, "report-dir", "", "Also write one report file per source page (named after its URL) into this directory; keeps every source of each link, overriding -max-sources-per-link, and cannot be combined with -low-memory")
+	fs.BoolVar(&cfg.PerHostStats, "per-host-stats", false, "Report each host's first and last successful response and its failure count")
+	fs.BoolVar(&cfg.LowMemory, "low-memory", false, "Spool check results to a temp file to bound memory on huge crawls; cannot be combined with -format json (use ndjson)")
+	fs.BoolVar(&cfg.Strict, "strict", false, "Treat warnings (temporary redirects, empty resources, scheme-less host links, https audit, long redirect chains, auth-required) as failures")
+	fs.Var((*bodyRules)(&cfg.HealthyBody), "healthy-body-regex", "URL pattern ('*' wildcard) and regexp as pattern=regexp: matching links are dead unless their 2xx body matches (repeatable; first match wins)")
+	fs.Var((*stringList)(&cfg.ExpectDead), "expect-dead", "URL pattern ('*' wildcard) for links expected to be dead (repeatable)")
+}
@@ -2,48 +2,232 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"time"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/rojanmagar2001/godeadlink/internal/app"
 )
 
+// Exit codes.
+const (
+	exitOK          = 0
+	exitDeadLinks   = 1 // dead links found (see -fail-on)
+	exitCheckErrors = 2 // more links than -max-errors could not be checked
+	exitRuntime     = 3 // bad arguments or config, or the run itself failed
+	exitLowCoverage = 4 // fewer pages crawled than -min-pages
+)
+
+// errUsage reports bad arguments; the flag set has already printed why.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name  string
+	args  string // positional arguments, for the usage line
+	short string
+	run   func(fs *flag.FlagSet, args []string, stdout io.Writer) error
+}
+
+var commands = []command{
+	{name: "check", short: "Crawl a site (or changed local files) and check its links", run: runCheck},
+	{name: "crawl", short: "Crawl a site and list discovered links without checking them", run: runCrawl},
+	{name: "recheck", args: " results.ndjson", short: "Check again the links an ndjson run reported dead", run: runRecheck},
+	{name: "serve", short: "Serve an HTTP API that runs checks on demand (POST /check)", run: runServe},
+	{name: "diff", args: " old.ndjson new.ndjson", short: "Compare two ndjson runs: newly dead and fixed links", run: runDiff},
+}
+
 func main() {
-	var (
-		startURL      = flag.String("url", "", "Start URL (single page) e.g. https://example.com")
-		timeout       = flag.Duration("timeout", 10*time.Second, "HTTP timeout (e.g. 10s)")
-		headFirst     = flag.Bool("head-first", true, "Try HEAD before GET (fallback to GET if needed)")
-		concurrency   = flag.Int("concurrency", 20, "Number of concurrent links checks")
-		maxDepth      = flag.Int("max-depth", 2, "Max crawl depth (0 = only start page)")
-		maxPages      = flag.Int("max-pages", 200, "Max number of pages to crawl")
-		allowExternal = flag.Bool("allow-external", false, "Also check external links (default: false)")
-		checkAssets   = flag.Bool("check-assets", true, "Check asset links (img, script, link)")
-		rate          = flag.Int("rate", 10, "Global request rate (req/sec)")
-		perHost       = flag.Int("per-host-rate", 2, "Per-host request rate (req/sec)")
-		maxRuntime    = flag.Duration("max-runtime", 2*time.Minute, "Overall max runtime")
-	)
-	flag.Parse()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand and returns the process exit code.
+// Flags without a subcommand are the deprecated spelling of "check".
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return exitRuntime
+	}
+
+	name, rest := args[0], args[1:]
+	switch {
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		usage(stdout)
+		return exitOK
+	case strings.HasPrefix(name, "-"):
+		fmt.Fprintln(stderr, `warning: flags without a subcommand are deprecated; use "deadlink check" instead`)
+		name, rest = "check", args
+	}
+
+	cmd, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+		return exitRuntime
+	}
+
+	err := cmd.run(cmd.flagSet(stderr), rest, stdout)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errUsage):
+		return exitRuntime
+	}
+	fmt.Fprintln(stderr, "error:", err)
+	switch {
+	case errors.Is(err, app.ErrDeadLinks):
+		return exitDeadLinks
+	case errors.Is(err, app.ErrCheckErrors):
+		return exitCheckErrors
+	case errors.Is(err, app.ErrLowCoverage):
+		return exitLowCoverage
+	default:
+		return exitRuntime
+	}
+}
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: deadlink <command> [flags]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.short)
+	}
+	fmt.Fprintf(w, "\nRun \"deadlink <command> -h\" for the command's flags.\n")
+	fmt.Fprintf(w, "\nExit codes:\n"+
+		"  %d  success\n"+
+		"  %d  dead links found (or, for diff, newly dead links)\n"+
+		"  %d  more links than -max-errors could not be checked\n"+
+		"  %d  bad arguments or config, or the run itself failed\n"+
+		"  %d  fewer pages crawled than -min-pages\n",
+		exitOK, exitDeadLinks, exitCheckErrors, exitRuntime, exitLowCoverage)
+}
+
+// flagSet returns an empty flag set for c that reports errors to stderr.
+func (c command) flagSet(stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: deadlink %s [flags]%s\n\n%s.\n\nFlags:\n", c.name, c.args, c.short)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != nargs {
+		fmt.Fprintf(fs.Output(), "want %d arguments, got %d\n", nargs, fs.NArg())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func runCheck(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var cfg app.Config
+	maxRuntime := commonFlags(fs, &cfg)
+	crawlFlags(fs, &cfg)
+	checkFlags(fs, &cfg)
+	fs.StringVar(&cfg.GitDiff, "git-diff", "", "Check links in HTML/Markdown files changed since this git revision instead of crawling")
+	fs.BoolVar(&cfg.ValidateOnly, "validate-only", false, "Crawl but check nothing; list malformed, scheme-less, fragment-only and unsupported-scheme links")
+	fs.StringVar(&cfg.HAR, "har", "", "Check the request URLs of a HAR capture file instead of crawling")
+	fs.StringVar(&cfg.WARC, "warc", "", "Check the URLs a WARC file (.warc or .warc.gz) holds responses for instead of crawling")
+	fs.BoolVar(&cfg.HARExtractHTML, "har-html", false, "With -har or -warc, also check links in the captured HTML responses")
+	out := outputFlags(fs)
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	defer watchRateSignal(&cfg, fs.Output())()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *maxRuntime)
+	defer cancel()
+	return out.write(stdout, func(w io.Writer) error { return app.Run(ctx, cfg, w) })
+}
+
+func runCrawl(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var cfg app.Config
+	maxRuntime := commonFlags(fs, &cfg)
+	crawlFlags(fs, &cfg)
+	out := outputFlags(fs)
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	defer watchRateSignal(&cfg, fs.Output())()
 
 	ctx, cancel := context.WithTimeout(context.Background(), *maxRuntime)
 	defer cancel()
+	return out.write(stdout, func(w io.Writer) error { return app.Crawl(ctx, cfg, w) })
+}
 
-	cfg := app.Config{
-		StartURL:      *startURL,
-		Timeout:       *timeout,
-		HeadFirst:     *headFirst,
-		Concurrency:   *concurrency,
-		MaxDepth:      *maxDepth,
-		MaxPages:      *maxPages,
-		AllowExternal: *allowExternal,
-		CheckAssets:   *checkAssets,
-		Rate:          *rate,
-		PerHostRate:   *perHost,
+func runRecheck(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	var cfg app.Config
+	maxRuntime := commonFlags(fs, &cfg)
+	checkFlags(fs, &cfg)
+	out := outputFlags(fs)
+	if err := parse(fs, args, 1); err != nil {
+		return err
 	}
 
-	if err := app.Run(ctx, cfg, os.Stdout); err != nil {
-		fmt.Fprintln(os.Stderr, "error:", err)
-		os.Exit(1)
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
 	}
+	defer f.Close()
+	defer watchRateSignal(&cfg, fs.Output())()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *maxRuntime)
+	defer cancel()
+	return out.write(stdout, func(w io.Writer) error { return app.Recheck(ctx, cfg, f, w) })
+}
+
+func runDiff(fs *flag.FlagSet, args []string, stdout io.Writer) error {
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	before, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer before.Close()
+	after, err := os.Open(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer after.Close()
+
+	return app.Diff(before, after, stdout)
+}
+
+func runServe(fs *flag.FlagSet, args []string, _ io.Writer) error {
+	var cfg app.Config
+	maxRuntime := commonFlags(fs, &cfg)
+	crawlFlags(fs, &cfg)
+	checkFlags(fs, &cfg)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return app.Serve(ctx, *addr, cfg, *maxRuntime, fs.Output())
 }
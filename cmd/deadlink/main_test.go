@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/ok">ok</a><a href="/dead">dead</a>`))
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/dead", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun_Dispatch(t *testing.T) {
+	srv := newSite(t)
+	net := []string{"-rate", "100", "-per-host-rate", "100"}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "no args", args: nil, wantCode: 3, wantStderr: "Commands:"},
+		{name: "help", args: []string{"help"}, wantCode: 0, wantStdout: "recheck"},
+		{name: "unknown command", args: []string{"frobnicate"}, wantCode: 3, wantStderr: `unknown command "frobnicate"`},
+		{name: "unknown flag", args: []string{"diff", "-url", "x"}, wantCode: 3, wantStderr: "flag provided but not defined: -url"},
+		{name: "missing argument", args: []string{"diff", "a.ndjson"}, wantCode: 3, wantStderr: "want 2 arguments, got 1"},
+		{name: "subcommand help", args: []string{"crawl", "-h"}, wantCode: 0, wantStderr: "Usage: deadlink crawl"},
+		{
+			name:       "check",
+			args:       append([]string{"check", "-url", srv.URL + "/", "-fail-on", "none"}, net...),
+			wantCode:   0,
+			wantStdout: "/dead",
+		},
+		{
+			name:       "check fails on dead links",
+			args:       append([]string{"check", "-url", srv.URL + "/"}, net...),
+			wantCode:   1,
+			wantStderr: "dead links found",
+		},
+		{
+			name:       "deprecated alias",
+			args:       append([]string{"-url", srv.URL + "/", "-fail-on", "none"}, net...),
+			wantCode:   0,
+			wantStdout: "/dead",
+			wantStderr: "deprecated",
+		},
+		{
+			name:       "crawl",
+			args:       append([]string{"crawl", "-url", srv.URL + "/"}, net...),
+			wantCode:   0,
+			wantStdout: "Discovered links: 3",
+		},
+		{name: "crawl rejects check flags", args: []string{"crawl", "-strict"}, wantCode: 3},
+		{
+			name:       "resolve",
+			args:       append([]string{"check", "-url", "http://site.invalid/", "-resolve", "site.invalid:80:" + srv.Listener.Addr().String(), "-fail-on", "none"}, net...),
+			wantCode:   0,
+			wantStdout: "http://site.invalid/dead",
+		},
+		{name: "bad resolve", args: []string{"check", "-resolve", "site.invalid:80"}, wantCode: 3, wantStderr: "want host:port:addr"},
+		{name: "bad retry status", args: []string{"check", "-retry-status", "500,oops"}, wantCode: 3, wantStderr: `invalid status code "oops"`},
+		{name: "bad ok status range", args: []string{"check", "-ok-status", "401,403-401"}, wantCode: 3, wantStderr: `invalid status code "403-401"`},
+		{name: "bad healthy body", args: []string{"check", "-healthy-body-regex", "*/status=(unclosed"}, wantCode: 3, wantStderr: "body regexp for */status"},
+		{name: "unknown column", args: []string{"check", "-url", "http://127.0.0.1:1/", "-format", "csv", "-columns", "url,latency"}, wantCode: 3, wantStderr: `unknown column "latency"`},
+		{name: "bad content selector", args: []string{"check", "-url", "http://127.0.0.1:1/", "-content-selector", "main >"}, wantCode: 3, wantStderr: `content selector: selector "main >"`},
+		{name: "bad webhook-on", args: []string{"check", "-url", "http://127.0.0.1:1/", "-webhook", "http://127.0.0.1:1/hook", "-webhook-on", "success"}, wantCode: 3, wantStderr: `invalid webhook-on "success"`},
+		{name: "bad min-tls", args: []string{"check", "-url", "http://127.0.0.1:1/", "-min-tls", "1.4"}, wantCode: 3, wantStderr: `min-tls: invalid TLS version "1.4"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code %d, want %d\nstdout:\n%s\nstderr:\n%s", code, tt.wantCode, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr missing %q:\n%s", tt.wantStderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_RecheckAndDiff(t *testing.T) {
+	srv := newSite(t)
+	prev := writeFile(t, "prev.ndjson",
+		`{"type":"result","url":"`+srv.URL+`/ok","status":404,"dead":true}`+"\n"+
+			`{"type":"result","url":"`+srv.URL+`/dead","status":200}`+"\n"+
+			`{"type":"summary","checked":2}`+"\n")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"recheck", "-format", "ndjson", "-rate", "100", "-per-host-rate", "100", prev}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("recheck exit code %d\n%s", code, stderr.String())
+	}
+	if strings.Contains(stdout.String(), `/dead"`) {
+		t.Fatalf("recheck should only check links that were dead:\n%s", stdout.String())
+	}
+	cur := writeFile(t, "cur.ndjson", stdout.String()+`{"type":"result","url":"`+srv.URL+`/dead","status":404,"dead":true}`+"\n")
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"diff", prev, cur}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("diff with a newly dead link should exit 1, got %d\n%s", code, stderr.String())
+	}
+	for _, want := range []string{"NEW   404   " + srv.URL + "/dead", "FIXED 200   " + srv.URL + "/ok"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("diff output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	// Nothing listens on a closed listener's port; links to it fail with
+	// network errors. Same host as the site, so they count as internal.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := "http://" + ln.Addr().String()
+	ln.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/gone">gone</a><a href="` + closed + `/a">a</a><a href="` + closed + `/b">b</a>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	check := []string{"check", "-url", srv.URL + "/", "-rate", "100", "-per-host-rate", "100", "-timeout", "2s"}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"success", append(check, "-fail-on", "none"), exitOK},
+		{"dead links", check, exitDeadLinks},
+		{"errors within threshold", append(check, "-max-errors", "2"), exitDeadLinks},
+		{"errors over threshold", append(check, "-max-errors", "1"), exitCheckErrors},
+		{"errors over threshold without fail-on", append(check, "-max-errors", "1", "-fail-on", "none"), exitCheckErrors},
+		{"too few pages crawled", append(check, "-min-pages", "5"), exitLowCoverage},
+		{"enough pages crawled", append(check, "-min-pages", "1"), exitDeadLinks},
+		{"bad config", append(check, "-format", "xml"), exitRuntime},
+		{"bad flag", []string{"check", "-no-such-flag"}, exitRuntime},
+		{"unreadable input", []string{"recheck", filepath.Join(t.TempDir(), "missing.ndjson")}, exitRuntime},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.want {
+				t.Fatalf("exit code %d, want %d\nstdout:\n%s\nstderr:\n%s", code, tt.want, stdout.String(), stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_StartDisallowedByRobots(t *testing.T) {
+	var fetched []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("User-agent: *\nDisallow: /docs/\n"))
+	})
+	mux.HandleFunc("/docs/", func(w http.ResponseWriter, r *http.Request) {
+		fetched = append(fetched, r.URL.Path)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/docs/a">a</a>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	args := []string{"check", "-url", srv.URL + "/docs/", "-rate", "100", "-per-host-rate", "100"}
+
+	// robots.txt is respected by default.
+	var stdout, stderr bytes.Buffer
+	if code := run(args, &stdout, &stderr); code != exitRuntime {
+		t.Fatalf("exit code %d, want %d\nstderr:\n%s", code, exitRuntime, stderr.String())
+	}
+	want := "error: start URL is disallowed by robots.txt: " + srv.URL + "/docs/; use --ignore-robots to override\n"
+	if stderr.String() != want {
+		t.Fatalf("stderr:\n%s\nwant:\n%s", stderr.String(), want)
+	}
+	if len(fetched) != 0 || stdout.Len() != 0 {
+		t.Fatalf("nothing should be fetched or reported, got %v\n%s", fetched, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(append(args, "-ignore-robots"), &stdout, &stderr); code != exitOK {
+		t.Fatalf("with -ignore-robots: exit code %d\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRun_Progress(t *testing.T) {
+	// Slow pages keep both phases busy for several progress intervals; the
+	// linked pages are not crawled, so each is checked.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	args := []string{"check", "-url", srv.URL + "/", "-max-depth", "0", "-concurrency", "1",
+		"-rate", "100", "-per-host-rate", "100", "-progress-interval", "10ms"}
+
+	for _, progress := range []bool{true, false} {
+		var stdout, stderr bytes.Buffer
+		if code := run(append(args, "-progress="+strconv.FormatBool(progress)), &stdout, &stderr); code != 0 {
+			t.Fatalf("progress=%v: exit code %d\n%s", progress, code, stderr.String())
+		}
+		for _, tag := range []string{"[crawl]", "[check]"} {
+			if got := strings.Contains(stderr.String(), tag); got != progress {
+				t.Fatalf("progress=%v: %s lines present=%v\nstderr:\n%s", progress, tag, got, stderr.String())
+			}
+		}
+		if strings.Contains(stdout.String(), "[crawl]") || strings.Contains(stdout.String(), "[check]") {
+			t.Fatalf("progress belongs on stderr, not stdout:\n%s", stdout.String())
+		}
+	}
+}
+
+func TestRun_GzipOutput(t *testing.T) {
+	srv := newSite(t)
+	path := filepath.Join(t.TempDir(), "report.ndjson.gz")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"check", "-url", srv.URL + "/", "-format", "ndjson", "-output", path, "-rate", "100", "-per-host-rate", "100"}, &stdout, &stderr)
+	// The dead link still fails the run, but the report is complete.
+	if code != exitDeadLinks {
+		t.Fatalf("exit code %d, want %d\n%s", code, exitDeadLinks, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("report should go to the file, got stdout:\n%s", stdout.String())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("not gzip: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("truncated gzip stream: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for _, line := range lines {
+		if !json.Valid([]byte(line)) {
+			t.Fatalf("invalid JSON line %q", line)
+		}
+	}
+	if last := lines[len(lines)-1]; !strings.Contains(last, `"type":"summary"`) {
+		t.Fatalf("report should end with the summary, got %q", last)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// reportOutput is where a command writes its report: stdout, or the file
+// named by -output, gzipped when that ends in .gz or -gzip is set.
+type reportOutput struct {
+	path string
+	gzip bool
+}
+
+func outputFlags(fs *flag.FlagSet) *reportOutput {
+	o := &reportOutput{}
+	fs.StringVar(&o.path, "output", "", "Write the report to this file instead of stdout (gzipped if it ends in .gz)")
+	fs.BoolVar(&o.gzip, "gzip", false, "Gzip the report (implied by an -output ending in .gz)")
+	return o
+}
+
+// write runs fn with the report's writer, then closes it. Failing to
+// finish the report wins over fn's error: a dead-links verdict about a
+// truncated file would be misleading.
+func (o *reportOutput) write(stdout io.Writer, fn func(io.Writer) error) error {
+	w := stdout
+	var f *os.File
+	if o.path != "" {
+		var err error
+		if f, err = os.Create(o.path); err != nil {
+			return err
+		}
+		w = f
+	}
+	var zw *gzip.Writer
+	if o.gzip || strings.HasSuffix(o.path, ".gz") {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+
+	err := fn(w)
+
+	var closeErr error
+	if zw != nil {
+		closeErr = zw.Close()
+	}
+	if f != nil {
+		if cerr := f.Close(); closeErr == nil {
+			closeErr = cerr
+		}
+	}
+	if closeErr != nil {
+		return fmt.Errorf("write report: %w", closeErr)
+	}
+	return err
+}
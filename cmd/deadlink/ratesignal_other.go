@@ -0,0 +1,14 @@
+//go:build !unix
+
+package main
+
+import (
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/app"
+)
+
+// watchRateSignal is a no-op where SIGUSR1 does not exist.
+func watchRateSignal(*app.Config, io.Writer) (stop func()) {
+	return func() {}
+}
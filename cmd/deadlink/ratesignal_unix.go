@@ -0,0 +1,56 @@
+//go:build unix
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/rojanmagar2001/godeadlink/internal/app"
+)
+
+// watchRateSignal makes SIGUSR1 cycle the run's request rate through
+// configured -> slow -> paused -> configured, reporting each step on w.
+// The returned func stops watching.
+func watchRateSignal(cfg *app.Config, w io.Writer) (stop func()) {
+	presets := []struct {
+		name  string
+		rates app.Rates
+	}{
+		{"configured", app.Rates{Global: cfg.Rate, PerHost: cfg.PerHostRate}},
+		{"slow (1 req/s)", app.Rates{Global: 1, PerHost: 1}},
+		{"paused (SIGUSR1 again to resume)", app.Rates{Global: 0, PerHost: cfg.PerHostRate}},
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGUSR1)
+	rates := make(chan app.Rates, 1)
+	cfg.Rates = rates
+	done := make(chan struct{})
+
+	go func() {
+		cur := 0
+		for {
+			select {
+			case <-done:
+				return
+			case <-sig:
+				cur = (cur + 1) % len(presets)
+				fmt.Fprintf(w, "rate: %s\n", presets[cur].name)
+				select {
+				case rates <- presets[cur].rates:
+				case <-done:
+					return
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/rojanmagar2001/godeadlink
 
 go 1.25.5
 
-require golang.org/x/net v0.48.0 // indirect
+require (
+	golang.org/x/net v0.48.0
+	golang.org/x/text v0.32.0
+)
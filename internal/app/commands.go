@@ -0,0 +1,111 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+// Crawl crawls like Run but checks nothing; it lists every discovered link.
+// Like Run, it returns ErrLowCoverage when fewer than MinPages were crawled.
+func Crawl(ctx context.Context, cfg Config, stdout io.Writer) error {
+	if err := cfg.applyDefaults(); err != nil {
+		return err
+	}
+	p := build(cfg)
+	defer p.Close()
+
+	if err := p.loadGraph(cfg.LinkGraph); err != nil {
+		return err
+	}
+	if _, err := p.orch.Crawl(ctx, cfg.seeds()); err != nil {
+		return err
+	}
+	if err := p.saveGraph(cfg.LinkGraph); err != nil {
+		return err
+	}
+	if err := p.writeInventory(cfg.Inventory); err != nil {
+		return err
+	}
+	if err := usecase.WriteDiscovered(stdout, p.store.AllDiscovered(), p.orch.Crawled()); err != nil {
+		return err
+	}
+	return cfg.coverage(p.orch.Crawled())
+}
+
+// validate crawls and lints the discovered links without checking any of
+// them. Malformed links make it return ErrDeadLinks, as dead ones would,
+// and too few pages ErrLowCoverage.
+func (cfg Config) validate(ctx context.Context, stdout io.Writer) error {
+	p := build(cfg)
+	defer p.Close()
+
+	if _, err := p.orch.Crawl(ctx, cfg.seeds()); err != nil {
+		return err
+	}
+	if err := p.writeInventory(cfg.Inventory); err != nil {
+		return err
+	}
+	malformed, err := usecase.WriteLint(stdout, p.store.AllDiscovered(), p.orch.Crawled())
+	if err != nil {
+		return err
+	}
+	if err := cfg.coverage(p.orch.Crawled()); err != nil {
+		return err
+	}
+	if cfg.FailOn == FailOnDead && malformed > 0 {
+		return ErrDeadLinks
+	}
+	return nil
+}
+
+// Recheck checks again the links a previous ndjson run reported dead.
+func Recheck(ctx context.Context, cfg Config, previous io.Reader, stdout io.Writer) error {
+	if err := cfg.applyDefaults(); err != nil {
+		return err
+	}
+	prior, err := usecase.ReadNDJSON(previous)
+	if err != nil {
+		return err
+	}
+
+	var urls []string
+	for _, r := range prior {
+		if r.Dead {
+			urls = append(urls, r.URL)
+		}
+	}
+
+	p := build(cfg)
+	defer p.Close()
+
+	rep, err := p.orch.RunURLs(ctx, urls, stdout)
+	if err != nil {
+		return err
+	}
+	return cfg.verdict(rep)
+}
+
+// Diff compares two ndjson runs and lists links that became dead or were
+// fixed. It returns ErrDeadLinks when anything newly broke.
+func Diff(before, after io.Reader, stdout io.Writer) error {
+	old, err := usecase.ReadNDJSON(before)
+	if err != nil {
+		return fmt.Errorf("read old run: %w", err)
+	}
+	cur, err := usecase.ReadNDJSON(after)
+	if err != nil {
+		return fmt.Errorf("read new run: %w", err)
+	}
+
+	d := usecase.DiffRuns(old, cur)
+	if err := d.Write(stdout); err != nil {
+		return err
+	}
+	if len(d.NewlyDead) > 0 {
+		return ErrDeadLinks
+	}
+	return nil
+}
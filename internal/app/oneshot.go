@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/infra/store"
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+// unlimited is a limiter for a single request.
+type unlimited struct{}
+
+func (unlimited) Take(context.Context, string) error { return nil }
+
+// oneShot reports whether cfg asks for no crawl at all (MaxDepth below 0),
+// so the run is the check of StartURL alone.
+func (cfg Config) oneShot() bool {
+	return cfg.MaxDepth < 0
+}
+
+// checkOne checks cfg.StartURL directly, skipping the crawl, the limiter
+// and the worker pool.
+func (cfg Config) checkOne(ctx context.Context, stdout io.Writer) error {
+	if cfg.StartURL == "" || len(cfg.Seeds) > 0 || cfg.GitDiff != "" || cfg.HAR != "" || cfg.WARC != "" {
+		return errors.New("max-depth -1 checks exactly one -url")
+	}
+
+	tr := cfg.transport()
+	defer tr.CloseIdleConnections()
+	chk := usecase.NewLinkChecker(cfg.checkerConfig(tr), unlimited{})
+	orch := usecase.NewOrchestrator(nil, chk, store.NewMemory(), cfg.orchestratorConfig())
+	rep, err := orch.CheckOne(ctx, cfg.StartURL, stdout)
+	if err != nil {
+		return err
+	}
+	return cfg.verdict(rep)
+}
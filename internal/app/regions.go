@@ -0,0 +1,140 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+// Region is a named set of DNS overrides (see Config.Resolve) that makes
+// the site resolve as it would from one region, e.g. to one CDN PoP.
+type Region struct {
+	Name    string
+	Resolve map[string]string
+}
+
+// checkRegions rejects what a run with Regions cannot do: it crawls and
+// checks each region, and prints only the combined text report, which
+// needs every result.
+func (cfg Config) checkRegions() error {
+	switch {
+	case cfg.HAR != "" || cfg.WARC != "" || cfg.GitDiff != "":
+		return errors.New("regions apply to crawled sites, not -har, -warc or -git-diff")
+	case cfg.oneShot():
+		return errors.New("regions need a crawl; max-depth -1 crawls nothing")
+	case cfg.ValidateOnly:
+		return errors.New("regions and validate-only cannot be combined")
+	case cfg.Format != usecase.FormatText:
+		return fmt.Errorf("regions print a text report; format %q is not supported", cfg.Format)
+	case cfg.LowMemory:
+		return errors.New("regions and low-memory cannot be combined")
+	case cfg.ReportDir != "":
+		return errors.New("regions and report-dir cannot be combined")
+	case cfg.Inventory != "":
+		return errors.New("regions and inventory cannot be combined")
+	case cfg.LinkGraph != "":
+		return errors.New("regions and link-graph cannot be combined")
+	}
+	return nil
+}
+
+// runRegions runs the crawl and check once per region, one after the
+// other, and prints a summary line per region and every link whose outcome
+// differs between them. The run fails if any region's does.
+func (cfg Config) runRegions(ctx context.Context, stdout io.Writer) error {
+	reps := make([]*usecase.Report, len(cfg.Regions))
+	var verdict error
+	for i, region := range cfg.Regions {
+		c := cfg
+		c.Resolve = maps.Clone(cfg.Resolve)
+		if c.Resolve == nil {
+			c.Resolve = map[string]string{}
+		}
+		maps.Copy(c.Resolve, region.Resolve)
+
+		p := build(c)
+		rep, err := p.orch.RunSeeds(ctx, c.seeds(), io.Discard)
+		if err != nil {
+			err = fmt.Errorf("region %s: %w", region.Name, err)
+			p.notify(ctx, c, rep, err)
+			p.Close()
+			return err
+		}
+		reps[i] = rep
+		err = c.verdict(rep)
+		if err != nil {
+			err = fmt.Errorf("region %s: %w", region.Name, err)
+			if verdict == nil {
+				verdict = err
+			}
+		}
+		// Each region's report goes to the webhook on its own.
+		p.notify(ctx, c, rep, err)
+		p.Close()
+	}
+
+	for i, rep := range reps {
+		fmt.Fprintf(stdout, "Region %s: checked %d  OK %d  failures %d\n",
+			cfg.Regions[i].Name, rep.Checked, rep.OK+rep.Partial, rep.Failures)
+	}
+	writeRegionDiffs(stdout, cfg.Regions, reps)
+	return verdict
+}
+
+// writeRegionDiffs lists the links whose status differs between regions;
+// a link one region never found shows as "not found".
+func writeRegionDiffs(w io.Writer, regions []Region, reps []*usecase.Report) {
+	outcomes := map[string][]string{}
+	for i, rep := range reps {
+		for _, r := range rep.Results {
+			o, ok := outcomes[r.URL]
+			if !ok {
+				o = make([]string, len(reps))
+				for j := range o {
+					o[j] = "not found"
+				}
+				outcomes[r.URL] = o
+			}
+			o[i] = regionOutcome(r)
+		}
+	}
+
+	var differing []string
+	for u, o := range outcomes {
+		for _, s := range o[1:] {
+			if s != o[0] {
+				differing = append(differing, u)
+				break
+			}
+		}
+	}
+	if len(differing) == 0 {
+		fmt.Fprintln(w, "\nNo links differ by region.")
+		return
+	}
+
+	slices.Sort(differing)
+	fmt.Fprintln(w, "\nLinks whose outcome differs by region:")
+	for _, u := range differing {
+		fmt.Fprintf(w, "  %s\n", u)
+		for i, s := range outcomes[u] {
+			fmt.Fprintf(w, "    %s: %s\n", regions[i].Name, s)
+		}
+	}
+}
+
+// regionOutcome is the status code, or "error" for a failed request; error
+// texts are left out since they name the address that was dialed.
+func regionOutcome(r domain.Result) string {
+	if r.Err != nil {
+		return "error"
+	}
+	return strconv.Itoa(r.StatusCode)
+}
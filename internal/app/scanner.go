@@ -0,0 +1,199 @@
+package app
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+
+	"github.com/rojanmagar2001/godeadlink/internal/extract"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/extractor"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/limiter"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/snapshot"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/store"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Scanner runs scans one after another with the parts they can share —
+// the HTTP transport, with its pooled connections, and the rate limiter —
+// built once. Every Scan gets a fresh store, crawler and checker, so scans
+// see nothing of each other's results. Close the Scanner when done.
+type Scanner struct {
+	cfg  Config
+	lim  *limiter.PerHost
+	take ports.Limiter
+	tr   *http.Transport
+	done chan struct{}
+}
+
+// NewScanner validates cfg and builds the shared parts of its scans.
+// cfg.StartURL and cfg.Seeds are ignored; each Scan names its start URL.
+func NewScanner(cfg Config) (*Scanner, error) {
+	if err := cfg.applyDefaults(); err != nil {
+		return nil, err
+	}
+	return newScanner(cfg), nil
+}
+
+func newScanner(cfg Config) *Scanner {
+	lim := limiter.New(cfg.Rate, cfg.PerHostRate)
+	lim.SetHostRates(cfg.HostRates)
+	var take ports.Limiter = lim
+	if cfg.RandomDelayMax > 0 {
+		take = limiter.NewJitter(lim, cfg.RandomDelayMin, cfg.RandomDelayMax)
+	}
+	s := &Scanner{
+		cfg:  cfg,
+		lim:  lim,
+		take: take,
+		tr:   cfg.transport(),
+		done: make(chan struct{}),
+	}
+	if cfg.Rates != nil {
+		go s.followRates(cfg.Rates)
+	}
+	return s
+}
+
+// Scan crawls from startURL and checks the links found, returning the
+// report. Output options that write files (Inventory, LinkGraph) and the
+// text report are left to Run; use Config.OnResult to stream results.
+func (s *Scanner) Scan(ctx context.Context, startURL string) (*usecase.Report, error) {
+	cfg := s.cfg
+	cfg.StartURL, cfg.Seeds = startURL, nil
+	return s.pipeline(cfg).orch.RunSeeds(ctx, cfg.seeds(), io.Discard)
+}
+
+// Close stops the rate limiter and drops the pooled connections.
+func (s *Scanner) Close() {
+	close(s.done)
+	s.lim.Close()
+	s.tr.CloseIdleConnections()
+}
+
+// pipeline wires the per-scan parts for cfg around the shared ones.
+func (s *Scanner) pipeline(cfg Config) *pipeline {
+	httpOpts := []httpclient.Option{httpclient.WithTransport(s.tr)}
+	var jar http.CookieJar
+	if cfg.ShareCookies {
+		// Each scan starts with no cookies.
+		jar, _ = cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+		httpOpts = append(httpOpts, httpclient.WithJar(jar))
+	}
+	var httpc ports.HTTPClient = httpclient.New(cfg.CrawlTimeout, httpOpts...)
+	if cfg.Replay != "" {
+		httpc = snapshot.NewReplay(cfg.Replay)
+	}
+	extOpts := []extractor.Option{extractor.WithJSONLD(cfg.CheckJSONLD), extractor.WithSocial(cfg.CheckSocial), extractor.WithSchemes(cfg.Schemes)}
+	if cfg.ContentSelector != "" {
+		// applyDefaults has checked it parses.
+		sel, _ := extract.ParseSelector(cfg.ContentSelector)
+		extOpts = append(extOpts, extractor.WithContentSelector(sel))
+	}
+	ext := extractor.New(extOpts...)
+	storeOpts := []store.Option{store.WithURLKeyFunc(cfg.URLKey), store.WithVisitIgnoringQuery(cfg.IgnoreQueryForCrawl), store.WithStrippedParams(cfg.StripCacheBust), store.WithEscapeNormalization(cfg.NormalizeEscapes), store.WithMaxSources(cfg.maxSources())}
+	if cfg.VisitedBloom {
+		storeOpts = append(storeOpts, store.WithVisitedBloom(cfg.VisitedBloomSize))
+	}
+	st := store.NewMemory(storeOpts...)
+
+	crawler := usecase.NewCrawler(httpc, ext, s.take, cfg.UserAgent, cfg.CrawlTimeout, cfg.MaxDepth, cfg.MaxPages, cfg.CheckAssets, cfg.IgnoreMetaRobots)
+	crawler.StartRetries = cfg.StartRetries
+	crawler.StartRetryBackoff = cfg.RetryBackoff
+	crawler.ExternalDepth = cfg.externalDepth()
+	crawler.HostScope = cfg.Scope
+	crawler.ScopeQuery = cfg.ScopeQuery
+	crawler.ReportDuplicates = cfg.ReportDuplicates
+	crawler.MaxLinksPerPage = cfg.MaxLinksPerPage
+	crawler.MaxPagesPerHost = cfg.MaxPagesPerHost
+	crawler.ExcludePages = cfg.ExcludePages
+	// A bloom-filtered crawl must not grow the store with every page.
+	crawler.NoSelfLinks = cfg.NoSelfLinks || cfg.VisitedBloom
+	crawler.RespectRobots = !cfg.IgnoreRobots
+	if cfg.SavePages != "" {
+		crawler.SavePages = snapshot.NewSaver(cfg.SavePages)
+	}
+	if cfg.CrawlCSS {
+		crawler.CSS = ext
+		crawler.CSSDepth = cfg.CSSDepth
+	}
+	chkCfg := cfg.checkerConfig(s.tr)
+	chkCfg.Jar = jar
+	checker := usecase.NewLinkChecker(chkCfg, s.take)
+
+	orch := usecase.NewOrchestrator(crawler, checker, st, cfg.orchestratorConfig())
+
+	return &pipeline{scanner: s, store: st, crawler: crawler, orch: orch}
+}
+
+// orchestratorConfig is the part of cfg the orchestrator takes.
+func (cfg Config) orchestratorConfig() usecase.Config {
+	return usecase.Config{
+		AllowExternal: cfg.AllowExternal,
+		Concurrency:   cfg.Concurrency,
+		Timeout:       cfg.Timeout,
+		ProgressEvery: cfg.ProgressEvery,
+		Progress:      cfg.Progress,
+		ResultHandler: cfg.OnResult,
+		ExpectDead:    cfg.ExpectDead,
+		Strict:        cfg.Strict,
+		Format:        cfg.Format,
+		Columns:       cfg.Columns,
+		SendReferer:   cfg.SendReferer,
+		FlagEmpty:     cfg.FlagEmpty,
+		LowMemory:     cfg.LowMemory,
+		HTTPSAudit:    cfg.HTTPSAudit,
+		VerifySRI:     cfg.VerifySRI,
+		AuthAsWarning: cfg.AuthAsWarning,
+
+		TolerateErrors: cfg.TolerateErrors,
+
+		WarnRedirectHops: cfg.WarnRedirectHops,
+		PerHostStats:     cfg.PerHostStats,
+		ReportScope:      cfg.ReportScope,
+		ReportDir:        cfg.ReportDir,
+		StaleTop:         cfg.staleTop(),
+		StopOnFirstDead:  cfg.StopOnFirstDead,
+
+		TopBrokenSources: cfg.TopBrokenSources,
+
+		ReportRedirectTargets: cfg.ReportRedirectTargets,
+		ReportRedirectHosts:   cfg.ReportRedirectHosts,
+
+		AdaptiveConcurrency: cfg.AdaptiveConcurrency,
+		MinConcurrency:      cfg.MinConcurrency,
+
+		Sitemap:     cfg.Sitemap,
+		WarmupDelay: cfg.WarmupDelay,
+
+		DiscoveredBreakdown: cfg.DiscoveredBreakdown,
+	}
+}
+
+// followRates applies rate changes until the Scanner is closed.
+func (s *Scanner) followRates(rates <-chan Rates) {
+	for {
+		select {
+		case <-s.done:
+			return
+		case r, ok := <-rates:
+			if !ok {
+				return
+			}
+			s.lim.SetRate(r.Global, r.PerHost)
+		}
+	}
+}
+
+// transport builds the transport crawl and check requests share.
+func (cfg Config) transport() *http.Transport {
+	var minTLS uint16
+	if cfg.MinTLS != "" {
+		// applyDefaults has validated it.
+		minTLS, _ = httpclient.ParseTLSVersion(cfg.MinTLS)
+	}
+	return httpclient.NewTransport(cfg.UnixSocket, cfg.Resolve, minTLS)
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+func TestScanner_ReusedAcrossScans(t *testing.T) {
+	srv := newSite(t)
+
+	s, err := NewScanner(Config{
+		Timeout:     2 * time.Second,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var first *usecase.Report
+	scan := func() {
+		t.Helper()
+		rep, err := s.Scan(context.Background(), srv.URL+"/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = rep
+		}
+		// Each scan has its own store: every link is checked every time.
+		if rep.Crawled != 1 || rep.Checked == 0 || rep.Checked != first.Checked {
+			t.Fatalf("crawled %d, checked %d; want 1 and %d", rep.Crawled, rep.Checked, first.Checked)
+		}
+	}
+
+	scan()
+	base := runtime.NumGoroutine()
+	for range 5 {
+		scan()
+	}
+
+	// Finished scans leave nothing running beyond the shared pool's
+	// connections, which later scans reuse; give stragglers a moment.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > base && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > base {
+		t.Fatalf("goroutines grew from %d to %d over 5 scans", base, n)
+	}
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+// CheckRequest is the body of POST /check. Unset fields keep the server's
+// defaults.
+type CheckRequest struct {
+	URL           string   `json:"url"`
+	Seeds         []string `json:"seeds,omitempty"`
+	MaxDepth      *int     `json:"max_depth,omitempty"`
+	MaxPages      *int     `json:"max_pages,omitempty"`
+	AllowExternal *bool    `json:"allow_external,omitempty"`
+	CheckAssets   *bool    `json:"check_assets,omitempty"`
+	ExpectDead    []string `json:"expect_dead,omitempty"`
+	Strict        *bool    `json:"strict,omitempty"`
+}
+
+// maxRequestBody bounds the JSON body of POST /check.
+const maxRequestBody = 1 << 20
+
+// NewHandler serves the check API:
+//
+//	POST /check   run one check (body: CheckRequest) and return the JSON report
+//	GET  /healthz liveness probe
+//
+// Every request is a scan of s, starting from its Config: requests share
+// its transport and rate limiter but each gets its own store, bounded by
+// timeout. A report that fails the verdict carries it in "error"; the
+// webhook is notified as for a run. Outputs that write files are left out,
+// as concurrent requests would overwrite each other's.
+func NewHandler(s *Scanner, timeout time.Duration) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = io.WriteString(w, "ok\n")
+	})
+	mux.HandleFunc("POST /check", func(w http.ResponseWriter, r *http.Request) {
+		var req CheckRequest
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			httpError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+			return
+		}
+
+		cfg := req.apply(s.cfg.forRequest())
+		if len(cfg.seeds()) == 0 {
+			httpError(w, http.StatusBadRequest, errors.New("url is required"))
+			return
+		}
+		if err := cfg.applyDefaults(); err != nil {
+			httpError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		rep, verdict, err := s.pipeline(cfg).check(ctx, cfg, io.Discard)
+		if err != nil {
+			httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Render first so a failure can still become a proper error response.
+		var body bytes.Buffer
+		if err := usecase.WriteJSONError(&body, rep, cfg.PerHostStats, verdict); err != nil {
+			httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = body.WriteTo(w)
+	})
+	return mux
+}
+
+// forRequest returns cfg for one request of the check API: no rate
+// signal, progress lines or result stream, which only make sense for the
+// CLI's single run, no file outputs, which concurrent requests would
+// overwrite, and a report kept whole in memory for the JSON response.
+func (cfg Config) forRequest() Config {
+	cfg.Rates = nil
+	cfg.Progress = nil
+	cfg.OnResult = nil
+	cfg.Format = usecase.FormatText
+	cfg.LowMemory = false
+	cfg.ReportDir = ""
+	cfg.SavePages = ""
+	cfg.Inventory = ""
+	cfg.LinkGraph = ""
+	return cfg
+}
+
+// Serve runs the check API (see NewHandler) on addr until ctx is done,
+// then shuts down, letting running checks finish. log gets the listen
+// address.
+func Serve(ctx context.Context, addr string, defaults Config, timeout time.Duration, log io.Writer) error {
+	s, err := NewScanner(defaults.forRequest())
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           NewHandler(s, timeout),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(log, "listening on %s\n", ln.Addr())
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// apply returns cfg with the fields set in req.
+func (req CheckRequest) apply(cfg Config) Config {
+	cfg.StartURL = req.URL
+	cfg.Seeds = req.Seeds
+	if req.MaxDepth != nil {
+		cfg.MaxDepth = *req.MaxDepth
+	}
+	if req.MaxPages != nil {
+		cfg.MaxPages = *req.MaxPages
+	}
+	if req.AllowExternal != nil {
+		cfg.AllowExternal = *req.AllowExternal
+	}
+	if req.CheckAssets != nil {
+		cfg.CheckAssets = *req.CheckAssets
+	}
+	if req.ExpectDead != nil {
+		cfg.ExpectDead = req.ExpectDead
+	}
+	if req.Strict != nil {
+		cfg.Strict = *req.Strict
+	}
+	return cfg
+}
+
+func httpError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
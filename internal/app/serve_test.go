@@ -0,0 +1,134 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type checkResponse struct {
+	Summary struct {
+		Checked  int `json:"checked"`
+		OK       int `json:"ok"`
+		DeadHTTP int `json:"dead_http"`
+		Failures int `json:"failures"`
+	} `json:"summary"`
+	Results []struct {
+		URL    string `json:"url"`
+		Status int    `json:"status"`
+		Dead   bool   `json:"dead"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+func postCheck(t *testing.T, api, body string) (int, checkResponse) {
+	t.Helper()
+	resp, err := http.Post(api+"/check", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	var out checkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp.StatusCode, out
+}
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/ok">ok</a><a href="/gone">gone</a>`))
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	site := httptest.NewServer(mux) // /gone is a 404
+	defer site.Close()
+
+	hook, notified := receiver(t, http.StatusNoContent)
+	reports := t.TempDir()
+	s, err := NewScanner(Config{
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    2,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+		ReportDir:   reports,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	api := httptest.NewServer(NewHandler(s, 10*time.Second))
+	defer api.Close()
+
+	t.Run("healthz", func(t *testing.T) {
+		resp, err := http.Get(api.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("healthz: status %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		// Concurrent checks run isolated crawls, so each sees the whole site.
+		var wg sync.WaitGroup
+		for range 3 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				code, rep := postCheck(t, api.URL, `{"url":"`+site.URL+`/","max_depth":0}`)
+				if code != http.StatusOK || rep.Error != "dead links found" {
+					t.Errorf("status %d, error %q; want 200 with the verdict", code, rep.Error)
+					return
+				}
+				if rep.Summary.Checked != 3 || rep.Summary.OK != 2 || rep.Summary.DeadHTTP != 1 || rep.Summary.Failures != 1 {
+					t.Errorf("unexpected summary %+v", rep.Summary)
+				}
+				var dead []string
+				for _, r := range rep.Results {
+					if r.Dead {
+						dead = append(dead, r.URL)
+					}
+				}
+				if len(dead) != 1 || dead[0] != site.URL+"/gone" {
+					t.Errorf("expected only /gone dead, got %v", dead)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// Each failing check notifies; none writes the shared report dir.
+		if len(*notified) != 3 {
+			t.Errorf("webhook called %d times, want 3", len(*notified))
+		}
+		if entries, _ := os.ReadDir(reports); len(entries) != 0 {
+			t.Errorf("report dir written by the API: %d files", len(entries))
+		}
+	})
+
+	for _, tc := range []struct{ name, body string }{
+		{"missing url", `{"max_depth":1}`},
+		{"unknown field", `{"url":"` + site.URL + `/","colour":"red"}`},
+		{"not json", `url=x`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			code, rep := postCheck(t, api.URL, tc.body)
+			if code != http.StatusBadRequest || rep.Error == "" {
+				t.Fatalf("expected 400 with an error, got %d %+v", code, rep)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient"
+	"github.com/rojanmagar2001/godeadlink/internal/usecase"
+)
+
+// When a webhook is sent (see Config.WebhookOn).
+const (
+	WebhookOnFailure = "failure"
+	WebhookOnAlways  = "always"
+)
+
+func validateWebhook(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook %q (want an http or https URL)", raw)
+	}
+	return nil
+}
+
+// notify POSTs the JSON report to cfg.Webhook when cfg.WebhookOn asks for
+// it, through the scanner's transport. runErr is what the run returns: it
+// fails the run and, when set, goes into the payload's "error" field, with
+// an empty report if the run ended before it had one. Delivery outlives a
+// cancelled ctx, is bounded by cfg.Timeout, and its failures are logged to
+// cfg.Log without failing the run.
+func (p *pipeline) notify(ctx context.Context, cfg Config, rep *usecase.Report, runErr error) {
+	if cfg.Webhook == "" || (cfg.WebhookOn == WebhookOnFailure && runErr == nil) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.Timeout)
+	defer cancel()
+	if err := p.postReport(ctx, cfg, rep, runErr); err != nil && cfg.Log != nil {
+		fmt.Fprintf(cfg.Log, "warning: webhook not delivered: %v\n", err)
+	}
+}
+
+func (p *pipeline) postReport(ctx context.Context, cfg Config, rep *usecase.Report, runErr error) error {
+	var body bytes.Buffer
+	if err := usecase.WriteJSONError(&body, rep, cfg.PerHostStats, runErr); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.Webhook, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", cfg.UserAgent)
+
+	resp, err := httpclient.New(cfg.Timeout, httpclient.WithTransport(p.scanner.tr)).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered %s", cfg.Webhook, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the bodies POSTed to it.
+func receiver(t *testing.T, status int) (*httptest.Server, *[][]byte) {
+	t.Helper()
+	var mu sync.Mutex
+	var got [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with Content-Type %q", r.Method, r.Header.Get("Content-Type"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got = append(got, b)
+		mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &got
+}
+
+// brokenSite serves a page linking to a missing one.
+func brokenSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/missing">missing</a>`))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun_WebhookOnFailure(t *testing.T) {
+	site := newSite(t)
+	hook, got := receiver(t, http.StatusNoContent)
+
+	cfg := Config{
+		StartURL:    site.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+		WebhookOn:   WebhookOnFailure,
+	}
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("healthy run: %v", err)
+	}
+	if len(*got) != 0 {
+		t.Fatalf("webhook called after a passing run: %s", (*got)[0])
+	}
+
+	broken := brokenSite(t)
+	cfg.StartURL = broken.URL + "/"
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+	if len(*got) != 1 {
+		t.Fatalf("webhook called %d times after a failing run, want 1", len(*got))
+	}
+	var payload struct {
+		Summary struct {
+			Failures int `json:"failures"`
+		} `json:"summary"`
+		Results []struct {
+			URL  string `json:"url"`
+			Dead bool   `json:"dead"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal((*got)[0], &payload); err != nil {
+		t.Fatalf("payload is not the JSON report: %v\n%s", err, (*got)[0])
+	}
+	var dead []string
+	for _, r := range payload.Results {
+		if r.Dead {
+			dead = append(dead, r.URL)
+		}
+	}
+	if payload.Summary.Failures != 1 || len(dead) != 1 || dead[0] != broken.URL+"/missing" {
+		t.Fatalf("unexpected payload: %s", (*got)[0])
+	}
+
+	cfg.StartURL = site.URL + "/"
+	cfg.WebhookOn = WebhookOnAlways
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("healthy run: %v", err)
+	}
+	if len(*got) != 2 {
+		t.Fatalf("webhook-on always: called %d times in all, want 2", len(*got))
+	}
+}
+
+func TestRun_WebhookUndelivered(t *testing.T) {
+	site := brokenSite(t)
+	hook, _ := receiver(t, http.StatusInternalServerError)
+
+	var log bytes.Buffer
+	cfg := Config{
+		StartURL:    site.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+		Log:         &log,
+	}
+	// The run's own verdict stands; the webhook only adds a warning.
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+	if !strings.Contains(log.String(), "warning: webhook not delivered") || !strings.Contains(log.String(), "500") {
+		t.Fatalf("expected a delivery warning, got %q", log.String())
+	}
+}
+
+func TestRun_WebhookOnRunError(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	hook, got := receiver(t, http.StatusNoContent)
+
+	cfg := Config{
+		StartURL:    down.URL + "/",
+		Timeout:     2 * time.Second,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+		WebhookOn:   WebhookOnFailure,
+	}
+	runErr := Run(context.Background(), cfg, io.Discard)
+	if runErr == nil {
+		t.Fatal("expected the run to fail on an unreachable start page")
+	}
+	if len(*got) != 1 {
+		t.Fatalf("webhook called %d times after a failed run, want 1", len(*got))
+	}
+	var payload struct {
+		Error   string          `json:"error"`
+		Summary json.RawMessage `json:"summary"`
+	}
+	if err := json.Unmarshal((*got)[0], &payload); err != nil {
+		t.Fatalf("payload is not the JSON report: %v\n%s", err, (*got)[0])
+	}
+	if payload.Error != runErr.Error() || payload.Summary == nil {
+		t.Fatalf("unexpected payload: %s", (*got)[0])
+	}
+}
+
+func TestRun_WebhookAfterCancel(t *testing.T) {
+	site := brokenSite(t)
+	hook, got := receiver(t, http.StatusNoContent)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cfg := Config{
+		StartURL:    site.URL + "/",
+		Timeout:     2 * time.Second,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+	}
+	p := build(cfg)
+	defer p.Close()
+	rep, err := p.run(ctx, cfg, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	p.notify(ctx, cfg, rep, cfg.verdict(rep))
+	if len(*got) != 1 {
+		t.Fatalf("webhook called %d times after the run was cancelled, want 1", len(*got))
+	}
+}
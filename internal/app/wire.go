@@ -2,50 +2,742 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/rojanmagar2001/godeadlink/internal/infra/extractor"
+	"github.com/rojanmagar2001/godeadlink/internal/check"
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/extract"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/filecheck"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/ftpcheck"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/gitdiff"
 	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient"
-	"github.com/rojanmagar2001/godeadlink/internal/infra/limiter"
 	"github.com/rojanmagar2001/godeadlink/internal/infra/store"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/wscheck"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
 	"github.com/rojanmagar2001/godeadlink/internal/usecase"
 )
 
+// ErrDeadLinks is returned by Run when the run found failures and FailOn
+// asks for them to fail the run.
+var ErrDeadLinks = errors.New("dead links found")
+
+// ErrCheckErrors is returned by Run when more links than MaxErrors could
+// not be checked at all (network errors, timeouts). It takes precedence
+// over ErrDeadLinks.
+var ErrCheckErrors = errors.New("too many links could not be checked")
+
+// ErrLowCoverage is returned by Run when the crawl visited fewer than
+// MinPages pages, so a near-empty crawl does not pass for a healthy site.
+// It takes precedence over the other verdicts.
+var ErrLowCoverage = errors.New("crawl covered too few pages")
+
+// FailOn values.
+const (
+	FailOnDead = "dead"
+	FailOnNone = "none"
+)
+
+// DefaultUserAgent is the User-Agent requests are sent with when
+// Config.UserAgent is empty.
+const DefaultUserAgent = check.DefaultUserAgent
+
 type Config struct {
-	StartURL    string
-	Timeout     time.Duration
-	HeadFirst   bool
+	StartURL string
+	// Seeds are extra start URLs crawled alongside StartURL, all at depth 0.
+	Seeds   []string
+	Timeout time.Duration
+	// CrawlTimeout bounds fetching a page to extract links; CheckTimeout
+	// bounds a single link check. Both default to Timeout.
+	CrawlTimeout time.Duration
+	CheckTimeout time.Duration
+	// WarmupDelay waits between the crawl and the checks, e.g. for caches
+	// to fill after a deploy.
+	WarmupDelay time.Duration
+
+	HeadFirst bool
+	// ErrorTitles GETs links whose HEAD check is dead, to report the
+	// title of their error page; without HeadFirst every check is a GET
+	// and titles are reported anyway.
+	ErrorTitles bool
+	// Concurrency is how many links are checked at once; 0 scales it with
+	// the CPUs and the hosts to check (see usecase.Config.Concurrency).
 	Concurrency int
-	UserAgent   string
+	// UserAgent is sent with every request: crawl, robots.txt, checks,
+	// WebSocket handshakes and the webhook. robots.txt rules are read for
+	// its product token.
+	UserAgent string
 
+	// MaxDepth below 0 crawls nothing: StartURL alone is checked, without
+	// the limiter and worker pool a crawl needs.
 	MaxDepth      int
 	MaxPages      int
 	AllowExternal bool
 	CheckAssets   bool
+	// Schemes lists the URL schemes to record and check; others are
+	// skipped. http and https are always in it, so it only adds "ftp" and
+	// the WebSocket schemes "ws" and "wss", which have checkers too.
+	Schemes []string
+	// CheckJSONLD also checks URLs in JSON-LD structured data blocks.
+	CheckJSONLD bool
+	// CheckSocial also checks the URLs of Open Graph and Twitter card meta
+	// tags (og:image, twitter:image, ...), which link previews rely on.
+	CheckSocial bool
+	// ContentSelector, when set, is a CSS selector ("main, article")
+	// limiting the links taken from each page to those inside the
+	// elements it matches (see extract.Selector for the syntax supported).
+	ContentSelector string
+	// IgnoreQueryForCrawl crawls only one of several pages that differ
+	// just in their query string; all of them are still checked.
+	IgnoreQueryForCrawl bool
+	// IgnoreMetaRobots crawls past pages marked <meta name="robots" content="nofollow">.
+	IgnoreMetaRobots bool
+	// Pages robots.txt disallows for UserAgent are left uncrawled, and a
+	// disallowed start URL stops the run with usecase.ErrStartDisallowed;
+	// IgnoreRobots crawls them anyway.
+	IgnoreRobots bool
+	// Sitemap, when set, is loaded (a path like "/sitemap.xml" is resolved
+	// against the start URL) and compared with the crawl: the report lists
+	// its URLs no crawled page links to and the crawled pages it leaves out.
+	Sitemap string
 
 	Rate        int
 	PerHostRate int
+	// Rates, when set, changes Rate and PerHostRate while the run is going
+	// (0 pauses); see limiter.PerHost.SetRate.
+	Rates <-chan Rates
+	// HostRates overrides PerHostRate for hosts matching a pattern
+	// ("api.partner.com" or "*.partner.com").
+	HostRates map[string]int
 
 	ProgressEvery time.Duration
+	// Progress gets periodic crawl/check status lines; nil disables them.
+	Progress io.Writer
+	// OnResult gets each check result as it completes (see
+	// usecase.Config.ResultHandler).
+	OnResult func(domain.Result)
+
+	// Retries re-attempts transient failures (network errors, 429, 5xx).
+	Retries      int
+	RetryBackoff time.Duration
+	// RetryStatuses replaces the statuses worth a retry (default 429, 500,
+	// 502, 503, 504). It has no say in which statuses are dead.
+	RetryStatuses []int
+	// OKStatusCodes are statuses counted as OK rather than dead, even from
+	// 400 up, e.g. 401 and 403 for auth-gated pages.
+	OKStatusCodes []int
+	// RandomDelayMin and RandomDelayMax add a random wait in that range
+	// before each request, on top of rate limiting (max 0 = off).
+	RandomDelayMin time.Duration
+	RandomDelayMax time.Duration
+	// StartRetries re-attempts fetching a seed page that fails
+	// transiently, with the same backoff.
+	StartRetries int
+	// Scope decides which hosts are internal: "host" (the seeds' hosts,
+	// the default) or "etld1" (any host under a seed's registrable
+	// domain).
+	Scope string
+	// ScopeQuery crawls only pages that carry the start URL's query
+	// parameters, with the same values (see usecase.Crawler.ScopeQuery).
+	ScopeQuery bool
+	// LimitExternalDepth records external links only from pages at most
+	// ExternalDepth deep (0 = the seeds); unset, there is no limit.
+	LimitExternalDepth bool
+	ExternalDepth      int
+	// ExcludePages are exact page URLs never fetched, even when linked.
+	ExcludePages []string
+	// MaxPagesPerHost caps the pages crawled from any one host (0 = no
+	// limit); its further page links are reported as skipped.
+	MaxPagesPerHost int
+	// MaxLinksPerPage keeps only the first links of each crawled page
+	// (0 = no limit).
+	MaxLinksPerPage int
+	// NoSelfLinks stops counting crawled pages as discovered links; pages
+	// that fail to fetch are reported as broken pages instead.
+	NoSelfLinks bool
+	// CrawlCSS fetches the internal stylesheets crawled pages link to and
+	// checks what they reference, following @imports CSSDepth levels deep
+	// (1 = only the linked stylesheets).
+	CrawlCSS bool
+	CSSDepth int
+
+	// ExpectDead lists URL patterns for links that should stay dead.
+	ExpectDead []string
+	// FailOn selects what makes Run return ErrDeadLinks ("dead" or "none").
+	FailOn string
+	// MaxErrors makes Run return ErrCheckErrors when more links than this
+	// fail with errors (0 = off).
+	MaxErrors int
+	// MinPages makes Run (and Crawl) return ErrLowCoverage when fewer
+	// pages than this were crawled (0 = off). It needs a crawl, so it
+	// cannot be combined with HAR, WARC, GitDiff or a MaxDepth below 0.
+	MinPages int
+	// Strict makes warnings count as failures.
+	Strict bool
+	// Format is "text" (default), "ndjson", "json", "csv" or "lychee".
+	Format string
+	// Columns picks and orders the csv columns (see usecase.CSVColumns).
+	Columns []string
+	// SendReferer sets Referer to the page a link was found on.
+	SendReferer bool
+	// FlagEmpty warns about assets served with an empty body.
+	FlagEmpty bool
+	// LowMemory spools check results to disk instead of keeping them all.
+	// The json format holds every result until the end, so it cannot be
+	// combined with LowMemory.
+	LowMemory bool
+	// HTTPSAudit warns about redirects through or down to plain http.
+	HTTPSAudit bool
+	// AuthAsWarning reports 401/403 links as auth-required warnings, not
+	// dead links.
+	AuthAsWarning bool
+	// TolerateErrors lists error-message patterns whose links are reported
+	// as tolerated-error warnings, not dead links.
+	TolerateErrors []string
+	// VerifySRI fails assets whose content does not match their integrity
+	// attribute.
+	VerifySRI bool
+	// WarnRedirectHops warns about links taking more redirect hops than
+	// this (0 = off).
+	WarnRedirectHops int
+	// PerHostStats reports first/last successful response and failures
+	// per host.
+	PerHostStats bool
+	// ReportScope lists only internal or external dead links in the text
+	// report ("internal", "external" or "all", the default).
+	ReportScope string
+	// DiscoveredBreakdown splits the discovered-links count by kind and
+	// by whether the links were checked.
+	DiscoveredBreakdown bool
+	// ReportDuplicates lists links a crawled page repeats.
+	ReportDuplicates bool
+	// ReportDir also writes one report file per source page there. It
+	// holds every result until the end of the run, so it cannot be
+	// combined with LowMemory.
+	ReportDir string
+	// StopOnFirstDead ends the run at the first dead link found.
+	StopOnFirstDead bool
+	// ReportRedirectTargets groups redirected links by final URL.
+	ReportRedirectTargets bool
+	// AdaptiveConcurrency lets the number of concurrent checks fall to
+	// MinConcurrency while errors and throttling pile up, and climb back to
+	// Concurrency once checks are healthy.
+	AdaptiveConcurrency bool
+	MinConcurrency      int
+
+	// ReportRedirectHosts lists external hosts internal links reach only
+	// through redirects.
+	ReportRedirectHosts bool
+	// ReportStale lists the StaleTop live links with the oldest
+	// Last-Modified.
+	ReportStale bool
+	StaleTop    int
+	// TopBrokenSources ranks this many pages by the dead links on them
+	// (0 = off). It keeps every source of each link, whatever
+	// MaxSourcesPerLink says.
+	TopBrokenSources int
+
+	// ValidateOnly crawls but checks nothing: it lists the links whose
+	// syntax alone rules them out (see usecase.WriteLint).
+	ValidateOnly bool
+
+	// GitDiff switches to checking local files: only HTML/Markdown files
+	// changed since this git revision (in the repo at GitDir) are checked.
+	// Their relative links are checked as local files, so "file" joins
+	// Schemes.
+	GitDiff string
+	GitDir  string
+
+	// HAR switches to checking the request URLs of this HAR capture file,
+	// and WARC to the URLs this WARC file holds responses for;
+	// HARExtractHTML also checks links in their captured HTML responses.
+	HAR            string
+	WARC           string
+	HARExtractHTML bool
+
+	// HealthyBody lists content assertions: a link matching a rule's URL
+	// pattern is dead unless its 2xx body matches the rule's regexp.
+	HealthyBody []BodyRule
+
+	// CaptureHeaders names response headers to include in each result of
+	// the JSON output.
+	CaptureHeaders []string
+
+	// Inventory, when set, also writes every discovered link with its
+	// metadata to this file as JSON.
+	Inventory string
+
+	// LinkGraph, when set, is where the crawl's link graph is kept between
+	// runs: it is loaded first (if it exists) so unchanged pages answer 304
+	// and keep their prior links, and rewritten after a complete crawl (not
+	// one cut short by MaxPages or cancellation).
+	LinkGraph string
+
+	// SavePages, when set, is a directory every page fetched for crawling
+	// is saved to; Replay crawls from such a directory instead of the
+	// network. Only the crawl is replayed: links are still checked live,
+	// except the pages whose saved result is reused.
+	SavePages string
+	Replay    string
+
+	// UnixSocket sends every HTTP request to this Unix domain socket; URLs
+	// keep their host, which becomes just the Host header.
+	UnixSocket string
+	// Resolve dials "host:port" keys at their "addr:port" values instead of
+	// resolving them.
+	Resolve map[string]string
+	// ShareCookies keeps the cookies responses set in one jar shared by the
+	// crawler and the checker, so a session cookie a crawled page sets is
+	// sent with later requests, checks included.
+	ShareCookies bool
+	// MinTLS is the lowest TLS version ("1.0" to "1.3") connections
+	// accept; links served only over older versions fail with a TLS
+	// error. Empty keeps Go's default (1.2).
+	MinTLS string
+	// Regions, when set, runs the whole check once per region, each with
+	// its own Resolve overrides on top of Resolve, and reports the links
+	// whose outcome differs between regions. It crawls and prints a text
+	// report, so it cannot be combined with HAR, WARC, GitDiff, other
+	// formats, LowMemory, ReportDir, Inventory or LinkGraph.
+	Regions []Region
+
+	// StripCacheBust lists query parameters (v, ver, hash, ...) ignored when
+	// deduping URLs, so versioned assets match across builds. Links are
+	// still checked with them.
+	StripCacheBust []string
+	// NormalizeEscapes also dedups URLs that differ only in
+	// percent-encoding, per RFC 3986 (see store.WithEscapeNormalization).
+	NormalizeEscapes bool
+	// VisitedBloom keeps the crawled pages in a fixed-size bloom filter
+	// sized for VisitedBloomSize pages (default 1,000,000) instead of an
+	// exact set (see store.WithVisitedBloom). It implies NoSelfLinks.
+	// Discovered links still take memory for each distinct link.
+	VisitedBloom     bool
+	VisitedBloomSize int
+	// MaxSourcesPerLink caps the pages kept per link as its sources
+	// (0 = no limit); they are still all counted (see store.WithMaxSources).
+	// TopBrokenSources and ReportDir lift the cap, as they need every page
+	// a link is on.
+	MaxSourcesPerLink int
+
+	// URLKey, when set, replaces the default URL normalization used to
+	// dedup pages and links (see store.WithURLKeyFunc).
+	URLKey func(string) string
+
+	// Webhook, when set, gets the JSON report (see usecase.WriteJSON)
+	// POSTed after the run: only when it fails (WebhookOnFailure, the
+	// default) or always (WebhookOnAlways).
+	Webhook   string
+	WebhookOn string
+	// Log gets warnings that do not fail the run, such as an undelivered
+	// webhook; nil discards them.
+	Log io.Writer
 }
 
 func Run(ctx context.Context, cfg Config, stdout io.Writer) error {
+	if err := cfg.applyDefaults(); err != nil {
+		return err
+	}
+	if cfg.oneShot() {
+		return cfg.checkOne(ctx, stdout)
+	}
+	if cfg.ValidateOnly {
+		return cfg.validate(ctx, stdout)
+	}
+	if len(cfg.Regions) > 0 {
+		return cfg.runRegions(ctx, stdout)
+	}
+	p := build(cfg)
+	defer p.Close()
+
+	_, verdict, err := p.check(ctx, cfg, stdout)
+	if err != nil {
+		return err
+	}
+	return verdict
+}
+
+// check runs cfg and judges it: err is why the run could not complete,
+// verdict why its report fails cfg (see Config.verdict). The webhook hears
+// of either.
+func (p *pipeline) check(ctx context.Context, cfg Config, stdout io.Writer) (rep *usecase.Report, verdict, err error) {
+	rep, err = p.run(ctx, cfg, stdout)
+	if err != nil {
+		p.notify(ctx, cfg, rep, err)
+		return rep, nil, err
+	}
+	verdict = cfg.verdict(rep)
+	p.notify(ctx, cfg, rep, verdict)
+	return rep, verdict, nil
+}
+
+// run checks what cfg points at (a HAR or WARC file, a git diff or the start
+// URLs) and writes the inventory; the report is nil when the run failed
+// before it had one.
+func (p *pipeline) run(ctx context.Context, cfg Config, stdout io.Writer) (*usecase.Report, error) {
+	var rep *usecase.Report
+	var err error
+	switch {
+	case cfg.HAR != "":
+		f, ferr := os.Open(cfg.HAR)
+		if ferr != nil {
+			return nil, ferr
+		}
+		defer f.Close()
+		rep, err = p.orch.RunHAR(ctx, f, cfg.HARExtractHTML, stdout)
+	case cfg.WARC != "":
+		f, ferr := os.Open(cfg.WARC)
+		if ferr != nil {
+			return nil, ferr
+		}
+		defer f.Close()
+		rep, err = p.orch.RunWARC(ctx, f, cfg.HARExtractHTML, stdout)
+	case cfg.GitDiff != "":
+		if cfg.GitDir == "" {
+			cfg.GitDir = "."
+		}
+		files, ferr := gitdiff.ChangedFiles(ctx, cfg.GitDir, cfg.GitDiff)
+		if ferr != nil {
+			return nil, ferr
+		}
+		rep, err = p.orch.RunFiles(ctx, files, stdout)
+	default:
+		if err := p.loadGraph(cfg.LinkGraph); err != nil {
+			return nil, err
+		}
+		rep, err = p.orch.RunSeeds(ctx, cfg.seeds(), stdout)
+		if err == nil {
+			err = p.saveGraph(cfg.LinkGraph)
+		}
+	}
+	if errors.Is(err, usecase.ErrStartDisallowed) {
+		return rep, fmt.Errorf("%w; use --ignore-robots to override", err)
+	}
+	if err != nil {
+		return rep, err
+	}
+	return rep, p.writeInventory(cfg.Inventory)
+}
+
+func (cfg *Config) applyDefaults() error {
 	if cfg.UserAgent == "" {
-		cfg.UserAgent = "deadlink-learning-bot/0.1"
+		cfg.UserAgent = DefaultUserAgent
 	}
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 10 * time.Second
 	}
+	if cfg.CrawlTimeout <= 0 {
+		cfg.CrawlTimeout = cfg.Timeout
+	}
+	if cfg.CheckTimeout <= 0 {
+		cfg.CheckTimeout = cfg.Timeout
+	}
+	if cfg.FailOn == "" {
+		cfg.FailOn = FailOnDead
+	}
+	switch cfg.WebhookOn {
+	case "":
+		cfg.WebhookOn = WebhookOnFailure
+	case WebhookOnFailure, WebhookOnAlways:
+	default:
+		return fmt.Errorf("invalid webhook-on %q (want %q or %q)", cfg.WebhookOn, WebhookOnFailure, WebhookOnAlways)
+	}
+	if cfg.ContentSelector != "" {
+		if _, err := extract.ParseSelector(cfg.ContentSelector); err != nil {
+			return fmt.Errorf("content selector: %w", err)
+		}
+	}
+	if cfg.MinTLS != "" {
+		if _, err := httpclient.ParseTLSVersion(cfg.MinTLS); err != nil {
+			return fmt.Errorf("min-tls: %w", err)
+		}
+	}
+	if cfg.HAR != "" && cfg.WARC != "" {
+		return errors.New("har and warc cannot be combined")
+	}
+	if cfg.SavePages != "" && cfg.Replay != "" {
+		return errors.New("save-pages and replay cannot be combined")
+	}
+	if cfg.MinPages > 0 {
+		switch {
+		case cfg.HAR != "":
+			return errors.New("min-pages and har cannot be combined")
+		case cfg.WARC != "":
+			return errors.New("min-pages and warc cannot be combined")
+		case cfg.GitDiff != "":
+			return errors.New("min-pages and git-diff cannot be combined")
+		case cfg.oneShot():
+			return errors.New("min-pages needs a crawl; max-depth -1 crawls nothing")
+		case cfg.MinPages > cfg.MaxPages:
+			return fmt.Errorf("min-pages %d is over max-pages %d", cfg.MinPages, cfg.MaxPages)
+		}
+	}
+	if cfg.ReportDir != "" && cfg.LowMemory {
+		return errors.New("report-dir and low-memory cannot be combined")
+	}
+	if cfg.Replay != "" {
+		if fi, err := os.Stat(cfg.Replay); err != nil || !fi.IsDir() {
+			return fmt.Errorf("replay: %s is not a directory", cfg.Replay)
+		}
+	}
+	if cfg.Webhook != "" {
+		if err := validateWebhook(cfg.Webhook); err != nil {
+			return err
+		}
+	}
+	if cfg.VisitedBloom && cfg.VisitedBloomSize <= 0 {
+		cfg.VisitedBloomSize = 1_000_000
+	}
+	switch cfg.Format {
+	case "":
+		cfg.Format = usecase.FormatText
+	case usecase.FormatText, usecase.FormatNDJSON, usecase.FormatJSON, usecase.FormatCSV, usecase.FormatLychee:
+	default:
+		return fmt.Errorf("invalid format %q (want %q, %q, %q, %q or %q)", cfg.Format, usecase.FormatText, usecase.FormatNDJSON, usecase.FormatJSON, usecase.FormatCSV, usecase.FormatLychee)
+	}
+	if cfg.Format == usecase.FormatJSON && cfg.LowMemory {
+		return errors.New("format json and low-memory cannot be combined; format ndjson streams the results instead")
+	}
+	if len(cfg.Columns) > 0 && cfg.Format != usecase.FormatCSV {
+		return fmt.Errorf("columns need format %q", usecase.FormatCSV)
+	}
+	if err := usecase.ValidateColumns(cfg.Columns); err != nil {
+		return err
+	}
+	if len(cfg.Regions) > 0 {
+		if err := cfg.checkRegions(); err != nil {
+			return err
+		}
+	}
+	switch cfg.ReportScope {
+	case "":
+		cfg.ReportScope = usecase.ScopeAll
+	case usecase.ScopeAll, usecase.ScopeInternal, usecase.ScopeExternal:
+	default:
+		return fmt.Errorf("invalid report-scope %q (want %q, %q or %q)", cfg.ReportScope, usecase.ScopeInternal, usecase.ScopeExternal, usecase.ScopeAll)
+	}
+	switch cfg.Scope {
+	case "":
+		cfg.Scope = usecase.HostScopeExact
+	case usecase.HostScopeExact, usecase.HostScopeETLD1:
+	default:
+		return fmt.Errorf("invalid scope %q (want %q or %q)", cfg.Scope, usecase.HostScopeExact, usecase.HostScopeETLD1)
+	}
+	if cfg.FailOn != FailOnDead && cfg.FailOn != FailOnNone {
+		return fmt.Errorf("invalid fail-on %q (want %q or %q)", cfg.FailOn, FailOnDead, FailOnNone)
+	}
+	// The crawl itself is over http(s), so those are kept whatever else is
+	// listed.
+	schemes := []string{"http", "https"}
+	for _, s := range cfg.Schemes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		switch s {
+		case "http", "https":
+			continue
+		case "ftp", "ws", "wss":
+		default:
+			return fmt.Errorf("unsupported scheme %q (want http, https, ftp, ws or wss)", s)
+		}
+		if !slices.Contains(schemes, s) {
+			schemes = append(schemes, s)
+		}
+	}
+	if cfg.GitDiff != "" {
+		schemes = append(schemes, "file")
+	}
+	cfg.Schemes = schemes
+	return nil
+}
+
+// seeds returns StartURL followed by the extra Seeds.
+func (cfg Config) seeds() []string {
+	var seeds []string
+	if cfg.StartURL != "" {
+		seeds = append(seeds, cfg.StartURL)
+	}
+	return append(seeds, cfg.Seeds...)
+}
+
+// verdict turns a finished report into Run's error.
+func (cfg Config) verdict(rep *usecase.Report) error {
+	if err := cfg.coverage(rep.Crawled); err != nil {
+		return err
+	}
+	if cfg.MaxErrors > 0 && rep.Errors > cfg.MaxErrors {
+		return fmt.Errorf("%w: %d errors (max %d)", ErrCheckErrors, rep.Errors, cfg.MaxErrors)
+	}
+	if cfg.FailOn == FailOnDead && rep.Failures > 0 {
+		return ErrDeadLinks
+	}
+	return nil
+}
+
+// coverage returns ErrLowCoverage when crawled is under MinPages.
+func (cfg Config) coverage(crawled int) error {
+	if crawled < cfg.MinPages {
+		return fmt.Errorf("%w: %d pages crawled (min %d)", ErrLowCoverage, crawled, cfg.MinPages)
+	}
+	return nil
+}
 
-	httpc := httpclient.New(cfg.Timeout)
-	lim := limiter.New(cfg.Rate, cfg.PerHostRate)
-	ext := extractor.New()
-	st := store.NewMemory()
+// Rates is a global and default per-host request rate in req/sec.
+type Rates struct {
+	Global  int
+	PerHost int
+}
+
+// pipeline is everything one run needs, wired from a Config.
+type pipeline struct {
+	scanner *Scanner
+	store   *store.Memory
+	crawler *usecase.Crawler
+	orch    *usecase.Orchestrator
+}
+
+// build wires a pipeline around a Scanner of its own, which the
+// pipeline's Close shuts down.
+func build(cfg Config) *pipeline {
+	return newScanner(cfg).pipeline(cfg)
+}
+
+// staleTop is how many stale links the report lists (0 = none).
+func (cfg Config) staleTop() int {
+	if !cfg.ReportStale {
+		return 0
+	}
+	return cfg.StaleTop
+}
+
+// externalDepth is the crawler's external-link depth limit (-1 = none).
+func (cfg Config) externalDepth() int {
+	if !cfg.LimitExternalDepth {
+		return -1
+	}
+	return cfg.ExternalDepth
+}
+
+// maxSources is how many sources the store keeps per link (0 = all).
+func (cfg Config) maxSources() int {
+	if cfg.TopBrokenSources > 0 || cfg.ReportDir != "" {
+		return 0
+	}
+	return cfg.MaxSourcesPerLink
+}
 
-	crawler := usecase.NewCrawler(httpc, ext, lim, cfg.UserAgent, cfg.Timeout, cfg.MaxDepth, cfg.MaxPages, cfg.CheckAssets)
-	checker := usecase.NewLinkChecker(cfg.Timeout, cfg.HeadFirst, lim)
+// checkerConfig is the checker's part of cfg; its requests, HTTP or not,
+// go through tr.
+func (cfg Config) checkerConfig(tr *http.Transport) usecase.CheckerConfig {
+	return usecase.CheckerConfig{
+		Timeout:      cfg.CheckTimeout,
+		HeadFirst:    cfg.HeadFirst,
+		Retries:      cfg.Retries,
+		RetryBackoff: cfg.RetryBackoff,
+		ConfirmEmpty: cfg.FlagEmpty,
+		ErrorTitles:  cfg.ErrorTitles,
+
+		RetryStatuses: cfg.RetryStatuses,
+		OKStatusCodes: cfg.OKStatusCodes,
+
+		CaptureHeaders: cfg.CaptureHeaders,
+		HealthyBody:    cfg.HealthyBody,
+
+		Transport:      tr,
+		UserAgent:      cfg.UserAgent,
+		SchemeCheckers: schemeCheckers(cfg, tr),
+	}
+}
+
+// BodyRule is a content assertion (see Config.HealthyBody).
+type BodyRule = usecase.BodyRule
+
+// schemeCheckers returns the non-HTTP checkers for the enabled schemes;
+// WebSocket handshakes go through tr.
+func schemeCheckers(cfg Config, tr *http.Transport) map[string]ports.SchemeChecker {
+	out := map[string]ports.SchemeChecker{}
+	for _, s := range cfg.Schemes {
+		switch s {
+		case "ftp":
+			out[s] = ftpcheck.New(cfg.CheckTimeout)
+		case "ws", "wss":
+			out[s] = wscheck.New(cfg.CheckTimeout, tr, cfg.UserAgent)
+		case "file":
+			out[s] = filecheck.New()
+		}
+	}
+	return out
+}
+
+// writeInventory writes the store's discovered links to path as JSON; an
+// empty path writes nothing.
+func (p *pipeline) writeInventory(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("inventory: %w", err)
+	}
+	err = usecase.WriteInventory(f, p.store.AllDiscovered())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("inventory: %w", err)
+	}
+	return nil
+}
+
+// loadGraph makes the link graph at path the crawler's prior one; an empty
+// path or a missing file (the first run) loads nothing.
+func (p *pipeline) loadGraph(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("link graph: %w", err)
+	}
+	defer f.Close()
+
+	prior, err := usecase.ReadGraph(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	p.crawler.Prior = prior
+	return nil
+}
+
+// saveGraph writes the crawler's link graph to path; an empty path or an
+// incomplete crawl, whose graph would drop the pages it missed, writes
+// nothing.
+func (p *pipeline) saveGraph(path string) error {
+	if path == "" || p.crawler.Incomplete() {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("link graph: %w", err)
+	}
+	err = usecase.WriteGraph(f, p.crawler.Graph())
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("link graph: %w", err)
+	}
+	return nil
+}
 
-	orch := usecase.NewOrchestrator(crawler, checker, st, cfg.AllowExternal, cfg.Concurrency, cfg.Timeout, cfg.ProgressEvery)
-	return orch.Run(ctx, cfg.StartURL, stdout)
+func (p *pipeline) Close() {
+	p.scanner.Close()
 }
@@ -0,0 +1,833 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newSite(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/moved">moved</a>`))
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusFound)
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRun_StrictPromotesWarnings(t *testing.T) {
+	srv := newSite(t)
+
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    0,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("warning-only run should pass, got %v", err)
+	}
+
+	cfg.Strict = true
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("strict run should fail with ErrDeadLinks, got %v", err)
+	}
+}
+
+func TestRun_SeparateCrawlAndCheckTimeouts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Only the crawl fetch (GET) of the start page is slow.
+		if r.Method == http.MethodGet {
+			time.Sleep(150 * time.Millisecond)
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/slow">slow</a>`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(150 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	base := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+
+	// A short crawl timeout loses the start page's links, while the generous
+	// check timeout still lets the page itself check OK.
+	cfg := base
+	cfg.CrawlTimeout = 50 * time.Millisecond
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); err != nil {
+		t.Fatalf("expected pass, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "Discovered links: 1\n") {
+		t.Fatalf("expected only the start page to be discovered:\n%s", out.String())
+	}
+
+	// A short check timeout fails /slow, which the crawl found fine.
+	cfg = base
+	cfg.CheckTimeout = 50 * time.Millisecond
+	out.Reset()
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "DEAD ERR   "+srv.URL+"/slow") {
+		t.Fatalf("expected /slow to time out:\n%s", out.String())
+	}
+}
+
+func TestRun_CheckJSONLD(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><script type="application/ld+json">
+			{"@context": "https://schema.org", "@type": "Organization", "url": "/", "logo": "/logo.png"}
+		</script></head><body>home</body></html>`))
+	})
+	srv := httptest.NewServer(mux) // /logo.png is a 404
+	defer srv.Close()
+
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		CheckAssets: true,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("without CheckJSONLD the logo is not seen, got %v", err)
+	}
+
+	cfg.CheckJSONLD = true
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "DEAD 404   "+srv.URL+"/logo.png") {
+		t.Fatalf("expected the dead logo to be reported:\n%s", out.String())
+	}
+}
+
+func TestRun_CheckSocial(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<html><head><meta property="og:image" content="/preview.png"></head><body>home</body></html>`))
+	})
+	srv := httptest.NewServer(mux) // /preview.png is a 404
+	defer srv.Close()
+
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		CheckAssets: true,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("without CheckSocial the preview image is not seen, got %v", err)
+	}
+
+	cfg.CheckSocial = true
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "DEAD 404   "+srv.URL+"/preview.png") {
+		t.Fatalf("expected the dead preview image to be reported:\n%s", out.String())
+	}
+}
+
+func TestRun_OneShot(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.URL.Path == "/gone" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/gone">gone</a>`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := Config{StartURL: srv.URL + "/", MaxDepth: -1, HeadFirst: true, Timeout: 2 * time.Second}
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "OK   200   "+srv.URL+"/ (") || strings.Count(out.String(), "\n") != 1 {
+		t.Fatalf("expected one OK line, got:\n%s", out.String())
+	}
+	// No crawl: the page is not fetched and its link is not checked.
+	if got := strings.Join(requests, ", "); got != "HEAD /" {
+		t.Fatalf("expected a single HEAD request, got %s", got)
+	}
+
+	cfg.StartURL = srv.URL + "/gone"
+	out.Reset()
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "DEAD 404   "+srv.URL+"/gone (") {
+		t.Fatalf("expected a DEAD line, got:\n%s", out.String())
+	}
+
+	// Expected dead is not a failure, in one-shot as in a run.
+	cfg.ExpectDead = []string{srv.URL + "/gone"}
+	out.Reset()
+	if err := Run(context.Background(), cfg, &out); err != nil {
+		t.Fatalf("expected dead link: %v\n%s", err, out.String())
+	}
+	cfg.ExpectDead = nil
+
+	// Other formats write the records a run would.
+	cfg.Format = "json"
+	out.Reset()
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+	var doc struct {
+		Summary struct {
+			Checked  int `json:"checked"`
+			Failures int `json:"failures"`
+		} `json:"summary"`
+		Results []struct {
+			URL  string `json:"url"`
+			Dead bool   `json:"dead"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("json output: %v\n%s", err, out.String())
+	}
+	if doc.Summary.Checked != 1 || doc.Summary.Failures != 1 || len(doc.Results) != 1 || !doc.Results[0].Dead {
+		t.Fatalf("unexpected json output:\n%s", out.String())
+	}
+	cfg.Format = ""
+
+	cfg.Seeds = []string{srv.URL + "/other"}
+	if err := Run(context.Background(), cfg, io.Discard); err == nil || errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("one-shot with several URLs should be rejected, got %v", err)
+	}
+}
+
+func TestRun_Regions(t *testing.T) {
+	// Both regions serve the same page; /x is down only in "us".
+	region := func(xStatus int) *httptest.Server {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<a href="/x">x</a><a href="/y">y</a>`))
+		})
+		mux.HandleFunc("/x", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(xStatus) })
+		mux.HandleFunc("/y", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+		srv := httptest.NewServer(mux)
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	eu, us := region(http.StatusOK), region(http.StatusServiceUnavailable)
+	hook, got := receiver(t, http.StatusNoContent)
+
+	cfg := Config{
+		StartURL:    "http://site.invalid/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    0,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Webhook:     hook.URL,
+		Regions: []Region{
+			{Name: "eu", Resolve: map[string]string{"site.invalid:80": eu.Listener.Addr().String()}},
+			{Name: "us", Resolve: map[string]string{"site.invalid:80": us.Listener.Addr().String()}},
+		},
+	}
+
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("a region with a dead link should fail the run, got %v", err)
+	}
+	want := "Region eu: checked 3  OK 3  failures 0\n" +
+		"Region us: checked 3  OK 2  failures 1\n" +
+		"\nLinks whose outcome differs by region:\n" +
+		"  http://site.invalid/x\n    eu: 200\n    us: 503\n"
+	if out.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", out.String(), want)
+	}
+	// Only the failing region notifies, with its own report.
+	if len(*got) != 1 || !strings.Contains(string((*got)[0]), `"error":"region us: dead links found"`) {
+		t.Fatalf("expected one webhook for region us, got %d: %s", len(*got), bytes.Join(*got, []byte("\n")))
+	}
+
+	// What the combined report cannot show is rejected, not dropped.
+	for name, mod := range map[string]func(*Config){
+		"format":     func(c *Config) { c.Format = "json" },
+		"low-memory": func(c *Config) { c.LowMemory = true },
+		"report-dir": func(c *Config) { c.ReportDir = t.TempDir() },
+		"inventory":  func(c *Config) { c.Inventory = filepath.Join(t.TempDir(), "inventory.json") },
+		"link-graph": func(c *Config) { c.LinkGraph = filepath.Join(t.TempDir(), "graph.json") },
+		"har":        func(c *Config) { c.HAR = "capture.har" },
+	} {
+		bad := cfg
+		mod(&bad)
+		if err := Run(context.Background(), bad, io.Discard); err == nil || errors.Is(err, ErrDeadLinks) {
+			t.Errorf("%s: expected regions to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestRun_Inventory(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/a">a</a><img src="/logo.png"><a href="mailto:x@example.com">mail</a>`))
+	})
+	mux.HandleFunc("/a", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/">home</a>`))
+	})
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    1,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		CheckAssets: true,
+		Inventory:   path,
+	}
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	type record struct {
+		URL            string   `json:"url"`
+		Kind           string   `json:"kind"`
+		FirstSeenDepth int      `json:"first_seen_depth"`
+		Skipped        string   `json:"skipped"`
+		Sources        []string `json:"sources"`
+	}
+	var got []record
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("decode inventory: %v\n%s", err, data)
+	}
+
+	// A crawled page is recorded with itself as a source.
+	home := srv.URL + "/"
+	want := []record{
+		{URL: home, Kind: "page", Sources: []string{home, srv.URL + "/a"}},
+		{URL: srv.URL + "/a", Kind: "page", Sources: []string{home, srv.URL + "/a"}},
+		{URL: srv.URL + "/logo.png", Kind: "asset", Sources: []string{home}},
+		{URL: "mailto:x@example.com", Kind: "page", Skipped: "unsupported_scheme", Sources: []string{home}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("inventory:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestRun_ValidateOnly(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="/a">a</a><a href="http://[::1">bad</a><a href="www.example.com">host</a>` +
+			`<a href="mailto:x@example.com">mail</a><a href="#top">top</a><a href="https://example.invalid/">ext</a>`))
+	})
+	// robots.txt is read for the crawl; nothing else may be requested.
+	mux.HandleFunc("/robots.txt", http.NotFound)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := Config{
+		StartURL:      srv.URL + "/",
+		Timeout:       2 * time.Second,
+		MaxPages:      10,
+		Rate:          100,
+		PerHostRate:   100,
+		AllowExternal: true,
+		ValidateOnly:  true,
+	}
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("run: got %v, want ErrDeadLinks", err)
+	}
+	if len(requested) > 0 {
+		t.Fatalf("validate-only made requests: %v", requested)
+	}
+
+	for _, want := range []string{
+		`invalid_url        "http://[::1"`,
+		`scheme_less_host   "` + srv.URL + `/www.example.com"`,
+		`unsupported_scheme "mailto:x@example.com"`,
+		`fragment_only      "#top"`,
+		"    found on : " + srv.URL + "/\n",
+		"Lint findings: 4 (2 malformed)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), srv.URL+"/a") {
+		t.Errorf("well-formed link reported:\n%s", out.String())
+	}
+}
+
+func TestRun_ShareCookies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s3cret", Path: "/"})
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<img src="/private/logo.png">`))
+	})
+	mux.HandleFunc("/private/", func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("session"); err != nil || c.Value != "s3cret" {
+			w.WriteHeader(http.StatusForbidden)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		CheckAssets: true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("without a shared jar: expected ErrDeadLinks, got %v", err)
+	}
+	cfg.ShareCookies = true
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("with the crawl's session cookie: %v", err)
+	}
+}
+
+func TestRun_TopBrokenSourcesKeepsEverySource(t *testing.T) {
+	mux := http.NewServeMux()
+	for path, body := range map[string]string{
+		"/{$}": `<a href="/p1">p1</a><a href="/p2">p2</a>`,
+		"/p1":  `<a href="/gone">gone</a>`,
+		"/p2":  `<a href="/gone">gone</a>`,
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	cfg := Config{
+		StartURL:          srv.URL + "/",
+		Timeout:           2 * time.Second,
+		HeadFirst:         true,
+		MaxDepth:          2,
+		MaxPages:          10,
+		Rate:              100,
+		PerHostRate:       100,
+		MaxSourcesPerLink: 1,
+		TopBrokenSources:  5,
+	}
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v\n%s", err, out.String())
+	}
+	_, ranking, ok := strings.Cut(out.String(), "Top broken-link sources")
+	if !ok || !strings.Contains(ranking, srv.URL+"/p1") || !strings.Contains(ranking, srv.URL+"/p2") {
+		t.Fatalf("expected both pages ranked despite -max-sources-per-link 1:\n%s", out.String())
+	}
+}
+
+func TestRun_ReportDirKeepsEverySource(t *testing.T) {
+	mux := http.NewServeMux()
+	for path, body := range map[string]string{
+		"/{$}": `<a href="/p1">p1</a><a href="/p2">p2</a>`,
+		"/p1":  `<a href="/gone">gone</a>`,
+		"/p2":  `<a href="/gone">gone</a>`,
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := Config{
+		StartURL:          srv.URL + "/",
+		Timeout:           2 * time.Second,
+		HeadFirst:         true,
+		MaxDepth:          2,
+		MaxPages:          10,
+		Rate:              100,
+		PerHostRate:       100,
+		MaxSourcesPerLink: 1,
+		ReportDir:         dir,
+	}
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var withGone []string
+	for _, e := range entries {
+		b, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(b), "DEAD 404   "+srv.URL+"/gone\n") && !strings.HasPrefix(string(b), "# "+srv.URL+"/gone\n") {
+			withGone = append(withGone, e.Name())
+		}
+	}
+	if len(withGone) != 2 {
+		t.Fatalf("/gone is in page reports %v, want both p1 and p2 despite -max-sources-per-link 1", withGone)
+	}
+
+	cfg.LowMemory = true
+	if err := Run(context.Background(), cfg, io.Discard); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected report-dir with low-memory to be rejected, got %v", err)
+	}
+}
+
+func TestRun_JSONRejectsLowMemory(t *testing.T) {
+	site := newSite(t)
+	cfg := Config{StartURL: site.URL + "/", Format: "json", LowMemory: true}
+	var out bytes.Buffer
+	if err := Run(context.Background(), cfg, &out); err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected json with low-memory to be rejected, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Fatalf("nothing should be written, got:\n%s", out.String())
+	}
+}
+
+func TestRun_LinkGraph(t *testing.T) {
+	mux := http.NewServeMux()
+	for path, body := range map[string]string{
+		"/{$}": `<a href="/p1">p1</a>`,
+		"/p1":  `<a href="/">home</a>`,
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	graph := filepath.Join(t.TempDir(), "graph.json")
+	cfg := Config{
+		StartURL:    srv.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    2,
+		MaxPages:    1,
+		Rate:        100,
+		PerHostRate: 100,
+		LinkGraph:   graph,
+	}
+	// Cut short by max-pages: the graph would lack /p1, so it is not saved.
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if _, err := os.Stat(graph); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("graph saved after a partial crawl: %v", err)
+	}
+
+	cfg.MaxPages = 10
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	b, err := os.ReadFile(graph)
+	if err != nil {
+		t.Fatalf("graph not saved after a full crawl: %v", err)
+	}
+	if !strings.Contains(string(b), srv.URL+"/p1") {
+		t.Fatalf("graph lacks /p1:\n%s", b)
+	}
+
+	cfg.LinkGraph = filepath.Join(t.TempDir(), "missing", "graph.json")
+	err = Run(context.Background(), cfg, io.Discard)
+	if err == nil || strings.Count(err.Error(), "link graph") != 1 {
+		t.Fatalf("expected one link graph error, got %v", err)
+	}
+}
+
+func TestRun_ExternalDepthZeroValueIsNoLimit(t *testing.T) {
+	ext := httptest.NewServer(http.NotFoundHandler())
+	defer ext.Close()
+	// Another host name than the site's 127.0.0.1, so it is external.
+	extURL := strings.Replace(ext.URL, "127.0.0.1", "localhost", 1)
+	mux := http.NewServeMux()
+	for path, body := range map[string]string{
+		"/{$}": `<a href="/p1">p1</a>`,
+		"/p1":  `<a href="` + extURL + `/gone">gone</a>`,
+	} {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(body))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cfg := Config{
+		StartURL:      srv.URL + "/",
+		Timeout:       2 * time.Second,
+		HeadFirst:     true,
+		MaxDepth:      2,
+		MaxPages:      10,
+		Rate:          100,
+		PerHostRate:   100,
+		AllowExternal: true,
+	}
+	// The external link is on a depth-1 page: checked without a limit...
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected the external link checked, got %v", err)
+	}
+	// ...and skipped with a limit of the seeds.
+	cfg.LimitExternalDepth = true
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("expected the external link skipped, got %v", err)
+	}
+}
+
+func TestRun_SchemesKeepHTTP(t *testing.T) {
+	site := brokenSite(t)
+	cfg := Config{
+		StartURL:    site.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Schemes:     []string{"ftp"},
+	}
+	// Listing ftp adds it; the http links are still checked.
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v", err)
+	}
+}
+
+func TestRun_WebSocketLinksShareTransport(t *testing.T) {
+	var ua string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<a href="ws://site.invalid/socket">socket</a>`))
+	})
+	mux.HandleFunc("/socket", func(w http.ResponseWriter, r *http.Request) {
+		ua = r.UserAgent()
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n")
+		_ = buf.Flush()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// site.invalid only resolves through -resolve, as the crawl's does.
+	cfg := Config{
+		StartURL:    "http://site.invalid/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		Schemes:     []string{"ws"},
+		UserAgent:   "test-bot/1.0",
+		Resolve:     map[string]string{"site.invalid:80": srv.Listener.Addr().String()},
+	}
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if ua != "test-bot/1.0" {
+		t.Fatalf("handshake sent User-Agent %q, want test-bot/1.0", ua)
+	}
+}
+
+func TestRun_GitDiffChecksRelativeLinks(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	write := func(name, body string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	write("doc.md", "# doc\n")
+	write("other.md", "# other\n")
+	git("add", ".")
+	git("commit", "-q", "-m", "init")
+	write("doc.md", "See [other](other.md#intro) and [gone](missing.md).\n")
+
+	var out bytes.Buffer
+	cfg := Config{GitDiff: "HEAD", GitDir: dir, Timeout: 2 * time.Second}
+	if err := Run(context.Background(), cfg, &out); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("expected ErrDeadLinks, got %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "missing.md") {
+		t.Fatalf("expected missing.md reported dead:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "Checked links: 2") {
+		t.Fatalf("expected both relative links checked:\n%s", out.String())
+	}
+}
+
+func TestRun_MinPages(t *testing.T) {
+	site := newSite(t)
+	base := Config{
+		StartURL:    site.URL + "/",
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+		MinPages:    5,
+	}
+
+	// One page crawled under min-pages 5, whatever the mode.
+	validate := base
+	validate.ValidateOnly = true
+	for name, run := range map[string]func() error{
+		"run":           func() error { return Run(context.Background(), base, io.Discard) },
+		"validate-only": func() error { return Run(context.Background(), validate, io.Discard) },
+		"crawl":         func() error { return Crawl(context.Background(), base, io.Discard) },
+	} {
+		if err := run(); !errors.Is(err, ErrLowCoverage) {
+			t.Errorf("%s: expected ErrLowCoverage, got %v", name, err)
+		}
+	}
+
+	for name, mod := range map[string]func(*Config){
+		"har":       func(c *Config) { c.HAR = "capture.har" },
+		"warc":      func(c *Config) { c.WARC = "crawl.warc" },
+		"git-diff":  func(c *Config) { c.GitDiff = "HEAD" },
+		"one-shot":  func(c *Config) { c.MaxDepth = -1 },
+		"max-pages": func(c *Config) { c.MaxPages = 4 },
+	} {
+		cfg := base
+		mod(&cfg)
+		if err := cfg.applyDefaults(); err == nil {
+			t.Errorf("%s: expected min-pages to be rejected", name)
+		}
+	}
+}
+
+func TestRun_VisitedBloomKeepsStoreSmall(t *testing.T) {
+	site := newSite(t)
+	cfg := Config{
+		StartURL:     site.URL + "/",
+		Timeout:      2 * time.Second,
+		HeadFirst:    true,
+		MaxPages:     10,
+		Rate:         100,
+		PerHostRate:  100,
+		VisitedBloom: true,
+	}
+	if err := cfg.applyDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	p := build(cfg)
+	defer p.Close()
+	if _, verdict, err := p.check(context.Background(), cfg, io.Discard); err != nil || verdict != nil {
+		t.Fatalf("run: %v, %v", err, verdict)
+	}
+
+	// The crawled page is not a link of its own, and its result is not kept.
+	for _, m := range p.store.AllDiscovered() {
+		if m.URL == site.URL+"/" {
+			t.Fatalf("start page recorded as a link: %+v", m)
+		}
+	}
+	if got := p.store.PageResults(); len(got) != 0 {
+		t.Fatalf("expected no page results kept, got %+v", got)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_UnixSocket(t *testing.T) {
+	// Socket paths are length-limited, so keep the directory short.
+	dir, err := os.MkdirTemp("", "dl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	sock := filepath.Join(dir, "s")
+
+	ln, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("unix sockets unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(newSite(t).Config.Handler)
+	srv.Listener.Close()
+	srv.Listener = ln
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	cfg := Config{
+		// Nothing resolves this host; only the socket can answer.
+		StartURL:    "http://site.invalid/",
+		UnixSocket:  sock,
+		Timeout:     2 * time.Second,
+		HeadFirst:   true,
+		MaxDepth:    1,
+		MaxPages:    10,
+		Rate:        100,
+		PerHostRate: 100,
+	}
+	if err := Run(context.Background(), cfg, io.Discard); err != nil {
+		t.Fatalf("run over unix socket: %v", err)
+	}
+
+	cfg.UnixSocket = ""
+	if err := Run(context.Background(), cfg, io.Discard); !errors.Is(err, ErrDeadLinks) {
+		t.Fatalf("run without the socket should find the start page dead, got %v", err)
+	}
+}
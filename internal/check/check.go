@@ -5,56 +5,203 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rojanmagar2001/godeadlink/internal/model"
 )
 
+// ErrSelfRedirect is the error of a link whose server redirects it to its
+// own URL, which would otherwise loop until the redirect limit.
+var ErrSelfRedirect = errors.New("self-redirect: the URL redirects to itself")
+
+// ErrUnhealthyBody is the error of a link that answered 2xx with a body
+// that does not match its Checker.HealthyBody regexp.
+var ErrUnhealthyBody = errors.New("health check content mismatch")
+
+// maxRedirects matches net/http's default limit.
+const maxRedirects = 10
+
+// Three independent sets of status codes decide what happens to a
+// response, and a status may be in more than one:
+//
+//   - retry: a status in Checker.RetryStatuses (DefaultRetryStatuses when
+//     nil) is retried up to Checker.Retries times, like a network error;
+//   - HEAD fallback: a HEAD answered with a status in headFallbackStatuses
+//     is tried again with GET;
+//   - ok/dead: the final status is dead from 400 up (model.Result.IsDead).
+//
+// So a 503 that persists through its retries is dead, and a 404 is dead at
+// once.
+var (
+	DefaultRetryStatuses = []int{
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	headFallbackStatuses = []int{http.StatusMethodNotAllowed, http.StatusBadRequest}
+)
+
 type Checker struct {
 	Client      *http.Client
 	HeadFirst   bool
 	MaxBodyRead int64
+	// UserAgent is sent with every request; NewChecker sets
+	// DefaultUserAgent.
+	UserAgent string
+
+	// Retries is how many extra attempts a transient failure (network error
+	// or a status in RetryStatuses) gets. RetryBackoff grows linearly per
+	// attempt.
+	Retries      int
+	RetryBackoff time.Duration
+	// RetryStatuses are the statuses worth retrying; nil means
+	// DefaultRetryStatuses.
+	RetryStatuses []int
+
+	// ConfirmEmpty re-checks with GET when a HEAD response doesn't say how
+	// big the body is, so Result.Empty is reliable.
+	ConfirmEmpty bool
+
+	// ErrorTitles re-checks with GET when a HEAD response is dead (4xx or
+	// 5xx), so Result.Title can hold the error page's title, which only a
+	// GET reads. The GET's result is the one kept.
+	ErrorTitles bool
+
+	// CaptureHeaders names response headers to copy into Result.Headers.
+	CaptureHeaders []string
+
+	// HealthyBody, if set, returns the regexp a link's 2xx body must match
+	// to be OK, or nil for none. Such links are checked with GET only, and
+	// a body that does not match (within MaxBodyRead) fails with
+	// ErrUnhealthyBody, which is not retried.
+	HealthyBody func(link string) *regexp.Regexp
 }
 
+// DefaultUserAgent is the User-Agent checks are sent with unless told
+// otherwise.
+const DefaultUserAgent = "deadlink-learning-bot/0.1"
+
 func NewChecker(timeout time.Duration, headFirst bool) *Checker {
 	return &Checker{
 		Client: &http.Client{
-			Timeout: timeout,
+			Timeout:       timeout,
+			CheckRedirect: checkRedirect,
 		},
 		HeadFirst:   headFirst,
 		MaxBodyRead: 1 << 20, // 1MB safety cap
+		UserAgent:   DefaultUserAgent,
 	}
 }
 
 func (c *Checker) Check(ctx context.Context, link string) model.Result {
-	// Try HEAD first if enabled
-	if c.HeadFirst {
-		res := c.do(ctx, http.MethodHead, link)
+	return c.CheckFrom(ctx, link, "")
+}
+
+// CheckFrom is Check with a Referer header (skipped when referer is empty),
+// for hosts that reject hotlinked assets.
+func (c *Checker) CheckFrom(ctx context.Context, link, referer string) model.Result {
+	// Every method tried ends up in the result, so a fallback is visible.
+	var methods []string
+	do := func(method string) model.Result {
+		methods = append(methods, method)
+		res := c.do(ctx, method, link, referer)
+		res.Method = strings.Join(methods, ",")
+		return res
+	}
+
+	// Try HEAD first if enabled (a body to check needs GET)
+	if c.HeadFirst && c.healthyBody(link) == nil {
+		res := do(http.MethodHead)
 		// Some servers reject HEAD; fall back to GET
-		if res.Err == nil && (res.StatusCode == http.StatusMethodNotAllowed || res.StatusCode == http.StatusBadRequest) {
-			res = c.do(ctx, http.MethodGet, link)
+		if res.Err == nil && slices.Contains(headFallbackStatuses, res.StatusCode) {
+			res = do(http.MethodGet)
+		}
+		if c.ConfirmEmpty && res.Err == nil && res.StatusCode < 300 && res.ContentLength < 0 {
+			res = do(http.MethodGet)
+		}
+		if c.ErrorTitles && res.Err == nil && res.StatusCode >= 400 && methods[len(methods)-1] == http.MethodHead {
+			res = do(http.MethodGet)
 		}
 		if res.Err != nil {
 			// If HEAD failed due to a method/specific issue, try GET once.
 			// Otherwise keep the error
 			var he *http.ProtocolError
 			if errors.As(res.Err, &he) {
-				return c.do(ctx, http.MethodGet, link)
+				return do(http.MethodGet)
 			}
 		}
 		return res
 	}
 
-	return c.do(ctx, http.MethodGet, link)
+	return do(http.MethodGet)
+}
+
+// do performs one logical request, retrying transient failures. Every
+// attempt's outcome is kept on the result so flapping links can be spotted.
+func (c *Checker) do(ctx context.Context, method, link, referer string) model.Result {
+	var attempts []model.Attempt
+	for i := 0; ; i++ {
+		res := c.once(ctx, method, link, referer)
+		attempts = append(attempts, model.Attempt{StatusCode: res.StatusCode, Err: errString(res.Err)})
+		if i >= c.Retries || !c.retryable(ctx, res) {
+			res.Attempts = attempts
+			return res
+		}
+
+		select {
+		case <-ctx.Done():
+			res.Attempts = attempts
+			return res
+		case <-time.After(c.RetryBackoff * time.Duration(i+1)):
+		}
+	}
+}
+
+func (c *Checker) retryable(ctx context.Context, res model.Result) bool {
+	if errors.Is(res.Err, ErrUnhealthyBody) {
+		return false
+	}
+	if res.Err != nil {
+		return ctx.Err() == nil
+	}
+	statuses := c.RetryStatuses
+	if statuses == nil {
+		statuses = DefaultRetryStatuses
+	}
+	return slices.Contains(statuses, res.StatusCode)
 }
 
-func (c *Checker) do(ctx context.Context, method, link string) model.Result {
+func (c *Checker) healthyBody(link string) *regexp.Regexp {
+	if c.HealthyBody == nil {
+		return nil
+	}
+	return c.HealthyBody(link)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+func (c *Checker) once(ctx context.Context, method, link, referer string) model.Result {
 	req, err := http.NewRequestWithContext(ctx, method, link, nil)
 	if err != nil {
 		return model.Result{URL: link, Err: fmt.Errorf("new request: %w", err), Elapsed: 0}
 	}
-	req.Header.Set("User-Agent", "deadlink-learning-bot/0.1")
+	req.Header.Set("User-Agent", c.UserAgent)
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
 
 	start := time.Now()
 	resp, err := c.Client.Do(req)
@@ -66,9 +213,103 @@ func (c *Checker) do(ctx context.Context, method, link string) model.Result {
 	defer resp.Body.Close()
 
 	// Drain a little body on GET to avoid some servers misbehaving / keepalive issues.
+	// An HTML error page is kept for its title.
+	empty := resp.ContentLength == 0
+	var title string
+	var bodyErr error
 	if method == http.MethodGet {
-		_, _ = io.CopyN(io.Discard, resp.Body, c.MaxBodyRead)
+		var n int64
+		healthy := c.healthyBody(link)
+		switch {
+		case resp.StatusCode >= 400 && isHTML(resp.Header.Get("Content-Type")):
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, c.MaxBodyRead))
+			n, title = int64(len(body)), PageTitle(body)
+		case healthy != nil && resp.StatusCode < 300:
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, c.MaxBodyRead))
+			n = int64(len(body))
+			if !healthy.Match(body) {
+				bodyErr = fmt.Errorf("%w: body does not match %s", ErrUnhealthyBody, healthy)
+			}
+		default:
+			n, _ = io.CopyN(io.Discard, resp.Body, c.MaxBodyRead)
+		}
+		empty = n == 0
 	}
 
-	return model.Result{URL: link, StatusCode: resp.StatusCode, Err: nil, Elapsed: elapsed}
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+
+	return model.Result{
+		URL:           link,
+		StatusCode:    resp.StatusCode,
+		Err:           bodyErr,
+		Elapsed:       elapsed,
+		FinalURL:      resp.Request.URL.String(),
+		RedirectChain: redirectChain(resp),
+		Empty:         empty,
+		ContentLength: resp.ContentLength,
+		LastModified:  lastModified,
+		Headers:       c.captureHeaders(resp.Header),
+		Title:         title,
+	}
+}
+
+// captureHeaders returns the CaptureHeaders present in h, keyed by their
+// canonical name; nil if none are.
+func (c *Checker) captureHeaders(h http.Header) map[string]string {
+	var out map[string]string
+	for _, name := range c.CaptureHeaders {
+		v := h.Values(name)
+		if len(v) == 0 {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]string, len(c.CaptureHeaders))
+		}
+		out[http.CanonicalHeaderKey(name)] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// checkRedirect stops a redirect to the URL that was just requested, and
+// otherwise applies the default redirect limit.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if sameURL(req.URL, via[len(via)-1].URL) {
+		return ErrSelfRedirect
+	}
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return nil
+}
+
+// sameURL compares URLs ignoring the fragment, host case and default ports.
+func sameURL(a, b *url.URL) bool {
+	norm := func(u *url.URL) string {
+		c := *u
+		c.Fragment, c.RawFragment = "", ""
+		scheme := strings.ToLower(c.Scheme)
+		host, port := strings.ToLower(c.Hostname()), c.Port()
+		if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+			port = ""
+		}
+		c.Scheme, c.Host = scheme, host
+		if port != "" {
+			c.Host = net.JoinHostPort(host, port)
+		}
+		return c.String()
+	}
+	return norm(a) == norm(b)
+}
+
+// redirectChain walks back from the final response through the redirect
+// responses net/http followed, returning them oldest first.
+func redirectChain(resp *http.Response) []model.Hop {
+	var chain []model.Hop
+	for prev := resp.Request.Response; prev != nil; prev = prev.Request.Response {
+		chain = append(chain, model.Hop{URL: prev.Request.URL.String(), StatusCode: prev.StatusCode})
+	}
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
 }
@@ -2,9 +2,14 @@ package check
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 )
@@ -121,3 +126,261 @@ func TestChecker_DeadAndRedirectAndOK(t *testing.T) {
 		t.Fatalf("redir should not be dead")
 	}
 }
+
+func TestChecker_RecordsRedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/b", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/c", http.StatusFound)
+	})
+	mux.HandleFunc("/c", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	res := NewChecker(2*time.Second, false).Check(context.Background(), srv.URL+"/a")
+	if res.Err != nil || res.StatusCode != 200 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.FinalURL != srv.URL+"/c" {
+		t.Fatalf("final url: got %q", res.FinalURL)
+	}
+	if len(res.RedirectChain) != 2 ||
+		res.RedirectChain[0].URL != srv.URL+"/a" || res.RedirectChain[0].StatusCode != 301 ||
+		res.RedirectChain[1].URL != srv.URL+"/b" || res.RedirectChain[1].StatusCode != 302 {
+		t.Fatalf("unexpected chain %+v", res.RedirectChain)
+	}
+}
+
+func TestChecker_RetriesRecordFlappingStatus(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flap", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chk := NewChecker(2*time.Second, false)
+	chk.Retries = 2
+	chk.RetryBackoff = time.Millisecond
+
+	res := chk.Check(context.Background(), srv.URL+"/flap")
+	if res.Err != nil || res.StatusCode != 200 {
+		t.Fatalf("expected recovery to 200, got %+v", res)
+	}
+	if len(res.Attempts) != 2 || res.Attempts[0].StatusCode != 503 || res.Attempts[1].StatusCode != 200 {
+		t.Fatalf("unexpected attempts %+v", res.Attempts)
+	}
+}
+
+func TestChecker_RetryStatuses(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls <= 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name     string
+		statuses []int
+		status   int
+		attempts int
+	}{
+		{"default", nil, 200, 3},
+		{"500 listed", []int{500}, 200, 3},
+		{"500 not listed", []int{503}, 500, 1},
+		{"none", []int{}, 500, 1},
+	} {
+		calls = 0
+		chk := NewChecker(2*time.Second, false)
+		chk.Retries = 2
+		chk.RetryBackoff = time.Millisecond
+		chk.RetryStatuses = tc.statuses
+
+		res := chk.Check(context.Background(), srv.URL+"/flaky")
+		if res.Err != nil || res.StatusCode != tc.status || len(res.Attempts) != tc.attempts {
+			t.Fatalf("%s: got status %d after %d attempts (err %v), want %d after %d",
+				tc.name, res.StatusCode, len(res.Attempts), res.Err, tc.status, tc.attempts)
+		}
+	}
+}
+
+func TestChecker_RecordsMethod(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	tests := []struct {
+		headFirst bool
+		path      string
+		want      string
+	}{
+		{true, "/ok", "HEAD"},
+		{true, "/no-head", "HEAD,GET"},
+		{false, "/no-head", "GET"},
+	}
+	for _, tt := range tests {
+		res := NewChecker(2*time.Second, tt.headFirst).Check(ctx, srv.URL+tt.path)
+		if res.StatusCode != http.StatusOK || res.Method != tt.want {
+			t.Fatalf("headFirst=%v %s: got %d via %q, want 200 via %q", tt.headFirst, tt.path, res.StatusCode, res.Method, tt.want)
+		}
+	}
+}
+
+func TestChecker_CaptureHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Server", "edge")
+		w.Header().Set("Cf-Cache-Status", "HIT")
+		w.Header().Set("X-Secret", "do not capture")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	chk := NewChecker(2*time.Second, true)
+	chk.CaptureHeaders = []string{"server", "CF-Cache-Status", "X-Cache"}
+	res := chk.Check(context.Background(), srv.URL)
+
+	// Absent headers (X-Cache) are left out too.
+	want := map[string]string{"Server": "edge", "Cf-Cache-Status": "HIT"}
+	if !reflect.DeepEqual(res.Headers, want) {
+		t.Fatalf("headers: got %v, want %v", res.Headers, want)
+	}
+
+	if res := NewChecker(2*time.Second, true).Check(context.Background(), srv.URL); res.Headers != nil {
+		t.Fatalf("no headers requested, got %v", res.Headers)
+	}
+}
+
+func TestChecker_ErrorTitles(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "<title>Not here</title>")
+	}))
+	defer srv.Close()
+
+	chk := NewChecker(2*time.Second, true)
+	if res := chk.Check(context.Background(), srv.URL); res.Method != "HEAD" || res.Title != "" {
+		t.Fatalf("without ErrorTitles: got %q via %q, want no title via HEAD", res.Title, res.Method)
+	}
+	chk.ErrorTitles = true
+	res := chk.Check(context.Background(), srv.URL)
+	if res.StatusCode != http.StatusNotFound || res.Method != "HEAD,GET" || res.Title != "Not here" {
+		t.Fatalf("with ErrorTitles: got %d, %q via %q, want 404, \"Not here\" via HEAD,GET", res.StatusCode, res.Title, res.Method)
+	}
+}
+
+func TestChecker_UserAgent(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.UserAgent())
+	}))
+	defer srv.Close()
+
+	chk := NewChecker(2*time.Second, true)
+	chk.Check(context.Background(), srv.URL)
+	chk.UserAgent = "test-bot/1.0"
+	chk.Check(context.Background(), srv.URL)
+
+	if want := []string{DefaultUserAgent, "test-bot/1.0"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("user agents: got %q, want %q", got, want)
+	}
+}
+
+func TestChecker_SelfRedirect(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page#again", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-a", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-b", http.StatusFound)
+	})
+	mux.HandleFunc("/loop-b", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop-a", http.StatusFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	for _, headFirst := range []bool{true, false} {
+		res := NewChecker(2*time.Second, headFirst).Check(ctx, srv.URL+"/page")
+		if !errors.Is(res.Err, ErrSelfRedirect) || !res.IsDead() {
+			t.Fatalf("headFirst=%v: expected a dead self-redirect, got %+v", headFirst, res)
+		}
+	}
+
+	// A longer loop is not a self-redirect; it hits the redirect limit.
+	res := NewChecker(2*time.Second, false).Check(ctx, srv.URL+"/loop-a")
+	if res.Err == nil || errors.Is(res.Err, ErrSelfRedirect) || !strings.Contains(res.Err.Error(), "stopped after 10 redirects") {
+		t.Fatalf("expected the redirect limit, got %v", res.Err)
+	}
+}
+
+func TestChecker_HealthyBody(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status/up", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "<h1>All systems operational</h1>")
+	})
+	mux.HandleFunc("/status/down", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "<h1>Partial outage</h1>")
+	})
+	mux.HandleFunc("/other", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "anything")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	chk := NewChecker(2*time.Second, true)
+	chk.Retries = 2
+	operational := regexp.MustCompile(`All systems operational`)
+	chk.HealthyBody = func(link string) *regexp.Regexp {
+		if strings.Contains(link, "/status/") {
+			return operational
+		}
+		return nil
+	}
+
+	down := chk.Check(context.Background(), srv.URL+"/status/down")
+	if !down.IsDead() || !errors.Is(down.Err, ErrUnhealthyBody) || down.StatusCode != 200 {
+		t.Fatalf("down: want dead with ErrUnhealthyBody and status 200, got %+v", down)
+	}
+	if down.Method != "GET" || len(down.Attempts) != 1 {
+		t.Fatalf("down: want one GET and no retries, got method %q, attempts %+v", down.Method, down.Attempts)
+	}
+
+	if up := chk.Check(context.Background(), srv.URL+"/status/up"); up.IsDead() || up.Method != "GET" {
+		t.Fatalf("up: want OK via GET, got %+v", up)
+	}
+	if other := chk.Check(context.Background(), srv.URL+"/other"); other.IsDead() || other.Method != "HEAD" {
+		t.Fatalf("other: want OK via HEAD, got %+v", other)
+	}
+}
@@ -0,0 +1,109 @@
+package check
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrIntegrityMismatch is the error of a resource whose content does not
+// match its Subresource Integrity metadata.
+var ErrIntegrityMismatch = errors.New("integrity mismatch")
+
+// maxIntegrityBody caps how much of a resource is hashed.
+const maxIntegrityBody = 32 << 20
+
+// sriAlgorithms lists the supported algorithms, weakest first.
+var sriAlgorithms = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"sha256", sha256.New},
+	{"sha384", sha512.New384},
+	{"sha512", sha512.New},
+}
+
+// VerifyIntegrity downloads link and compares its digest with integrity,
+// the value of an integrity attribute ("sha384-<base64> ..."). As browsers
+// do, only the strongest algorithm listed counts, and any of its digests
+// may match. Metadata without a supported algorithm verifies nothing and
+// returns nil.
+func (c *Checker) VerifyIntegrity(ctx context.Context, link, referer, integrity string) error {
+	alg, want := parseIntegrity(integrity)
+	if alg < 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("integrity GET: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("integrity GET: status %d", resp.StatusCode)
+	}
+
+	h := sriAlgorithms[alg].new()
+	n, err := io.Copy(h, io.LimitReader(resp.Body, maxIntegrityBody+1))
+	if err != nil {
+		return fmt.Errorf("integrity GET: read body: %w", err)
+	}
+	if n > maxIntegrityBody {
+		return fmt.Errorf("integrity GET: body larger than %d bytes", maxIntegrityBody)
+	}
+
+	got := h.Sum(nil)
+	for _, w := range want {
+		if subtle.ConstantTimeCompare(got, w) == 1 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s digest is %s-%s", ErrIntegrityMismatch,
+		sriAlgorithms[alg].name, sriAlgorithms[alg].name, base64.StdEncoding.EncodeToString(got))
+}
+
+// parseIntegrity returns the index in sriAlgorithms of the strongest
+// algorithm in integrity and its decoded digests, or -1 if there is none.
+// Options after "?" and malformed tokens are ignored.
+func parseIntegrity(integrity string) (int, [][]byte) {
+	best := -1
+	var digests [][]byte
+	for _, tok := range strings.Fields(integrity) {
+		tok, _, _ = strings.Cut(tok, "?")
+		name, b64, ok := strings.Cut(tok, "-")
+		if !ok {
+			continue
+		}
+		alg := -1
+		for i, a := range sriAlgorithms {
+			if strings.EqualFold(name, a.name) {
+				alg = i
+			}
+		}
+		d, err := base64.StdEncoding.DecodeString(b64)
+		if alg < 0 || alg < best || err != nil {
+			continue
+		}
+		if alg > best {
+			best, digests = alg, nil
+		}
+		digests = append(digests, d)
+	}
+	return best, digests
+}
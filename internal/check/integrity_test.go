@@ -0,0 +1,49 @@
+package check
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChecker_VerifyIntegrity(t *testing.T) {
+	body := []byte("console.log('hi')")
+	sum256 := sha256.Sum256(body)
+	sum384 := sha512.Sum384(body)
+	good256 := "sha256-" + base64.StdEncoding.EncodeToString(sum256[:])
+	good384 := "sha384-" + base64.StdEncoding.EncodeToString(sum384[:])
+	bad384 := "sha384-" + base64.StdEncoding.EncodeToString(make([]byte, sha512.Size384))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name      string
+		integrity string
+		mismatch  bool
+	}{
+		{"sha384 match", good384, false},
+		{"sha256 match", good256, false},
+		{"mismatch", bad384, true},
+		{"one of several matches", bad384 + " " + good384 + "?ct=application/javascript", false},
+		// Only the strongest algorithm counts, so a good sha256 can't
+		// rescue a bad sha384.
+		{"strongest algorithm wins", good256 + " " + bad384, true},
+		{"unsupported algorithm", "md5-AAAA", false},
+	}
+	chk := NewChecker(2*time.Second, true)
+	for _, tt := range tests {
+		err := chk.VerifyIntegrity(context.Background(), srv.URL+"/app.js", "", tt.integrity)
+		if got := errors.Is(err, ErrIntegrityMismatch); got != tt.mismatch || (!tt.mismatch && err != nil) {
+			t.Fatalf("%s: got %v", tt.name, err)
+		}
+	}
+}
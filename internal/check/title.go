@@ -0,0 +1,41 @@
+package check
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxTitle caps a page title kept for a report line, in runes.
+const maxTitle = 120
+
+func isHTML(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "text/html" || mt == "application/xhtml+xml")
+}
+
+// PageTitle returns the text of the first <title> in body with its
+// whitespace collapsed and cut to maxTitle runes, or "" if there is none.
+func PageTitle(body []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken:
+			if name, _ := z.TagName(); string(name) != "title" {
+				continue
+			}
+			if z.Next() != html.TextToken {
+				return ""
+			}
+			title := []rune(strings.Join(strings.Fields(string(z.Text())), " "))
+			if len(title) > maxTitle {
+				return string(title[:maxTitle-1]) + "…"
+			}
+			return string(title)
+		}
+	}
+}
@@ -15,11 +15,57 @@ const (
 	SkipInvalidURL        SkipReason = "invalid_url"
 	SkipExternal          SkipReason = "external"
 	SkipEmpty             SkipReason = "empty"
+	SkipExternalDepth     SkipReason = "external_depth"   // found deeper than --external-depth
+	SkipHostBudget        SkipReason = "host_page_budget" // its host hit --max-pages-per-host
 )
 
+// PerPage reports whether r comes from where the link was found (too
+// deep, or over its host's page budget) rather than from the link itself,
+// so the same link found elsewhere may still be checked.
+func (r SkipReason) PerPage() bool {
+	return r == SkipExternalDepth || r == SkipHostBudget
+}
+
 type FoundLink struct {
 	URL        string
 	Kind       LinkKind
 	SkipReason SkipReason
 	Raw        string
+	Warning    WarningKind
+	Integrity  string // Subresource Integrity metadata, if declared
+	// Stylesheet is set for links to stylesheets (<link rel="stylesheet">
+	// or a stylesheet's @import).
+	Stylesheet bool
+	// Occurrences is how many times the page links to URL (at least 1).
+	Occurrences int
+}
+
+// DuplicateLink is a destination one page links to more than once.
+type DuplicateLink struct {
+	Page  string
+	URL   string
+	Count int
+}
+
+// PageError is a crawled page whose links could not be extracted.
+type PageError struct {
+	Page string
+	Err  error
+}
+
+// Page is what the extractor found on one page. NoIndex and NoFollow come
+// from <meta name="robots">.
+type Page struct {
+	Links    []FoundLink
+	NoIndex  bool
+	NoFollow bool
+}
+
+// CrawledPage is a page as last fetched: its cache validators and what the
+// extractor found on it, so an unchanged page need not be extracted again.
+type CrawledPage struct {
+	URL          string
+	ETag         string
+	LastModified string
+	Page         Page
 }
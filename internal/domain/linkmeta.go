@@ -5,5 +5,15 @@ type LinkMeta struct {
 	FirstSeenDepth int
 	Sources        map[string]struct{}
 	Kind           LinkKind
-	Skipped        SkipReason // optional; for skipped counting
+	Skipped        SkipReason  // optional; for skipped counting
+	Warning        WarningKind // optional; set when the raw link looked suspicious
+	Integrity      string      // optional; Subresource Integrity metadata
+	SourceCount    int         // pages it was found on; more than len(Sources) when the store caps them
+}
+
+// SkippedLink is a discovered link that was not checked, and why.
+type SkippedLink struct {
+	URL     string
+	Reason  SkipReason
+	Sources []string // pages it was found on, sorted
 }
@@ -7,12 +7,70 @@ type Result struct {
 	StatusCode int
 	Err        error
 	Elapsed    time.Duration
+
+	FinalURL      string
+	RedirectChain []Hop
+	Attempts      []Attempt
+	Empty         bool
+
+	// Method lists the request methods used, e.g. "HEAD,GET" after a
+	// fallback; empty for non-HTTP schemes.
+	Method string
+
+	// LastModified is the Last-Modified the link answered with; zero if
+	// it sent none.
+	LastModified time.Time
+
+	// Headers holds the captured response headers (see
+	// CheckerConfig.CaptureHeaders), by canonical name.
+	Headers map[string]string
+
+	// Title is the <title> of the error page a dead link served, if any.
+	Title string
+
+	// CheckedAt is when the check finished.
+	CheckedAt time.Time
+
+	// FromCache is set when the result was served from a result cache
+	// instead of the network.
+	FromCache bool
+
+	// Accepted is set when StatusCode is one the run treats as OK even
+	// from 400 up (e.g. a 401 on an auth-gated page).
+	Accepted bool
 }
 
+type Attempt struct {
+	StatusCode int
+	Err        string
+}
+
+type Hop struct {
+	URL        string
+	StatusCode int
+}
+
+// IsDead reports whether the link failed: the check errored, or it
+// answered 400 or above with a status the run does not accept (see
+// Accepted).
 func (r Result) IsDead() bool {
 	if r.Err != nil {
 		return true
 	}
 
-	return r.StatusCode >= 400
+	return r.StatusCode >= 400 && !r.Accepted
+}
+
+// IsFlaky reports whether retries of this link produced differing outcomes
+// (e.g. 503 then 200).
+func (r Result) IsFlaky() bool {
+	if len(r.Attempts) < 2 {
+		return false
+	}
+	for _, a := range r.Attempts[1:] {
+		if a != r.Attempts[0] {
+			return true
+		}
+	}
+	return false
 }
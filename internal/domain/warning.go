@@ -0,0 +1,43 @@
+package domain
+
+// WarningKind classifies a problem with a link that is not dead. Warnings
+// are reported but only fail the run in strict mode, which promotes every
+// kind below.
+type WarningKind string
+
+const (
+	// WarnTemporaryRedirect: the redirect chain contains a 302, 303 or 307,
+	// so the link should probably point at the destination directly.
+	WarnTemporaryRedirect WarningKind = "temporary_redirect"
+	// WarnEmptyResource: an asset answered 2xx with an empty body
+	// (only with --flag-empty).
+	WarnEmptyResource WarningKind = "empty_resource"
+	// WarnSchemeLessHost: the raw href looks like a host name without a
+	// scheme (href="www.example.com/x"), so it resolved as a relative path.
+	WarnSchemeLessHost WarningKind = "scheme_less_host"
+	// WarnInsecureHop: the redirect chain ends at https but passes through
+	// an http:// URL on the way (only with --https-audit).
+	WarnInsecureHop WarningKind = "insecure_hop"
+	// WarnHTTPDowngrade: the redirect chain goes from https to a final
+	// http:// URL (only with --https-audit).
+	WarnHTTPDowngrade WarningKind = "http_downgrade"
+	// WarnLongRedirectChain: the link took more redirect hops than the
+	// --warn-redirect-hops threshold.
+	WarnLongRedirectChain WarningKind = "long_redirect_chain"
+	// WarnAuthRequired: the link answered 401 or 403, which usually means
+	// it sits behind a login rather than being broken (only with
+	// --auth-as-warning).
+	WarnAuthRequired WarningKind = "auth_required"
+	// WarnLinksTruncated: a crawled page had more links than
+	// --max-links-per-page; the rest were ignored. URL is the page.
+	WarnLinksTruncated WarningKind = "links_truncated"
+	// WarnToleratedError: the link failed with an error matching a
+	// --tolerate-error pattern, so it is not counted as dead.
+	WarnToleratedError WarningKind = "tolerated_error"
+)
+
+type Warning struct {
+	URL    string
+	Kind   WarningKind
+	Detail string
+}
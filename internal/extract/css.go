@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/model"
+)
+
+var (
+	cssComment = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	// url(x), url("x"), url('x')
+	cssURL = regexp.MustCompile(`(?i)url\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]*))\s*\)`)
+	// @import "x" and @import url(x)
+	cssImport = regexp.MustCompile(`(?i)@import\s+(?:"([^"]*)"|'([^']*)'|url\(\s*(?:"([^"]*)"|'([^']*)'|([^)"'\s]*))\s*\))`)
+)
+
+// ExtractCSSLinks finds the url() references and @import rules of a
+// stylesheet and classifies them like ExtractLinks. Everything a
+// stylesheet references is an asset; @imports are marked Stylesheet. Only
+// opts.Schemes applies to CSS.
+func ExtractCSSLinks(baseURL string, r io.Reader, opts Options) ([]FoundLink, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read css: %w", err)
+	}
+	src := cssComment.ReplaceAllString(string(b), "")
+
+	c := newCollector(opts)
+	add := func(m []string, sheet bool) {
+		raw := strings.TrimSpace(strings.Join(m[1:], ""))
+		resolved, skip := classify(base, raw, c.schemes)
+		if fl := c.emit(raw, resolved, model.LinkKindAsset, skip); sheet {
+			fl.Stylesheet = true
+		}
+	}
+	for _, m := range cssImport.FindAllStringSubmatch(src, -1) {
+		add(m, true)
+	}
+	// The imports are out of the way so their url() is not counted twice.
+	for _, m := range cssURL.FindAllStringSubmatch(cssImport.ReplaceAllString(src, ""), -1) {
+		add(m, false)
+	}
+
+	return c.out, nil
+}
@@ -15,124 +15,325 @@ type FoundLink struct {
 	Kind       model.LinkKind
 	SkipReason model.SkipReason
 	Raw        string
+	Warning    model.LinkWarning
+	// Integrity is the Subresource Integrity metadata of a <script> or
+	// <link> ("sha384-..."), if it has any.
+	Integrity string
+	// Stylesheet is set for a <link rel="stylesheet"> or a stylesheet's
+	// @import, the links worth fetching as CSS.
+	Stylesheet bool
+	// Occurrences is how many times the page links to URL; duplicates are
+	// folded into one FoundLink.
+	Occurrences int
+}
+
+// Page is everything found on one HTML page: its links and the directives
+// of its <meta name="robots"> tags.
+type Page struct {
+	Links    []FoundLink
+	NoIndex  bool
+	NoFollow bool
+}
+
+// Options selects optional link sources.
+type Options struct {
+	// JSONLD also extracts URLs from <script type="application/ld+json">.
+	JSONLD bool
+	// Social also extracts the URLs of Open Graph and Twitter card <meta>
+	// tags (og:image, twitter:image, ...), wherever they are on the page.
+	Social bool
+	// Schemes lists the URL schemes to keep (lowercase); links with any
+	// other scheme are skipped. Empty means http and https.
+	Schemes []string
+	// Content, when set, limits HTML extraction to links inside the
+	// elements it matches (e.g. main, article), leaving out navigation and
+	// footers repeated on every page. A page where it matches nothing is
+	// extracted whole. Robots directives are read from the whole page.
+	Content *Selector
 }
 
 // ExtractLinks  finds <a href="..."> values, resolves them against baseURL,
 // skips empty and non-http(s) schemes, removes fragments for uniqueness.
 func ExtractLinks(baseURL string, r io.Reader) ([]FoundLink, error) {
+	page, err := ExtractPage(baseURL, r, Options{})
+	if err != nil {
+		return nil, err
+	}
+	return page.Links, nil
+}
+
+// ExtractPage is ExtractLinks plus the page's meta robots directives and
+// the optional sources in opts.
+func ExtractPage(baseURL string, r io.Reader, opts Options) (Page, error) {
 	base, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("parse base url: %w", err)
+		return Page{}, fmt.Errorf("parse base url: %w", err)
 	}
 
 	doc, err := html.Parse(r)
 	if err != nil {
-		return nil, fmt.Errorf("parse html: %w", err)
+		return Page{}, fmt.Errorf("parse html: %w", err)
 	}
 
-	seen := make(map[string]struct{})
-	var out []FoundLink
+	var page Page
+
+	c := newCollector(opts)
+
+	// Etract helper for specific tag/attribute combos
+	extractAttr := func(n *html.Node, tag, attr string, kind model.LinkKind) {
+		if n.Type != html.ElementNode || n.Data != tag {
+			return
+		}
 
-	emit := func(raw string, resolved *url.URL, kind model.LinkKind, skip model.SkipReason) {
-		var final string
-		if resolved != nil {
-			// Drop fragment for uniqueness of “real” URLs
-			resolved.Fragment = ""
-			final = resolved.String()
+		for _, a := range n.Attr {
+			if strings.EqualFold(a.Key, attr) {
+				raw := strings.TrimSpace(a.Val)
+				resolved, skip := classify(base, raw, c.schemes)
+				fl := c.emit(raw, resolved, kind, skip)
+				if integrity := attrValue(n, "integrity"); integrity != "" && (tag == "script" || tag == "link") {
+					fl.Integrity = integrity
+				}
+				if tag == "link" && isStylesheet(n) {
+					fl.Stylesheet = true
+				}
+				return
+			}
 		}
+	}
+
+	content := opts.Content
+	if content != nil && !hasMatch(doc, content) {
+		content = nil
+	}
+
+	// inside is whether n is within a Content match (always, without one).
+	var walk func(n *html.Node, inside bool)
+	walk = func(n *html.Node, inside bool) {
+		inside = inside || content.Match(n)
+		if inside {
+			// Pages
+			extractAttr(n, "a", "href", model.LinkKindPage)
+
+			// Assets
+			extractAttr(n, "img", "src", model.LinkKindAsset)
+			extractAttr(n, "script", "src", model.LinkKindAsset)
+			extractAttr(n, "link", "href", model.LinkKindAsset)
 
-		// Dedup rule:
-		// - for checkable links: dedup by the resolved final URL
-		// - for skipped links: dedup by (reason + kind + raw) so different
-		//   unsupported schemes don't collapse into one
-		key := final
-		if skip != "" {
-			key = fmt.Sprintf("%s|%s|%s", skip, kind, raw)
+			if opts.JSONLD && isJSONLD(n) {
+				extractJSONLD(c, base, n)
+			}
 		}
-		if key == "" {
-			// extremely defensive fallback
-			key = fmt.Sprintf("empty|%s|%s", kind, raw)
+		if n.Type == html.ElementNode && n.Data == "meta" {
+			page.addRobots(n)
+			if opts.Social {
+				extractSocial(c, base, n)
+			}
 		}
 
-		if _, ok := seen[key]; ok {
-			return
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, inside)
 		}
-		seen[key] = struct{}{}
+	}
+
+	walk(doc, content == nil)
 
-		out = append(out, FoundLink{
-			URL:        final,
-			Kind:       kind,
-			SkipReason: skip,
-			Raw:        raw,
-		})
+	page.Links = c.out
+	return page, nil
+}
+
+// hasMatch reports whether sel matches n or any node below it.
+func hasMatch(n *html.Node, sel *Selector) bool {
+	if sel.Match(n) {
+		return true
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if hasMatch(c, sel) {
+			return true
+		}
 	}
+	return false
+}
 
-	isUnsupportedScheme := func(scheme string) bool {
-		switch strings.ToLower(scheme) {
-		case "http", "https", "":
-			return false
-		default:
+// attrValue returns n's attribute key, trimmed, or "".
+// isStylesheet reports whether a <link>'s rel lists "stylesheet".
+func isStylesheet(n *html.Node) bool {
+	for _, rel := range strings.Fields(attrValue(n, "rel")) {
+		if strings.EqualFold(rel, "stylesheet") {
 			return true
 		}
 	}
+	return false
+}
 
-	// Etract helper for specific tag/attribute combos
-	extractAttr := func(n *html.Node, tag, attr string, kind model.LinkKind) {
-		if n.Type != html.ElementNode || n.Data != tag {
-			return
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return strings.TrimSpace(a.Val)
 		}
+	}
+	return ""
+}
 
-		for _, a := range n.Attr {
-			if strings.EqualFold(a.Key, attr) {
-				raw := strings.TrimSpace(a.Val)
+// addRobots applies a <meta name="robots" content="..."> tag; other meta
+// tags are ignored.
+func (p *Page) addRobots(n *html.Node) {
+	var name, content string
+	for _, a := range n.Attr {
+		switch strings.ToLower(a.Key) {
+		case "name":
+			name = a.Val
+		case "content":
+			content = a.Val
+		}
+	}
+	if !strings.EqualFold(strings.TrimSpace(name), "robots") {
+		return
+	}
 
-				if raw == "" {
-					emit(raw, nil, kind, model.SkipEmpty)
-				}
-				if strings.HasPrefix(raw, "#") {
-					emit(raw, nil, kind, model.SkipFragmentOnly)
-				}
+	for _, d := range strings.Split(content, ",") {
+		switch strings.ToLower(strings.TrimSpace(d)) {
+		case "noindex":
+			p.NoIndex = true
+		case "nofollow":
+			p.NoFollow = true
+		case "none":
+			p.NoIndex, p.NoFollow = true, true
+		}
+	}
+}
 
-				parsed, err := url.Parse(raw)
-				if err != nil {
-					// Keep as “invalid” by returning a pseudo skipped item via unsupported scheme? No:
-					// We’ll treat invalid URLs as checkable later by emitting an empty SkipReason but raw string.
-					// For stage 4 we keep it simple: mark unsupported_scheme to indicate “not checkable”.
-					emit(raw, nil, kind, model.SkipInvalidURL)
-					return
-				}
+// collector dedups found links while preserving discovery order.
+type collector struct {
+	seen    map[string]int // key -> index in out
+	out     []FoundLink
+	schemes map[string]bool
+}
 
-				// Resolve relative references
-				resolved := base.ResolveReference(parsed)
+func newCollector(opts Options) *collector {
+	c := &collector{seen: make(map[string]int)}
+	if len(opts.Schemes) > 0 {
+		c.schemes = make(map[string]bool, len(opts.Schemes))
+		for _, s := range opts.Schemes {
+			c.schemes[strings.ToLower(s)] = true
+		}
+	}
+	return c
+}
 
-				// Skip unsupported schemes like mailto/tel/javascript/data
-				if isUnsupportedScheme(resolved.Scheme) {
-					emit(raw, nil, kind, model.SkipUnsupportedScheme)
-					return
-				}
+// emit records a found link and returns it, or the earlier link it is a
+// duplicate of. The pointer is only valid until the next emit.
+func (c *collector) emit(raw string, resolved *url.URL, kind model.LinkKind, skip model.SkipReason) *FoundLink {
+	var final string
+	if resolved != nil {
+		// Drop fragment for uniqueness of “real” URLs
+		resolved.Fragment = ""
+		final = resolved.String()
+	}
 
-				emit(raw, resolved, kind, "")
-				return
-			}
-		}
+	// Dedup rule:
+	// - for checkable links: dedup by the resolved final URL
+	// - for skipped links: dedup by (reason + kind + raw) so different
+	//   unsupported schemes don't collapse into one
+	key := final
+	if skip != "" {
+		key = fmt.Sprintf("%s|%s|%s", skip, kind, raw)
+	}
+	if key == "" {
+		// extremely defensive fallback
+		key = fmt.Sprintf("empty|%s|%s", kind, raw)
 	}
 
-	var walk func(*html.Node)
-	walk = func(n *html.Node) {
-		// Pages
-		extractAttr(n, "a", "href", model.LinkKindPage)
+	if i, ok := c.seen[key]; ok {
+		c.out[i].Occurrences++
+		return &c.out[i]
+	}
+	c.seen[key] = len(c.out)
 
-		// Assets
-		extractAttr(n, "img", "src", model.LinkKindAsset)
-		extractAttr(n, "script", "src", model.LinkKindAsset)
-		extractAttr(n, "link", "href", model.LinkKindAsset)
+	fl := FoundLink{
+		URL:         final,
+		Kind:        kind,
+		SkipReason:  skip,
+		Raw:         raw,
+		Occurrences: 1,
+	}
+	if skip == "" && looksLikeSchemeLessHost(raw) {
+		fl.Warning = model.WarnSchemeLessHost
+	}
+	c.out = append(c.out, fl)
+	return &c.out[len(c.out)-1]
+}
 
-		for c := n.FirstChild; c != nil; c = c.NextSibling {
-			walk(c)
-		}
+// classify resolves raw against base, or reports why it can't be checked.
+// schemes is the allowed set; nil means http and https.
+func classify(base *url.URL, raw string, schemes map[string]bool) (*url.URL, model.SkipReason) {
+	if raw == "" {
+		return nil, model.SkipEmpty
+	}
+	if strings.HasPrefix(raw, "#") {
+		return nil, model.SkipFragmentOnly
+	}
+
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return nil, model.SkipInvalidURL
 	}
 
-	walk(doc)
+	// Resolve relative references
+	resolved := base.ResolveReference(parsed)
 
-	return out, nil
+	// Skip unsupported schemes like mailto/tel/javascript/data
+	if isUnsupportedScheme(resolved.Scheme, schemes) {
+		return nil, model.SkipUnsupportedScheme
+	}
+	return resolved, ""
+}
+
+func isUnsupportedScheme(scheme string, schemes map[string]bool) bool {
+	scheme = strings.ToLower(scheme)
+	if scheme == "" {
+		return false
+	}
+	if schemes != nil {
+		return !schemes[scheme]
+	}
+	return scheme != "http" && scheme != "https"
+}
+
+// commonTLDs keeps looksLikeSchemeLessHost from firing on file names such
+// as "index.html" or "logo.png".
+var commonTLDs = map[string]bool{
+	"com": true, "org": true, "net": true, "io": true, "dev": true,
+	"edu": true, "gov": true, "info": true, "biz": true, "app": true,
+	"co": true, "uk": true, "de": true, "fr": true, "nl": true, "eu": true,
+	"us": true, "ca": true, "au": true, "in": true, "jp": true, "ru": true,
+}
+
+// looksLikeSchemeLessHost reports whether raw is probably a host name written
+// without a scheme (href="www.example.com/page"), which browsers and
+// ResolveReference treat as a relative path.
+func looksLikeSchemeLessHost(raw string) bool {
+	if raw == "" || strings.Contains(raw, "://") {
+		return false
+	}
+	switch raw[0] {
+	case '/', '.', '#', '?':
+		return false
+	}
+
+	first := raw
+	if i := strings.IndexAny(first, "/?#"); i >= 0 {
+		first = first[:i]
+	}
+	if strings.Contains(first, ":") {
+		return false // has a scheme (mailto:, tel:) or is host:port-ish
+	}
+	if strings.HasPrefix(strings.ToLower(first), "www.") {
+		return true
+	}
+
+	dot := strings.LastIndex(first, ".")
+	if dot <= 0 {
+		return false
+	}
+	return commonTLDs[strings.ToLower(first[dot+1:])]
 }
@@ -1,6 +1,7 @@
 package extract
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -99,3 +100,316 @@ func TestExtractLinks_InvalidURL(t *testing.T) {
 		t.Fatalf("expected 1 invalid url, got %d", invalid)
 	}
 }
+
+func TestExtractLinks_WarnsOnSchemeLessHosts(t *testing.T) {
+	html := `<html><body>
+		<a href="example.com">bare</a>
+		<a href="www.site.org/x">www</a>
+		<a href="index.html">file</a>
+		<a href="docs/guide">relative</a>
+		<a href="https://example.com/ok">absolute</a>
+	</body></html>`
+
+	found, err := ExtractLinks("https://blog.test/posts/", strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	warned := map[string]string{}
+	for _, f := range found {
+		if f.Warning == model.WarnSchemeLessHost {
+			warned[f.Raw] = f.URL
+		}
+	}
+
+	want := map[string]string{
+		"example.com":    "https://blog.test/posts/example.com",
+		"www.site.org/x": "https://blog.test/posts/www.site.org/x",
+	}
+	if len(warned) != len(want) {
+		t.Fatalf("expected warnings for %v, got %v", want, warned)
+	}
+	for raw, u := range want {
+		if warned[raw] != u {
+			t.Fatalf("%s: expected warning with resolved %s, got %q", raw, u, warned[raw])
+		}
+	}
+}
+
+func TestExtractPage_MetaRobots(t *testing.T) {
+	cases := []struct {
+		head              string
+		noIndex, noFollow bool
+	}{
+		{``, false, false},
+		{`<meta name="robots" content="noindex,nofollow">`, true, true},
+		{`<meta name="ROBOTS" content=" NoFollow ">`, false, true},
+		{`<meta name="robots" content="none">`, true, true},
+		{`<meta name="googlebot" content="nofollow">`, false, false},
+		{`<meta name="robots" content="noindex"><meta name="robots" content="nofollow">`, true, true},
+	}
+
+	for _, tc := range cases {
+		page, err := ExtractPage("https://example.com/", strings.NewReader(`<html><head>`+tc.head+`</head><body><a href="/x">x</a></body></html>`), Options{})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.head, err)
+		}
+		if page.NoIndex != tc.noIndex || page.NoFollow != tc.noFollow {
+			t.Fatalf("%s: got noindex=%v nofollow=%v, want %v %v", tc.head, page.NoIndex, page.NoFollow, tc.noIndex, tc.noFollow)
+		}
+		if len(page.Links) != 1 {
+			t.Fatalf("%s: expected 1 link, got %d", tc.head, len(page.Links))
+		}
+	}
+}
+
+func TestExtractPage_JSONLD(t *testing.T) {
+	html := `<html><head>
+		<script type="application/ld+json">{
+			"@context": "https://schema.org",
+			"@type": "Organization",
+			"url": "https://example.com/",
+			"logo": {"@type": "ImageObject", "url": "/img/logo.png"},
+			"sameAs": ["https://social.test/example", "not a url"],
+			"description": "https://example.com/ignored"
+		}</script>
+		<script type="application/ld+json">{ not json</script>
+		<script>{"url": "https://example.com/not-jsonld"}</script>
+	</head><body><a href="/about">about</a></body></html>`
+
+	page, err := ExtractPage("https://example.com/", strings.NewReader(html), Options{JSONLD: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]model.LinkKind{}
+	for _, f := range page.Links {
+		got[f.URL] = f.Kind
+	}
+	want := map[string]model.LinkKind{
+		"https://example.com/about":        model.LinkKindPage,
+		"https://example.com/":             model.LinkKindPage,
+		"https://example.com/img/logo.png": model.LinkKindAsset,
+		"https://social.test/example":      model.LinkKindPage,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for u, kind := range want {
+		if got[u] != kind {
+			t.Fatalf("%s: expected kind %q, got %q (all: %v)", u, kind, got[u], got)
+		}
+	}
+
+	// Off by default.
+	page, err = ExtractPage("https://example.com/", strings.NewReader(html), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Links) != 1 {
+		t.Fatalf("expected only the anchor without JSONLD, got %+v", page.Links)
+	}
+}
+
+func TestExtractPage_Social(t *testing.T) {
+	html := `<html><head>
+		<meta property="og:url" content="https://example.com/post">
+		<meta property="og:image" content="/img/preview.png">
+		<meta property="og:title" content="https://example.com/not-a-link">
+		<meta name="twitter:image" content=" https://cdn.example.com/card.jpg ">
+		<meta name="twitter:card" content="summary_large_image">
+		<meta property="og:image:alt" content="/alt text">
+	</head><body><main><a href="/about">about</a></main></body></html>`
+
+	// Meta tags sit outside any content region.
+	sel, err := ParseSelector("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, err := ExtractPage("https://example.com/", strings.NewReader(html), Options{Social: true, Content: sel})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]model.LinkKind{}
+	for _, f := range page.Links {
+		got[f.URL] = f.Kind
+	}
+	want := map[string]model.LinkKind{
+		"https://example.com/about":           model.LinkKindPage,
+		"https://example.com/post":            model.LinkKindPage,
+		"https://example.com/img/preview.png": model.LinkKindAsset,
+		"https://cdn.example.com/card.jpg":    model.LinkKindAsset,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	// Off by default.
+	page, err = ExtractPage("https://example.com/", strings.NewReader(html), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(page.Links) != 1 {
+		t.Fatalf("expected only the anchor without Social, got %+v", page.Links)
+	}
+}
+
+func TestExtractLinks_ConfigurableSchemes(t *testing.T) {
+	html := `<a href="ftp://files.example.com/pub/a.zip">ftp</a><a href="/page">page</a><a href="gopher://old.example.com/">gopher</a>`
+
+	skipped := func(links []FoundLink) map[string]model.SkipReason {
+		out := map[string]model.SkipReason{}
+		for _, f := range links {
+			out[f.Raw] = f.SkipReason
+		}
+		return out
+	}
+
+	page, err := ExtractPage("https://example.com/", strings.NewReader(html), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := skipped(page.Links)["ftp://files.example.com/pub/a.zip"]; got != model.SkipUnsupportedScheme {
+		t.Fatalf("ftp should be skipped by default, got %q", got)
+	}
+
+	page, err = ExtractPage("https://example.com/", strings.NewReader(html), Options{Schemes: []string{"http", "https", "ftp"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := skipped(page.Links)
+	if got["ftp://files.example.com/pub/a.zip"] != "" || got["/page"] != "" {
+		t.Fatalf("ftp and relative links should be kept, got %v", got)
+	}
+	if got["gopher://old.example.com/"] != model.SkipUnsupportedScheme {
+		t.Fatalf("unlisted schemes stay skipped, got %v", got)
+	}
+}
+
+func TestExtractPage_ContentSelector(t *testing.T) {
+	html := `<html><head><link rel="stylesheet" href="/site.css"><meta name="robots" content="nofollow"></head><body>
+		<nav><a href="/home">home</a><a href="/about">about</a></nav>
+		<main><a href="/post/1">post</a><img src="/img/1.png">
+			<div class="share"><a href="https://social.test/share">share</a></div></main>
+		<article id="extra"><a href="/post/2">more</a></article>
+		<footer><a href="/about">about</a><a href="/legal">legal</a></footer>
+	</body></html>`
+
+	urls := func(sel string) []string {
+		t.Helper()
+		s, err := ParseSelector(sel)
+		if err != nil {
+			t.Fatalf("%s: %v", sel, err)
+		}
+		page, err := ExtractPage("https://example.com/", strings.NewReader(html), Options{Content: s})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", sel, err)
+		}
+		if !page.NoFollow {
+			t.Fatalf("%s: robots directives should come from the whole page", sel)
+		}
+		var out []string
+		for _, f := range page.Links {
+			out = append(out, strings.TrimPrefix(f.URL, "https://example.com"))
+		}
+		return out
+	}
+
+	cases := map[string][]string{
+		"main":                  {"/post/1", "/img/1.png", "https://social.test/share"},
+		"main, article":         {"/post/1", "/img/1.png", "https://social.test/share", "/post/2"},
+		"#extra":                {"/post/2"},
+		"main > .share":         {"https://social.test/share"},
+		"main div[class=share]": {"https://social.test/share"},
+		"MAIN DIV[Class=share]": {"https://social.test/share"},
+		// Matching nothing falls back to the whole page.
+		"section": {"/site.css", "/home", "/about", "/post/1", "/img/1.png", "https://social.test/share", "/post/2", "/legal"},
+	}
+	for sel, want := range cases {
+		if got := urls(sel); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", sel, got, want)
+		}
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	for _, bad := range []string{"", "main,", "main >", "a[href", "#", "main ! a", `a[href="x]`} {
+		if _, err := ParseSelector(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+	for _, good := range []string{"main", " main , article ", "*", "div#main.content.wide", "ul>li a", `a[rel="nofollow"]`, "[data-region]"} {
+		if _, err := ParseSelector(good); err != nil {
+			t.Errorf("%q: %v", good, err)
+		}
+	}
+}
+
+func TestExtractPage_MarksStylesheets(t *testing.T) {
+	html := `<link rel="stylesheet" href="/a.css"><link rel="Preload Stylesheet" href="/b.css">
+	<link rel="icon" href="/favicon.ico"><img src="/logo.png">`
+
+	page, err := ExtractPage("https://example.com/", strings.NewReader(html), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := map[string]bool{}
+	for _, f := range page.Links {
+		got[f.URL] = f.Stylesheet
+	}
+	want := map[string]bool{
+		"https://example.com/a.css":       true,
+		"https://example.com/b.css":       true,
+		"https://example.com/favicon.ico": false,
+		"https://example.com/logo.png":    false,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestExtractCSSLinks(t *testing.T) {
+	css := `
+	@import "base.css";
+	@import url('print.css') print;
+	/* url(commented-out.png) */
+	body { background: url(img/bg.png) no-repeat; }
+	@font-face { src: url( "/fonts/a.woff2" ) format("woff2"); }
+	.icon { background-image: url(data:image/png;base64,AAAA); }`
+
+	found, err := ExtractCSSLinks("https://example.com/css/site.css", strings.NewReader(css), Options{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := map[string]model.SkipReason{}
+	for _, f := range found {
+		if f.Kind != model.LinkKindAsset {
+			t.Fatalf("%s: kind %s, want asset", f.Raw, f.Kind)
+		}
+		if sheet := strings.HasSuffix(f.URL, ".css"); f.Stylesheet != sheet || f.Occurrences != 1 {
+			t.Fatalf("%s: stylesheet %v, occurrences %d; want %v and 1", f.Raw, f.Stylesheet, f.Occurrences, sheet)
+		}
+		key := f.URL
+		if f.SkipReason != "" {
+			key = f.Raw
+		}
+		got[key] = f.SkipReason
+	}
+	want := map[string]model.SkipReason{
+		"https://example.com/css/base.css":   "",
+		"https://example.com/css/print.css":  "",
+		"https://example.com/css/img/bg.png": "",
+		"https://example.com/fonts/a.woff2":  "",
+		"data:image/png;base64,AAAA":         model.SkipUnsupportedScheme,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for u, skip := range want {
+		if s, ok := got[u]; !ok || s != skip {
+			t.Fatalf("%s: got %q (found %v), want %q", u, s, ok, skip)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package extract
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/model"
+	"golang.org/x/net/html"
+)
+
+// jsonLDKeys are the structured-data properties whose string values are
+// links; assets are the ones that name an image or file.
+var jsonLDKeys = map[string]model.LinkKind{
+	"url":          model.LinkKindPage,
+	"sameAs":       model.LinkKindPage,
+	"image":        model.LinkKindAsset,
+	"logo":         model.LinkKindAsset,
+	"thumbnailUrl": model.LinkKindAsset,
+	"contentUrl":   model.LinkKindAsset,
+}
+
+func isJSONLD(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != "script" {
+		return false
+	}
+	for _, a := range n.Attr {
+		if strings.EqualFold(a.Key, "type") {
+			return strings.EqualFold(strings.TrimSpace(a.Val), "application/ld+json")
+		}
+	}
+	return false
+}
+
+// extractJSONLD emits the URLs found under jsonLDKeys anywhere in the
+// script's JSON. Malformed JSON is ignored.
+func extractJSONLD(c *collector, base *url.URL, n *html.Node) {
+	var text strings.Builder
+	for t := n.FirstChild; t != nil; t = t.NextSibling {
+		if t.Type == html.TextNode {
+			text.WriteString(t.Data)
+		}
+	}
+
+	var doc any
+	if err := json.Unmarshal([]byte(text.String()), &doc); err != nil {
+		return
+	}
+
+	// kind is that of the enclosing key, or "" outside jsonLDKeys. An
+	// object under an asset key ("logo": {"url": ...}) names an asset.
+	var walk func(v any, kind model.LinkKind)
+	walk = func(v any, kind model.LinkKind) {
+		switch v := v.(type) {
+		case map[string]any:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys) // stable discovery order
+			for _, k := range keys {
+				childKind := jsonLDKeys[k]
+				if childKind != "" && kind == model.LinkKindAsset {
+					childKind = model.LinkKindAsset
+				}
+				walk(v[k], childKind)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child, kind)
+			}
+		case string:
+			if kind != "" && looksLikeURL(v) {
+				raw := strings.TrimSpace(v)
+				resolved, skip := classify(base, raw, c.schemes)
+				c.emit(raw, resolved, kind, skip)
+			}
+		}
+	}
+	walk(doc, "")
+}
+
+// looksLikeURL accepts absolute http(s) URLs and root-relative paths, so
+// plain text under a key like "image" (a caption, say) is not a link.
+func looksLikeURL(s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "/")
+}
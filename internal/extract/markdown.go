@@ -0,0 +1,60 @@
+package extract
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/model"
+)
+
+var (
+	// [text](dest "title") and ![alt](dest "title"); dest may be wrapped in <>.
+	mdInline = regexp.MustCompile(`(!?)\[[^\]]*\]\(\s*<?([^)\s>]*)>?(?:\s+["'(][^)]*)?\s*\)`)
+	// [label]: dest "title"
+	mdRefDef = regexp.MustCompile(`(?m)^[ ]{0,3}\[[^\]]+\]:\s*<?([^\s>]+)>?`)
+	// <https://example.com>
+	mdAutolink = regexp.MustCompile(`<((?:https?|ftp|wss?|mailto):[^>\s]+)>`)
+)
+
+// ExtractMarkdownLinks finds inline links, images, reference definitions and
+// autolinks in a Markdown document and classifies them like ExtractLinks.
+// It is a pragmatic scanner, not a full CommonMark parser. Only
+// opts.Schemes applies to Markdown.
+func ExtractMarkdownLinks(baseURL string, r io.Reader, opts Options) ([]FoundLink, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse base url: %w", err)
+	}
+
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read markdown: %w", err)
+	}
+	src := string(b)
+
+	c := newCollector(opts)
+	add := func(raw string, kind model.LinkKind) {
+		raw = strings.TrimSpace(raw)
+		resolved, skip := classify(base, raw, c.schemes)
+		c.emit(raw, resolved, kind, skip)
+	}
+
+	for _, m := range mdInline.FindAllStringSubmatch(src, -1) {
+		kind := model.LinkKindPage
+		if m[1] == "!" {
+			kind = model.LinkKindAsset
+		}
+		add(m[2], kind)
+	}
+	for _, m := range mdRefDef.FindAllStringSubmatch(src, -1) {
+		add(m[1], model.LinkKindPage)
+	}
+	for _, m := range mdAutolink.FindAllStringSubmatch(src, -1) {
+		add(m[1], model.LinkKindPage)
+	}
+
+	return c.out, nil
+}
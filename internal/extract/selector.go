@@ -0,0 +1,249 @@
+package extract
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Selector is a parsed CSS selector list. It supports the subset that
+// picks out a page region: type selectors and *, #id, .class, [attr] and
+// [attr=value], combined with descendant (space) and child (>)
+// combinators, and grouped with commas ("main, article, div.content").
+type Selector struct {
+	groups [][]selectorStep
+}
+
+// selectorStep is one compound selector and how it relates to the step
+// before it: ' ' for a descendant, '>' for a child.
+type selectorStep struct {
+	comb    byte
+	tag     string // "" matches any element
+	id      string
+	classes []string
+	attrs   []selectorAttr
+}
+
+type selectorAttr struct {
+	name, value string
+	hasValue    bool
+}
+
+// ParseSelector parses s; see Selector for what it supports.
+func ParseSelector(s string) (*Selector, error) {
+	p := &selectorParser{s: s}
+	sel := &Selector{}
+	for {
+		group, err := p.complex()
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", s, err)
+		}
+		sel.groups = append(sel.groups, group)
+		p.space()
+		if p.done() {
+			return sel, nil
+		}
+		if p.s[p.i] != ',' {
+			return nil, fmt.Errorf("selector %q: unexpected %q at offset %d", s, p.s[p.i], p.i)
+		}
+		p.i++
+	}
+}
+
+// Match reports whether the element n matches any selector in the list.
+func (sel *Selector) Match(n *html.Node) bool {
+	for _, g := range sel.groups {
+		if matchSteps(n, g, len(g)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSteps matches n against steps[i], then its ancestors against the
+// steps before it.
+func matchSteps(n *html.Node, steps []selectorStep, i int) bool {
+	if !steps[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if steps[i].comb == '>' {
+		return n.Parent != nil && matchSteps(n.Parent, steps, i-1)
+	}
+	for a := n.Parent; a != nil; a = a.Parent {
+		if matchSteps(a, steps, i-1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (st selectorStep) match(n *html.Node) bool {
+	if n.Type != html.ElementNode || st.tag != "" && n.Data != st.tag {
+		return false
+	}
+	if st.id != "" && attrValue(n, "id") != st.id {
+		return false
+	}
+	if len(st.classes) > 0 {
+		have := strings.Fields(attrValue(n, "class"))
+		for _, c := range st.classes {
+			if !slices.Contains(have, c) {
+				return false
+			}
+		}
+	}
+	for _, a := range st.attrs {
+		v, ok := lookupAttr(n, a.name)
+		if !ok || a.hasValue && v != a.value {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupAttr finds attribute key, which must be lowercase as the HTML
+// parser leaves every attribute name.
+func lookupAttr(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+type selectorParser struct {
+	s string
+	i int
+}
+
+func (p *selectorParser) done() bool { return p.i >= len(p.s) }
+
+// space skips whitespace and reports whether there was any.
+func (p *selectorParser) space() bool {
+	start := p.i
+	for !p.done() && strings.ContainsRune(" \t\n\r\f", rune(p.s[p.i])) {
+		p.i++
+	}
+	return p.i > start
+}
+
+// complex parses compound selectors joined by combinators, up to a comma
+// or the end.
+func (p *selectorParser) complex() ([]selectorStep, error) {
+	var steps []selectorStep
+	comb := byte(' ')
+	p.space()
+	for {
+		st, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		st.comb = comb
+		steps = append(steps, st)
+
+		spaced := p.space()
+		if p.done() || p.s[p.i] == ',' {
+			return steps, nil
+		}
+		comb = ' '
+		if p.s[p.i] == '>' {
+			comb = '>'
+			p.i++
+			p.space()
+		} else if !spaced {
+			return nil, fmt.Errorf("unexpected %q at offset %d", p.s[p.i], p.i)
+		}
+	}
+}
+
+func (p *selectorParser) compound() (selectorStep, error) {
+	var st selectorStep
+	start := p.i
+	if !p.done() && p.s[p.i] == '*' {
+		p.i++
+	} else {
+		st.tag = strings.ToLower(p.ident())
+	}
+	for !p.done() {
+		switch p.s[p.i] {
+		case '#':
+			p.i++
+			if st.id = p.ident(); st.id == "" {
+				return st, fmt.Errorf("missing id at offset %d", p.i)
+			}
+		case '.':
+			p.i++
+			c := p.ident()
+			if c == "" {
+				return st, fmt.Errorf("missing class at offset %d", p.i)
+			}
+			st.classes = append(st.classes, c)
+		case '[':
+			p.i++
+			a, err := p.attr()
+			if err != nil {
+				return st, err
+			}
+			st.attrs = append(st.attrs, a)
+		default:
+			if p.i == start {
+				return st, fmt.Errorf("unexpected %q at offset %d", p.s[p.i], p.i)
+			}
+			return st, nil
+		}
+	}
+	if p.i == start {
+		return st, fmt.Errorf("missing selector at offset %d", p.i)
+	}
+	return st, nil
+}
+
+// attr parses the rest of [name] or [name=value] after the "[".
+func (p *selectorParser) attr() (selectorAttr, error) {
+	var a selectorAttr
+	p.space()
+	if a.name = strings.ToLower(p.ident()); a.name == "" {
+		return a, fmt.Errorf("missing attribute name at offset %d", p.i)
+	}
+	p.space()
+	if !p.done() && p.s[p.i] == '=' {
+		p.i++
+		p.space()
+		a.hasValue = true
+		if !p.done() && (p.s[p.i] == '"' || p.s[p.i] == '\'') {
+			end := strings.IndexByte(p.s[p.i+1:], p.s[p.i])
+			if end < 0 {
+				return a, fmt.Errorf("unterminated string at offset %d", p.i)
+			}
+			a.value = p.s[p.i+1 : p.i+1+end]
+			p.i += end + 2
+		} else {
+			a.value = p.ident()
+		}
+		p.space()
+	}
+	if p.done() || p.s[p.i] != ']' {
+		return a, fmt.Errorf("missing ] at offset %d", p.i)
+	}
+	p.i++
+	return a, nil
+}
+
+func (p *selectorParser) ident() string {
+	start := p.i
+	for !p.done() {
+		c := p.s[p.i]
+		if c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			p.i++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.i]
+}
@@ -0,0 +1,51 @@
+package extract
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/model"
+	"golang.org/x/net/html"
+)
+
+// socialKeys are the Open Graph and Twitter card properties whose content
+// is a link; assets are the media a link preview shows.
+var socialKeys = map[string]model.LinkKind{
+	"og:url":              model.LinkKindPage,
+	"og:image":            model.LinkKindAsset,
+	"og:image:url":        model.LinkKindAsset,
+	"og:image:secure_url": model.LinkKindAsset,
+	"og:video":            model.LinkKindAsset,
+	"og:video:url":        model.LinkKindAsset,
+	"og:video:secure_url": model.LinkKindAsset,
+	"og:audio":            model.LinkKindAsset,
+	"og:audio:url":        model.LinkKindAsset,
+	"og:audio:secure_url": model.LinkKindAsset,
+	"twitter:url":         model.LinkKindPage,
+	"twitter:image":       model.LinkKindAsset,
+	"twitter:image:src":   model.LinkKindAsset,
+	"twitter:player":      model.LinkKindPage,
+}
+
+// extractSocial emits the content URL of a social <meta> tag. Open Graph
+// uses property= and Twitter cards name=, but sites mix them up, so both
+// are read.
+func extractSocial(c *collector, base *url.URL, n *html.Node) {
+	if n.Type != html.ElementNode || n.Data != "meta" {
+		return
+	}
+	key := attrValue(n, "property")
+	if key == "" {
+		key = attrValue(n, "name")
+	}
+	kind, ok := socialKeys[strings.ToLower(key)]
+	if !ok {
+		return
+	}
+	raw := attrValue(n, "content")
+	if raw == "" {
+		return
+	}
+	resolved, skip := classify(base, raw, c.schemes)
+	c.emit(raw, resolved, kind, skip)
+}
@@ -2,30 +2,104 @@ package extractor
 
 import (
 	"io"
+	"net/url"
+	"path"
+	"strings"
 
 	"github.com/rojanmagar2001/godeadlink/internal/domain"
 	"github.com/rojanmagar2001/godeadlink/internal/extract"
 )
 
-type Adapter struct{}
+type Adapter struct {
+	opts extract.Options
+}
+
+// Option configures an Adapter.
+type Option func(*Adapter)
+
+// WithJSONLD also extracts URLs from JSON-LD structured data blocks.
+func WithJSONLD(enabled bool) Option {
+	return func(a *Adapter) { a.opts.JSONLD = enabled }
+}
+
+// WithSocial also extracts URLs from Open Graph and Twitter card meta
+// tags.
+func WithSocial(enabled bool) Option {
+	return func(a *Adapter) { a.opts.Social = enabled }
+}
+
+// WithSchemes sets the URL schemes to keep; see extract.Options.Schemes.
+func WithSchemes(schemes []string) Option {
+	return func(a *Adapter) { a.opts.Schemes = schemes }
+}
+
+// WithContentSelector limits HTML extraction to the elements sel matches;
+// see extract.Options.Content.
+func WithContentSelector(sel *extract.Selector) Option {
+	return func(a *Adapter) { a.opts.Content = sel }
+}
+
+func New(opts ...Option) *Adapter {
+	a := &Adapter{}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Extract parses r as HTML, or as Markdown when baseURL names a .md file
+// (as it does when checking files on disk). Markdown has no robots
+// directives.
+func (a *Adapter) Extract(baseURL string, r io.Reader) (domain.Page, error) {
+	var page extract.Page
+	var err error
+	if isMarkdown(baseURL) {
+		page.Links, err = extract.ExtractMarkdownLinks(baseURL, r, a.opts)
+	} else {
+		page, err = extract.ExtractPage(baseURL, r, a.opts)
+	}
+	if err != nil {
+		return domain.Page{}, err
+	}
 
-func New() *Adapter { return &Adapter{} }
+	return domain.Page{Links: foundLinks(page.Links), NoIndex: page.NoIndex, NoFollow: page.NoFollow}, nil
+}
 
-func (a *Adapter) Extract(baseURL string, r io.Reader) ([]domain.FoundLink, error) {
-	found, err := extract.ExtractLinks(baseURL, r)
+// ExtractCSS parses r as a stylesheet; see extract.ExtractCSSLinks.
+func (a *Adapter) ExtractCSS(baseURL string, r io.Reader) (domain.Page, error) {
+	links, err := extract.ExtractCSSLinks(baseURL, r, a.opts)
 	if err != nil {
-		return nil, err
+		return domain.Page{}, err
 	}
+	return domain.Page{Links: foundLinks(links)}, nil
+}
 
-	out := make([]domain.FoundLink, 0, len(found))
-	for _, f := range found {
+func foundLinks(links []extract.FoundLink) []domain.FoundLink {
+	out := make([]domain.FoundLink, 0, len(links))
+	for _, f := range links {
 		out = append(out, domain.FoundLink{
 			URL:        f.URL,
 			Kind:       domain.LinkKind(f.Kind),
 			SkipReason: domain.SkipReason(f.SkipReason),
 			Raw:        f.Raw,
+			Warning:    domain.WarningKind(f.Warning),
+			Integrity:  f.Integrity,
+			Stylesheet: f.Stylesheet,
+
+			Occurrences: f.Occurrences,
 		})
 	}
+	return out
+}
 
-	return out, nil
+func isMarkdown(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
 }
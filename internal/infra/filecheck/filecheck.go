@@ -0,0 +1,50 @@
+package filecheck
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+var _ ports.SchemeChecker = (*Checker)(nil)
+
+// Checker checks file:// links by looking the path up on the local disk;
+// the query and fragment are ignored. An existing file or directory gets
+// status 200.
+type Checker struct{}
+
+func New() *Checker {
+	return &Checker{}
+}
+
+func (c *Checker) Check(_ context.Context, rawURL string) domain.Result {
+	start := time.Now()
+	res := domain.Result{URL: rawURL, FinalURL: rawURL}
+
+	err := stat(rawURL)
+	res.Elapsed = time.Since(start)
+	if err != nil {
+		res.Err = fmt.Errorf("file: %w", err)
+		return res
+	}
+	res.StatusCode = 200
+	return res
+}
+
+func stat(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host != "" && u.Host != "localhost" {
+		return fmt.Errorf("remote host %q", u.Host)
+	}
+	_, err = os.Stat(filepath.FromSlash(u.Path))
+	return err
+}
@@ -0,0 +1,30 @@
+package filecheck
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChecker_Check(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a b.md"), []byte("# a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fileURL := func(name string) string {
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(dir, name))}).String()
+	}
+
+	c := New()
+	ctx := context.Background()
+	for _, link := range []string{fileURL("a b.md"), fileURL("a b.md") + "#intro", fileURL("")} {
+		if r := c.Check(ctx, link); r.IsDead() || r.StatusCode != 200 {
+			t.Errorf("%s: expected a live 200 result, got %+v", link, r)
+		}
+	}
+	if r := c.Check(ctx, fileURL("missing.md")); !r.IsDead() {
+		t.Errorf("expected a missing file to be dead, got %+v", r)
+	}
+}
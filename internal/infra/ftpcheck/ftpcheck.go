@@ -0,0 +1,86 @@
+package ftpcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+var _ ports.SchemeChecker = (*Checker)(nil)
+
+// Checker checks ftp:// links by connecting to the server and reading its
+// greeting. It proves the server is up, not that the path exists.
+type Checker struct {
+	timeout time.Duration
+	dialer  net.Dialer
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Check dials the URL's host (port 21 by default) and expects a 220
+// greeting, which becomes the result's status code.
+func (c *Checker) Check(ctx context.Context, rawURL string) domain.Result {
+	start := time.Now()
+	res := domain.Result{URL: rawURL, FinalURL: rawURL}
+
+	code, err := c.greet(ctx, rawURL)
+	res.Elapsed = time.Since(start)
+	res.StatusCode = code
+	if err != nil {
+		res.Err = fmt.Errorf("ftp: %w", err)
+	}
+	return res
+}
+
+func (c *Checker) greet(ctx context.Context, rawURL string) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "21")
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	conn, err := c.dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("read greeting: %w", err)
+	}
+	greeting := strings.TrimSpace(line)
+	if len(greeting) < 3 {
+		return 0, fmt.Errorf("bad greeting %q", greeting)
+	}
+	code, err := strconv.Atoi(greeting[:3])
+	if err != nil {
+		return 0, fmt.Errorf("bad greeting %q", greeting)
+	}
+	_, _ = conn.Write([]byte("QUIT\r\n"))
+	if code != 220 {
+		return code, fmt.Errorf("server not ready: %s", greeting)
+	}
+	return code, nil
+}
@@ -0,0 +1,56 @@
+package ftpcheck
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// serve accepts connections on a local port and greets each with greeting.
+func serve(t *testing.T, greeting string) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte(greeting))
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestChecker_Check(t *testing.T) {
+	c := New(2 * time.Second)
+	ctx := context.Background()
+
+	ready := serve(t, "220 welcome\r\n")
+	if r := c.Check(ctx, "ftp://"+ready+"/pub/file.txt"); r.Err != nil || r.StatusCode != 220 || r.IsDead() {
+		t.Fatalf("expected a live 220 result, got %+v", r)
+	}
+
+	busy := serve(t, "421 too many users\r\n")
+	if r := c.Check(ctx, "ftp://"+busy+"/"); r.StatusCode != 421 || !r.IsDead() {
+		t.Fatalf("expected a dead 421 result, got %+v", r)
+	}
+
+	// Nothing listens on a closed listener's port.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln.Addr().String()
+	ln.Close()
+	if r := c.Check(ctx, "ftp://"+closed+"/"); r.Err == nil {
+		t.Fatalf("expected a dial error, got %+v", r)
+	}
+}
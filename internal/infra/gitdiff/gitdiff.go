@@ -0,0 +1,71 @@
+package gitdiff
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// checkable lists the file extensions whose links we know how to extract.
+var checkable = map[string]bool{
+	".html":     true,
+	".htm":      true,
+	".md":       true,
+	".markdown": true,
+}
+
+// ChangedFiles returns absolute paths of HTML/Markdown files under repoDir
+// that differ from rev (per `git diff --name-only rev`). Deleted files are
+// left out since there is nothing to check.
+func ChangedFiles(ctx context.Context, repoDir, rev string) ([]string, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, fmt.Errorf("git-diff: git not available: %w", err)
+	}
+
+	top, err := git(ctx, repoDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(top)
+
+	out, err := git(ctx, repoDir, "diff", "--name-only", rev, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		name := strings.TrimSpace(line)
+		if name == "" || !checkable[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		files = append(files, p)
+	}
+	return files, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,68 @@
+package gitdiff
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func writeFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	writeFile(t, filepath.Join(dir, "a.md"), "[a](https://example.com/a)\n")
+	writeFile(t, filepath.Join(dir, "b.html"), `<a href="https://example.com/b">b</a>`)
+	writeFile(t, filepath.Join(dir, "c.txt"), "plain")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "init")
+
+	writeFile(t, filepath.Join(dir, "b.html"), `<a href="https://example.com/changed">b</a>`)
+	writeFile(t, filepath.Join(dir, "c.txt"), "changed but not checkable")
+
+	files, err := ChangedFiles(context.Background(), dir, "HEAD")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 || filepath.Base(files[0]) != "b.html" {
+		t.Fatalf("expected only b.html, got %v", files)
+	}
+}
+
+func TestChangedFiles_InvalidRev(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	_, err := ChangedFiles(context.Background(), dir, "no-such-rev")
+	if err == nil || !strings.Contains(err.Error(), "git diff") {
+		t.Fatalf("expected git diff error, got %v", err)
+	}
+}
@@ -1,7 +1,12 @@
 package httpclient
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -9,8 +14,27 @@ type Client struct {
 	c *http.Client
 }
 
-func New(timeout time.Duration) *Client {
-	return &Client{c: &http.Client{Timeout: timeout}}
+// Option configures a Client.
+type Option func(*http.Client)
+
+// WithTransport sends requests through rt instead of the default
+// transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *http.Client) { c.Transport = rt }
+}
+
+// WithJar keeps cookies in jar: cookies set by responses are sent with
+// later requests, including those of other clients sharing jar.
+func WithJar(jar http.CookieJar) Option {
+	return func(c *http.Client) { c.Jar = jar }
+}
+
+func New(timeout time.Duration, opts ...Option) *Client {
+	c := &http.Client{Timeout: timeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return &Client{c: c}
 }
 
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
@@ -18,3 +42,56 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 }
 
 func (c *Client) Timeout() float64 { return 0 }
+
+// NewTransport builds the transport crawl and check requests share. With a
+// unixSocket path, every connection is dialed to that socket whatever the
+// URL's host (which then only serves as the Host header), and proxies are
+// not used.
+//
+// resolve overrides DNS like curl's --resolve: a connection to a
+// "host:port" key is dialed to its "addr:port" value instead, keeping the
+// URL's host for the Host header and TLS server name. Everything else,
+// proxies and dial timeouts included, stays as in http.DefaultTransport.
+//
+// minTLS, when not zero, is the lowest TLS version connections accept
+// (tls.VersionTLS12, ...); servers offering only older ones fail the
+// handshake.
+func NewTransport(unixSocket string, resolve map[string]string, minTLS uint16) *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if minTLS != 0 {
+		tr.TLSClientConfig = &tls.Config{MinVersion: minTLS}
+	}
+	var d net.Dialer
+	switch {
+	case unixSocket != "":
+		tr.Proxy = nil
+		tr.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", unixSocket)
+		}
+	case len(resolve) > 0:
+		dial := tr.DialContext
+		tr.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			if to, ok := resolve[strings.ToLower(addr)]; ok {
+				addr = to
+			}
+			return dial(ctx, network, addr)
+		}
+	}
+	return tr
+}
+
+// ParseTLSVersion turns "1.0" through "1.3" into a tls.VersionTLS1x
+// constant.
+func ParseTLSVersion(v string) (uint16, error) {
+	switch v {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("invalid TLS version %q (want 1.0, 1.1, 1.2 or 1.3)", v)
+}
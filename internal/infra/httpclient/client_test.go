@@ -0,0 +1,98 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tlsServer serves 200 over TLS no newer than maxVersion.
+func tlsServer(t *testing.T, maxVersion uint16) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	srv.TLS = &tls.Config{MinVersion: tls.VersionTLS10, MaxVersion: maxVersion}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // refused handshakes
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func get(t *testing.T, srv *httptest.Server, minTLS uint16) error {
+	t.Helper()
+	tr := NewTransport("", nil, minTLS)
+	defer tr.CloseIdleConnections()
+	if tr.TLSClientConfig == nil {
+		tr.TLSClientConfig = &tls.Config{}
+	}
+	tr.TLSClientConfig.RootCAs = srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(2*time.Second, WithTransport(tr)).Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func TestNewTransport_MinTLS(t *testing.T) {
+	tls10 := tlsServer(t, tls.VersionTLS10)
+	if err := get(t, tls10, tls.VersionTLS12); err == nil || !strings.Contains(err.Error(), "tls") {
+		t.Fatalf("TLS 1.0 server with min 1.2: expected a TLS error, got %v", err)
+	}
+	if err := get(t, tls10, tls.VersionTLS10); err != nil {
+		t.Fatalf("TLS 1.0 server with min 1.0: %v", err)
+	}
+
+	tls12 := tlsServer(t, tls.VersionTLS12)
+	if err := get(t, tls12, 0); err != nil {
+		t.Fatalf("TLS 1.2 server with the default minimum: %v", err)
+	}
+	if err := get(t, tls12, tls.VersionTLS13); err == nil || !strings.Contains(err.Error(), "tls") {
+		t.Fatalf("TLS 1.2 server with min 1.3: expected a TLS error, got %v", err)
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	if v, err := ParseTLSVersion("1.2"); err != nil || v != tls.VersionTLS12 {
+		t.Fatalf("1.2: got %x, %v", v, err)
+	}
+	if _, err := ParseTLSVersion("1.4"); err == nil {
+		t.Fatal("1.4: expected an error")
+	}
+}
+
+func TestNewTransport_Resolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Host)
+	}))
+	defer srv.Close()
+
+	tr := NewTransport("", map[string]string{"site.invalid:80": srv.Listener.Addr().String()}, 0)
+	defer tr.CloseIdleConnections()
+	// Only the dialing changes: proxies from the environment still apply.
+	if tr.Proxy == nil {
+		t.Fatal("resolve dropped the proxy setting")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "http://site.invalid/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := New(2*time.Second, WithTransport(tr)).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if b, _ := io.ReadAll(resp.Body); string(b) != "site.invalid" {
+		t.Fatalf("Host header %q, want site.invalid", b)
+	}
+}
@@ -0,0 +1,114 @@
+// Package mock is an in-memory ports.HTTPClient for tests: it serves
+// canned responses keyed by URL, so crawl logic can run without a server.
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing/iotest"
+)
+
+// Response is a canned response. A non-nil Err is returned from Do instead.
+type Response struct {
+	Status      int // 0 means 200
+	ContentType string
+	Body        string
+	Err         error
+	// BodyErr, when set, is what reading the body fails with after Body.
+	BodyErr error
+	// FinalURL, when set, is where the request ended up after redirects:
+	// the response's Request carries it.
+	FinalURL string
+}
+
+// Client answers requests from its responses; unknown URLs get a 404.
+// It is safe for concurrent use.
+type Client struct {
+	mu        sync.Mutex
+	responses map[string]Response
+	requests  []string
+}
+
+func New() *Client {
+	return &Client{responses: make(map[string]Response)}
+}
+
+// Set registers the response for url (compared with the request URL's
+// String form).
+func (c *Client) Set(url string, r Response) *Client {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[url] = r
+	return c
+}
+
+// HTML registers a 200 text/html response for url.
+func (c *Client) HTML(url, body string) *Client {
+	return c.Set(url, Response{ContentType: "text/html; charset=utf-8", Body: body})
+}
+
+// Requests returns "METHOD URL" for every request so far, in order.
+func (c *Client) Requests() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.requests...)
+}
+
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	u := req.URL.String()
+	c.mu.Lock()
+	c.requests = append(c.requests, req.Method+" "+u)
+	r, ok := c.responses[u]
+	c.mu.Unlock()
+
+	if !ok {
+		r = Response{Status: http.StatusNotFound, ContentType: "text/plain; charset=utf-8", Body: "not found"}
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	if r.Status == 0 {
+		r.Status = http.StatusOK
+	}
+
+	h := make(http.Header)
+	if r.ContentType != "" {
+		h.Set("Content-Type", r.ContentType)
+	}
+	body := r.Body
+	if req.Method == http.MethodHead {
+		body = ""
+	}
+	var rd io.Reader = strings.NewReader(body)
+	if r.BodyErr != nil {
+		rd = io.MultiReader(rd, iotest.ErrReader(r.BodyErr))
+	}
+	if r.FinalURL != "" {
+		final, err := url.Parse(r.FinalURL)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.URL = final
+	}
+	return &http.Response{
+		Status:        http.StatusText(r.Status),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(rd),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (c *Client) Timeout() float64 { return 0 }
@@ -0,0 +1,58 @@
+package limiter
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+var _ ports.Limiter = (*Jitter)(nil)
+
+// Jitter wraps a limiter and, once it allows a request, waits a random
+// duration in [min, max] more, so requests don't arrive at the perfectly
+// regular pace of a token bucket.
+type Jitter struct {
+	next     ports.Limiter
+	min, max time.Duration
+
+	// randN returns a value in [0, n) and timer starts a timer firing
+	// after d, returning its channel and a func stopping it; both are
+	// swapped in tests.
+	randN func(n int64) int64
+	timer func(d time.Duration) (<-chan time.Time, func())
+}
+
+// NewJitter wraps next with a random delay between min and max; max below
+// min is treated as min.
+func NewJitter(next ports.Limiter, min, max time.Duration) *Jitter {
+	return &Jitter{next: next, min: min, max: max, randN: rand.Int64N, timer: newTimer}
+}
+
+func newTimer(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTimer(d)
+	return t.C, func() { t.Stop() }
+}
+
+func (j *Jitter) Take(ctx context.Context, rawURL string) error {
+	if err := j.next.Take(ctx, rawURL); err != nil {
+		return err
+	}
+
+	d := j.min
+	if j.max > j.min {
+		d += time.Duration(j.randN(int64(j.max-j.min) + 1))
+	}
+	if d <= 0 {
+		return nil
+	}
+	fired, stop := j.timer(d)
+	defer stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-fired:
+		return nil
+	}
+}
@@ -0,0 +1,54 @@
+package limiter
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type noLimit struct{}
+
+func (noLimit) Take(context.Context, string) error { return nil }
+
+func TestJitter_DelaysWithinRange(t *testing.T) {
+	const lo, hi = 20 * time.Millisecond, 40 * time.Millisecond
+	j := NewJitter(noLimit{}, lo, hi)
+
+	// Draw the lowest, highest and a middle value in turn, and fire every
+	// timer at once, noting the delay it was started with.
+	draws := []int64{0, int64(hi - lo), int64(hi-lo) / 2}
+	j.randN = func(n int64) int64 {
+		if n != int64(hi-lo)+1 {
+			t.Fatalf("drew from [0, %d), want [0, %d)", n, int64(hi-lo)+1)
+		}
+		v := draws[0]
+		draws = append(draws[1:], v)
+		return v
+	}
+	var waited []time.Duration
+	j.timer = func(d time.Duration) (<-chan time.Time, func()) {
+		waited = append(waited, d)
+		fired := make(chan time.Time, 1)
+		fired <- time.Time{}
+		return fired, func() {}
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := j.Take(context.Background(), "https://example.com/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []time.Duration{lo, hi, (lo + hi) / 2}; !reflect.DeepEqual(waited, want) {
+		t.Fatalf("waited %v, want %v", waited, want)
+	}
+}
+
+func TestJitter_StopsOnCancel(t *testing.T) {
+	j := NewJitter(noLimit{}, time.Hour, time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := j.Take(ctx, "https://example.com/"); err == nil {
+		t.Fatal("Take should give up when the context ends")
+	}
+}
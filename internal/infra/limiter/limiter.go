@@ -3,39 +3,130 @@ package limiter
 import (
 	"context"
 	"net/url"
+	"path"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rojanmagar2001/godeadlink/internal/ports"
 )
 
-// tokenBucket is a simple rate limiter using a buffered channel.
+var _ ports.Limiter = (*PerHost)(nil)
+
+// DefaultIdleTTL is how long a per-host bucket may go unused before it is
+// reclaimed (and its refill goroutine stopped).
+const DefaultIdleTTL = 5 * time.Minute
+
+// tokenBucket is a simple rate limiter using a buffered channel. Its
+// capacity (the burst size) is fixed; the refill rate can change at runtime.
 type tokenBucket struct {
-	ch chan struct{}
+	ch      chan struct{}
+	stop    chan struct{}
+	changed chan struct{} // wakes the refill goroutine after setRate
+	once    sync.Once
+	rate    atomic.Int64
+	tick    tickerFunc
+
+	lastUsed time.Time // guarded by PerHost.mu
+	// waiters counts Takes holding the bucket; it is raised under
+	// PerHost.mu so a sweep never stops a bucket someone is waiting on.
+	waiters atomic.Int64
+}
+
+// tickerFunc starts a ticker firing every d and returns its channel and a
+// func stopping it; swapped in tests.
+type tickerFunc func(d time.Duration) (<-chan time.Time, func())
+
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
 }
 
-func newTokenBucket(rate int) *tokenBucket {
+func newTokenBucket(rate int, tick tickerFunc) *tokenBucket {
 	tb := &tokenBucket{
-		ch: make(chan struct{}, rate),
+		ch:      make(chan struct{}, max(rate, 1)),
+		stop:    make(chan struct{}),
+		changed: make(chan struct{}, 1),
+		tick:    tick,
+	}
+	tb.rate.Store(int64(rate))
+
+	// One token up front so the first request to a host doesn't wait a
+	// whole tick, without handing every new host a full burst.
+	if rate > 0 {
+		tb.ch <- struct{}{}
 	}
 
-	// Fill tokens periodically
-	go func() {
-		ticker := time.NewTicker(time.Second)
-		defer ticker.Stop()
+	go tb.refill()
+	return tb
+}
+
+// fill tops the bucket up to its burst size.
+func (t *tokenBucket) fill() {
+	for i := int64(len(t.ch)); i < t.rate.Load(); i++ {
+		t.ch <- struct{}{}
+	}
+}
+
+// refill adds one token every 1/rate seconds, re-reading the rate whenever
+// it changes. A rate of 0 adds nothing until the rate is raised.
+func (t *tokenBucket) refill() {
+	for {
+		var tick <-chan time.Time
+		stop := func() {}
+		if rate := t.rate.Load(); rate > 0 {
+			tick, stop = t.tick(time.Second / time.Duration(rate))
+		}
 
-		for range ticker.C {
-			for i := 0; i < rate; i++ {
-				select {
-				case tb.ch <- struct{}{}:
-				default:
-					// bucket full
-				}
+		changed := t.wait(tick)
+		stop()
+		if !changed {
+			return
+		}
+	}
+}
+
+// wait adds a token on every tick until the rate changes (true) or the
+// bucket is stopped (false).
+func (t *tokenBucket) wait(tick <-chan time.Time) bool {
+	for {
+		select {
+		case <-t.stop:
+			return false
+		case <-t.changed:
+			return true
+		case <-tick:
+			select {
+			case t.ch <- struct{}{}:
+			default:
+				// bucket full
 			}
 		}
-	}()
+	}
+}
 
-	return tb
+// setRate changes the refill rate (tokens per second; 0 pauses refills).
+// Stored tokens beyond the new rate are dropped so a slowdown takes effect
+// at once rather than after the old burst is spent.
+func (t *tokenBucket) setRate(rate int) {
+	if rate < 0 {
+		rate = 0
+	}
+	if t.rate.Swap(int64(rate)) == int64(rate) {
+		return
+	}
+	for len(t.ch) > rate {
+		select {
+		case <-t.ch:
+		default:
+		}
+	}
+	select {
+	case t.changed <- struct{}{}:
+	default:
+		// a wake-up is already pending
+	}
 }
 
 func (t *tokenBucket) Take(ctx context.Context) error {
@@ -47,26 +138,49 @@ func (t *tokenBucket) Take(ctx context.Context) error {
 	}
 }
 
-// hostLimiter manages per-host buckets
+// Stop ends the refill goroutine. Safe to call more than once.
+func (t *tokenBucket) Stop() {
+	t.once.Do(func() { close(t.stop) })
+}
+
+// PerHost limits requests per host and, across all hosts, to a global
+// rate: a request waits for a token from its host's bucket, then from the
+// global one.
 type PerHost struct {
 	global *tokenBucket
+	tick   tickerFunc
 
 	mu   sync.Mutex
 	rate int
 	host map[string]*tokenBucket
+	// overrides maps host patterns to a rate used instead of rate.
+	overrides map[string]int
+
+	idleTTL   time.Duration
+	lastSweep time.Time
+	now       func() time.Time
 }
 
-func New(globalRate, perHostRate int) ports.Limiter {
+func New(globalRate, perHostRate int) *PerHost {
+	return newPerHost(globalRate, perHostRate, newTicker)
+}
+
+func newPerHost(globalRate, perHostRate int, tick tickerFunc) *PerHost {
 	if globalRate <= 0 {
 		globalRate = 10
 	}
 	if perHostRate <= 0 {
 		perHostRate = 2
 	}
+	global := newTokenBucket(globalRate, tick)
+	global.fill()
 	return &PerHost{
-		global: newTokenBucket(globalRate),
-		rate:   perHostRate,
-		host:   make(map[string]*tokenBucket),
+		global:  global,
+		tick:    tick,
+		rate:    perHostRate,
+		host:    make(map[string]*tokenBucket),
+		idleTTL: DefaultIdleTTL,
+		now:     time.Now,
 	}
 }
 
@@ -81,12 +195,99 @@ func (h *PerHost) Take(ctx context.Context, rawURL string) error {
 	}
 
 	h.mu.Lock()
+	now := h.now()
+	if now.Sub(h.lastSweep) >= h.idleTTL {
+		h.sweepLocked(now)
+	}
 	tb, ok := h.host[host]
 	if !ok {
-		tb = newTokenBucket(h.rate)
+		tb = newTokenBucket(h.rateForLocked(host), h.tick)
 		h.host[host] = tb
 	}
+	tb.lastUsed = now
+	tb.waiters.Add(1)
 	h.mu.Unlock()
 
-	return tb.Take(ctx)
+	// Host first, so requests queued on a slow host don't hold global tokens.
+	err = tb.Take(ctx)
+	tb.waiters.Add(-1)
+	if err != nil {
+		return err
+	}
+	return h.global.Take(ctx)
+}
+
+// SetRate changes the global and default per-host rates of a running
+// limiter; existing buckets pick the new rate up immediately. Hosts with a
+// SetHostRates override keep it but are still held to the global rate, so
+// a global rate of 0 pauses every request until a later SetRate raises it.
+// Burst sizes stay as they were created.
+func (h *PerHost) SetRate(globalRate, perHostRate int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.global.setRate(globalRate)
+	h.rate = max(perHostRate, 0)
+	for host, tb := range h.host {
+		tb.setRate(h.rateForLocked(host))
+	}
+}
+
+// SetHostRates sets per-host rate overrides, keyed by host name or by a
+// pattern such as "*.partner.com" ('*' as in path.Match). They apply to
+// buckets created afterwards. An exact host wins over patterns; among
+// patterns the longest match wins.
+func (h *PerHost) SetHostRates(rates map[string]int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.overrides = make(map[string]int, len(rates))
+	for pattern, rate := range rates {
+		if rate > 0 {
+			h.overrides[strings.ToLower(pattern)] = rate
+		}
+	}
+}
+
+// rateForLocked returns the bucket rate for host. Caller must hold h.mu.
+func (h *PerHost) rateForLocked(host string) int {
+	host = strings.ToLower(host)
+	if rate, ok := h.overrides[host]; ok {
+		return rate
+	}
+	rate, best := h.rate, ""
+	for pattern, r := range h.overrides {
+		if len(pattern) < len(best) || len(pattern) == len(best) && pattern > best {
+			continue
+		}
+		if ok, _ := path.Match(pattern, host); ok {
+			rate, best = r, pattern
+		}
+	}
+	return rate
+}
+
+// sweepLocked stops and forgets buckets idle for longer than idleTTL,
+// skipping any a Take is still waiting on. Caller must hold h.mu.
+func (h *PerHost) sweepLocked(now time.Time) {
+	for host, tb := range h.host {
+		if now.Sub(tb.lastUsed) >= h.idleTTL && tb.waiters.Load() == 0 {
+			tb.Stop()
+			delete(h.host, host)
+		}
+	}
+	h.lastSweep = now
+}
+
+// Close stops every bucket's refill goroutine. The limiter must not be used
+// afterwards.
+func (h *PerHost) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for host, tb := range h.host {
+		tb.Stop()
+		delete(h.host, host)
+	}
+	h.global.Stop()
 }
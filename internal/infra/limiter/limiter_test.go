@@ -0,0 +1,226 @@
+package limiter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestPerHost_ReclaimsIdleBuckets(t *testing.T) {
+	h := New(1000, 2)
+	defer h.Close()
+
+	clock := time.Now()
+	h.now = func() time.Time { return clock }
+	h.lastSweep = clock
+
+	base := runtime.NumGoroutine()
+
+	ctx := context.Background()
+	const hosts = 50
+	for i := 0; i < hosts; i++ {
+		if err := h.Take(ctx, fmt.Sprintf("https://host%d.example/", i)); err != nil {
+			t.Fatalf("take: %v", err)
+		}
+	}
+	if got := len(h.host); got != hosts {
+		t.Fatalf("expected %d buckets, got %d", hosts, got)
+	}
+	if got := runtime.NumGoroutine(); got < base+hosts {
+		t.Fatalf("expected at least %d goroutines, got %d", base+hosts, got)
+	}
+
+	// Move past the TTL; the next Take sweeps everything except its own host.
+	clock = clock.Add(DefaultIdleTTL + time.Second)
+	if err := h.Take(ctx, "https://fresh.example/"); err != nil {
+		t.Fatalf("take: %v", err)
+	}
+	if got := len(h.host); got != 1 {
+		t.Fatalf("expected 1 bucket after sweep, got %d", got)
+	}
+
+	// Stopped refill goroutines exit asynchronously.
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > base+1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > base+1 {
+		t.Fatalf("expected goroutines to shrink to %d, got %d", base+1, got)
+	}
+}
+
+func TestPerHost_KeepsActiveBuckets(t *testing.T) {
+	h := New(10, 2)
+	defer h.Close()
+
+	clock := time.Now()
+	h.now = func() time.Time { return clock }
+	h.lastSweep = clock
+
+	ctx := context.Background()
+	_ = h.Take(ctx, "https://idle.example/")
+
+	clock = clock.Add(DefaultIdleTTL / 2)
+	_ = h.Take(ctx, "https://busy.example/")
+
+	clock = clock.Add(DefaultIdleTTL/2 + time.Second)
+	_ = h.Take(ctx, "https://busy.example/")
+
+	if _, ok := h.host["idle.example"]; ok {
+		t.Fatalf("idle bucket should have been reclaimed")
+	}
+	if _, ok := h.host["busy.example"]; !ok {
+		t.Fatalf("busy bucket should be kept")
+	}
+}
+
+func TestPerHost_KeepsBucketsWithWaiters(t *testing.T) {
+	h := newPerHost(1000, 1, noTicks)
+	defer h.Close()
+
+	clock := time.Now()
+	h.now = func() time.Time { return clock }
+	h.lastSweep = clock
+
+	const slow = "https://slow.example/"
+	if blocks(h, slow) {
+		t.Fatal("expected a new host to start with one token")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- h.Take(ctx, slow) }()
+	for h.host["slow.example"].waiters.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The waiter's bucket has gone unused past the TTL but must survive
+	// the sweep the next Take runs.
+	clock = clock.Add(DefaultIdleTTL + time.Second)
+	if blocks(h, "https://fresh.example/") {
+		t.Fatal("expected a token for a fresh host")
+	}
+	if _, ok := h.host["slow.example"]; !ok {
+		t.Fatal("a bucket with a waiter should not be reclaimed")
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("expected the waiter to give up on cancel")
+	}
+	clock = clock.Add(DefaultIdleTTL + time.Second)
+	_ = h.Take(context.Background(), "https://fresh.example/")
+	if _, ok := h.host["slow.example"]; ok {
+		t.Fatal("expected the bucket to be reclaimed once nobody waits on it")
+	}
+}
+
+func TestPerHost_NewHostGetsOneToken(t *testing.T) {
+	h := newPerHost(1000, 5, noTicks)
+	defer h.Close()
+
+	if blocks(h, "https://a.example/") {
+		t.Fatal("expected a token for the first request")
+	}
+	if !blocks(h, "https://a.example/") {
+		t.Fatal("expected no burst beyond the first token")
+	}
+}
+
+func TestPerHost_HostRateOverrides(t *testing.T) {
+	h := New(10, 2)
+	defer h.Close()
+	h.SetHostRates(map[string]int{
+		"api.partner.com": 1,
+		"*.partner.com":   3,
+		"*.com":           7,
+	})
+
+	ctx := context.Background()
+	for host, want := range map[string]int{
+		"api.partner.com": 1,
+		"www.partner.com": 3,
+		"WWW.Partner.com": 3,
+		"example.com":     7,
+		"example.org":     2,
+	} {
+		if err := h.Take(ctx, "https://"+host+"/"); err != nil {
+			t.Fatalf("take: %v", err)
+		}
+		if got := cap(h.host[host].ch); got != want {
+			t.Errorf("%s: expected rate %d, got %d", host, want, got)
+		}
+	}
+}
+
+// noTicks never refills a bucket, so only the tokens it starts with count.
+func noTicks(time.Duration) (<-chan time.Time, func()) { return nil, func() {} }
+
+// blocks reports whether Take on link has to wait for a refill.
+func blocks(h *PerHost, link string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	return h.Take(ctx, link) != nil
+}
+
+func TestPerHost_GlobalRateCapsAllHosts(t *testing.T) {
+	h := newPerHost(3, 2, noTicks)
+	defer h.Close()
+
+	for _, link := range []string{"https://a.example/", "https://b.example/", "https://c.example/"} {
+		if blocks(h, link) {
+			t.Fatalf("%s: expected a token", link)
+		}
+	}
+	// d.example holds its first token; the global bucket is empty.
+	if !blocks(h, "https://d.example/") {
+		t.Fatal("expected the global rate to hold back a fourth request")
+	}
+}
+
+func TestPerHost_SetRate(t *testing.T) {
+	h := newPerHost(10, 5, noTicks)
+	defer h.Close()
+	h.SetHostRates(map[string]int{"fast.example": 50})
+
+	const fast, plain = "https://fast.example/", "https://plain.example/"
+	for _, link := range []string{fast, plain} {
+		if blocks(h, link) {
+			t.Fatalf("%s: expected a token", link)
+		}
+	}
+	h.host["fast.example"].fill()
+
+	// Slow down: the default host rate drops, the override stays, and the
+	// global rate holds the overridden host back too.
+	h.SetRate(1, 1)
+	if got := h.host["plain.example"].rate.Load(); got != 1 {
+		t.Errorf("plain.example: expected rate 1, got %d", got)
+	}
+	if got := h.host["fast.example"].rate.Load(); got != 50 {
+		t.Errorf("fast.example: expected its override of 50, got %d", got)
+	}
+	if blocks(h, fast) {
+		t.Fatal("expected the one global token left after slowing down")
+	}
+	if !blocks(h, fast) {
+		t.Fatal("expected a global rate of 1 to hold back an overridden host")
+	}
+
+	h.SetRate(0, 5)
+	if got := h.global.rate.Load(); got != 0 {
+		t.Fatalf("expected a paused global rate, got %d", got)
+	}
+	if !blocks(h, fast) {
+		t.Fatal("expected a global rate of 0 to pause every host")
+	}
+
+	h.SetRate(10, 5)
+	if got := h.host["plain.example"].rate.Load(); got != 5 {
+		t.Errorf("plain.example: expected rate 5 after resuming, got %d", got)
+	}
+	if got := h.global.rate.Load(); got != 10 {
+		t.Errorf("expected global rate 10 after resuming, got %d", got)
+	}
+}
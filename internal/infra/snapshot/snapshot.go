@@ -0,0 +1,131 @@
+// Package snapshot saves the pages a crawl fetched to a directory and
+// serves them back as a ports.HTTPClient, so a crawl can be replayed
+// offline. Each page is two files named after a hash of its URL: NAME.json
+// with the URL, final URL, status and headers, and NAME.body with the raw
+// body.
+package snapshot
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+var (
+	_ ports.PageSaver  = (*Saver)(nil)
+	_ ports.HTTPClient = (*Replay)(nil)
+)
+
+type meta struct {
+	URL      string      `json:"url"`
+	FinalURL string      `json:"final_url,omitempty"` // only when redirected
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+}
+
+// name is the file name (without extension) of url's page.
+func name(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Saver writes pages into a directory, creating it when needed. A page
+// saved twice keeps the last version.
+type Saver struct {
+	dir string
+}
+
+func NewSaver(dir string) *Saver {
+	return &Saver{dir: dir}
+}
+
+func (s *Saver) SavePage(url, finalURL string, status int, header http.Header, body []byte) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	m := meta{URL: url, Status: status, Header: header}
+	if finalURL != url {
+		m.FinalURL = finalURL
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	base := filepath.Join(s.dir, name(url))
+	// The body goes first: a page counts as saved once its .json exists.
+	if err := os.WriteFile(base+".body", body, 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+".json", append(b, '\n'), 0o644)
+}
+
+// Replay answers requests with the pages saved in a directory. A URL
+// that was not saved is an error, as an unreachable page would be. A page
+// saved after redirects answers with its final URL as resp.Request.URL,
+// as the HTTP client would.
+type Replay struct {
+	dir string
+}
+
+func NewReplay(dir string) *Replay {
+	return &Replay{dir: dir}
+}
+
+func (r *Replay) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	base := filepath.Join(r.dir, name(req.URL.String()))
+	b, err := os.ReadFile(base + ".json")
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("snapshot: %s was not saved", req.URL)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("snapshot: %w", err)
+	}
+	var m meta
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("snapshot: %s: %w", base+".json", err)
+	}
+	var body []byte
+	if req.Method != http.MethodHead {
+		if body, err = os.ReadFile(base + ".body"); err != nil {
+			return nil, fmt.Errorf("snapshot: %w", err)
+		}
+	}
+	if m.Header == nil {
+		m.Header = http.Header{}
+	}
+	if m.FinalURL != "" {
+		final, err := url.Parse(m.FinalURL)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot: %s: final url: %w", base+".json", err)
+		}
+		req = req.Clone(req.Context())
+		req.URL = final
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", m.Status, http.StatusText(m.Status)),
+		StatusCode:    m.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        m.Header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Replay) Timeout() float64 { return 0 }
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// bloomFPRate is the false-positive rate a bloom filter is sized for at
+// its expected number of keys.
+const bloomFPRate = 0.001
+
+// bloom is a fixed-size bloom filter. add reports whether a key was new;
+// a false positive makes it report an unseen key as seen, never the
+// reverse. Its memory does not grow with the number of keys, but the
+// false-positive rate rises past the expected count it was sized for.
+type bloom struct {
+	bits   []uint64
+	m      uint64 // number of bits
+	hashes uint64
+	n      int // keys reported new
+}
+
+// newBloom sizes a filter for expected keys at bloomFPRate.
+func newBloom(expected int) *bloom {
+	if expected < 1 {
+		expected = 1
+	}
+	n := float64(expected)
+	m := uint64(math.Ceil(-n * math.Log(bloomFPRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/n*math.Ln2)))
+	return &bloom{bits: make([]uint64, (m+63)/64), m: m, hashes: k}
+}
+
+// add sets key's bits and reports whether any was unset before.
+func (b *bloom) add(key string) bool {
+	// Double hashing (Kirsch-Mitzenmacher): bit i is h1 + i*h2, with both
+	// halves taken from one 128-bit FNV-1a sum.
+	h := fnv.New128a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum(nil)
+	h1 := binary.BigEndian.Uint64(sum[:8])
+	h2 := binary.BigEndian.Uint64(sum[8:]) | 1
+
+	added := false
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.m
+		word, mask := bit/64, uint64(1)<<(bit%64)
+		if b.bits[word]&mask == 0 {
+			b.bits[word] |= mask
+			added = true
+		}
+	}
+	if added {
+		b.n++
+	}
+	return added
+}
+
+// size is the filter's memory in bytes.
+func (b *bloom) size() int { return len(b.bits) * 8 }
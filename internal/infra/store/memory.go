@@ -1,12 +1,14 @@
 package store
 
 import (
+	"hash/fnv"
 	"net/url"
 	"sort"
 	"strings"
 	"sync"
 
 	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/urlnorm"
 )
 
 type Memory struct {
@@ -14,20 +16,135 @@ type Memory struct {
 
 	visited map[string]struct{}
 	links   map[string]*domain.LinkMeta
+	pages   map[string]domain.Result
+	key     func(string) string
+	// id is the identity links, visited pages and page results are
+	// deduped by: key, minus stripParams. key stays the URL checked.
+	id func(string) string
+
+	// extractErrors are the pages whose links could not be extracted.
+	extractErrors []domain.PageError
+
+	// visitedBloom, when set, replaces visited (see WithVisitedBloom).
+	visitedBloom *bloom
+	// maxSources caps each link's Sources (0 = no limit); overflow holds
+	// hashes of the sources counted beyond it, by link, so each page is
+	// counted once.
+	maxSources int
+	overflow   map[string]map[uint64]struct{}
+
+	// visitIgnoresQuery keys visited pages without their query string.
+	visitIgnoresQuery bool
+	// stripParams are query parameters dropped from every key.
+	stripParams map[string]bool
+	// normalizeEscapes normalizes the percent-encoding of every key.
+	normalizeEscapes bool
 }
 
-func NewMemory() *Memory {
-	return &Memory{
+// Option configures a Memory store.
+type Option func(*Memory)
+
+// WithURLKeyFunc replaces the default URL normalization used to dedup
+// visited pages, discovered links and their sources. The key is also the
+// URL that gets checked, so fn must return a fetchable URL. fn must be
+// deterministic and idempotent (fn(fn(u)) == fn(u)); a key func that maps
+// the same page to different keys silently defeats dedup, so pages may be
+// crawled and links checked more than once.
+func WithURLKeyFunc(fn func(string) string) Option {
+	return func(m *Memory) {
+		if fn != nil {
+			m.key = fn
+		}
+	}
+}
+
+// WithVisitIgnoringQuery makes MarkVisitedPage treat URLs that differ only
+// in their query string as the same page, so only the first variant is
+// crawled. Discovered links keep their full URL and are all checked.
+func WithVisitIgnoringQuery(enabled bool) Option {
+	return func(m *Memory) { m.visitIgnoresQuery = enabled }
+}
+
+// WithStrippedParams ignores the named query parameters when deduping
+// links and pages, on top of the key func. It is meant for cache-busting
+// parameters (?v=abc123) that change every build, so the same asset is
+// recognized across runs. A link is still checked, and reported, as the
+// URL it was first seen with, parameters included, since servers may
+// route or cache on them.
+func WithStrippedParams(names []string) Option {
+	return func(m *Memory) {
+		for _, n := range names {
+			if m.stripParams == nil {
+				m.stripParams = make(map[string]bool)
+			}
+			m.stripParams[n] = true
+		}
+	}
+}
+
+// WithEscapeNormalization normalizes the percent-encoding of keys, on top
+// of the key func (see urlnorm.NormalizeEscapes), so /%7Euser and /~user,
+// or /a%2f and /a%2F, are one link. Escaped reserved characters are kept:
+// /a%2Fb and /a/b stay distinct.
+func WithEscapeNormalization(enabled bool) Option {
+	return func(m *Memory) { m.normalizeEscapes = enabled }
+}
+
+// WithVisitedBloom keeps the visited pages in a bloom filter sized for
+// expected pages instead of a map, so their memory stays fixed however
+// large the crawl gets. The price is a false-positive rate of about 0.1%
+// up to expected pages (rising beyond it): such a page is taken as
+// visited and not crawled, though its links found elsewhere are still
+// checked. To keep the rest of the store from growing with every page,
+// RecordPageResult then keeps only the results of pages that failed, so
+// crawled pages are fetched again when they are checked as links, and
+// the caller should not record crawled pages as links of their own (see
+// usecase.Crawler.NoSelfLinks). expected <= 0 keeps the exact map.
+func WithVisitedBloom(expected int) Option {
+	return func(m *Memory) {
+		if expected > 0 {
+			m.visitedBloom = newBloom(expected)
+		}
+	}
+}
+
+// WithMaxSources keeps at most n source pages per link (n <= 0 keeps them
+// all), so a link on every page of a large site does not hold every page.
+// LinkMeta.SourceCount still counts every distinct page, keeping a hash
+// of each one past the cap.
+func WithMaxSources(n int) Option {
+	return func(m *Memory) { m.maxSources = n }
+}
+
+func NewMemory(opts ...Option) *Memory {
+	m := &Memory{
 		visited: make(map[string]struct{}),
 		links:   make(map[string]*domain.LinkMeta),
+		pages:   make(map[string]domain.Result),
+		key:     normalizeForKey,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.normalizeEscapes {
+		key := m.key
+		m.key = func(raw string) string { return normalizeEscapes(key(raw)) }
+	}
+	m.id = m.key
+	if len(m.stripParams) > 0 {
+		m.id = func(raw string) string { return stripQueryParams(m.key(raw), m.stripParams) }
 	}
+	return m
 }
 
 func (m *Memory) MarkVisitedPage(url string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	k := normalizeForKey(url)
+	k := m.visitKey(url)
+	if m.visitedBloom != nil {
+		return m.visitedBloom.add(k)
+	}
 	if _, ok := m.visited[k]; ok {
 		return false
 	}
@@ -35,9 +152,34 @@ func (m *Memory) MarkVisitedPage(url string) bool {
 	return true
 }
 
+// VisitKey returns the key MarkVisitedPage dedups url by, so callers can
+// tell whether two URLs are the same page to the store.
+func (m *Memory) VisitKey(url string) string {
+	return m.visitKey(url)
+}
+
+// visitKey is the crawl-dedup key: the link identity, minus the query
+// string when visitIgnoresQuery is set.
+func (m *Memory) visitKey(raw string) string {
+	k := m.id(raw)
+	if !m.visitIgnoresQuery {
+		return k
+	}
+	u, err := url.Parse(k)
+	if err != nil {
+		return k
+	}
+	u.RawQuery = ""
+	u.ForceQuery = false
+	return u.String()
+}
+
 func (m *Memory) VisitedCount() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.visitedBloom != nil {
+		return m.visitedBloom.n
+	}
 	return len(m.visited)
 }
 
@@ -45,10 +187,16 @@ func (m *Memory) RecordDiscoveredLink(meta domain.LinkMeta, sourcePage string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	k := normalizeForKey(meta.URL)
+	// Skipped links that normalize to nothing keep their raw text:
+	// otherwise "#top" would become "" and every fragment-only link would
+	// merge into one.
+	k, checkURL := m.id(meta.URL), m.key(meta.URL)
+	if k == "" && meta.Skipped != "" {
+		k, checkURL = meta.URL, meta.URL
+	}
 	ex, ok := m.links[k]
 	if !ok {
-		meta.URL = k
+		meta.URL = checkURL
 		if meta.Sources == nil {
 			meta.Sources = map[string]struct{}{}
 		}
@@ -62,13 +210,58 @@ func (m *Memory) RecordDiscoveredLink(meta domain.LinkMeta, sourcePage string) {
 	if meta.Kind != "" {
 		ex.Kind = meta.Kind
 	}
-	if meta.Skipped != "" {
+	// A link skipped only for where it was found gets checked when any
+	// page finds it checkable; a link skipped for what it is (its scheme,
+	// an invalid URL) stays skipped, whichever page recorded it first.
+	switch {
+	case meta.Skipped == "":
+		if ex.Skipped.PerPage() {
+			ex.Skipped = ""
+		}
+	case !meta.Skipped.PerPage():
 		ex.Skipped = meta.Skipped
 	}
+	if meta.Warning != "" {
+		ex.Warning = meta.Warning
+	}
+	if meta.Integrity != "" {
+		ex.Integrity = meta.Integrity
+	}
 
 	if sourcePage != "" {
-		ex.Sources[normalizeForKey(sourcePage)] = struct{}{}
+		src := m.key(sourcePage)
+		if _, ok := ex.Sources[src]; !ok {
+			switch {
+			case m.maxSources <= 0 || len(ex.Sources) < m.maxSources:
+				ex.Sources[src] = struct{}{}
+				ex.SourceCount++
+			case m.countOverflow(k, src):
+				ex.SourceCount++
+			}
+		}
+	}
+}
+
+// countOverflow reports whether src is new among the sources of link k
+// that did not fit under maxSources, remembering it.
+func (m *Memory) countOverflow(k, src string) bool {
+	h := fnv.New64a()
+	h.Write([]byte(src))
+	sum := h.Sum64()
+
+	seen := m.overflow[k]
+	if seen == nil {
+		if m.overflow == nil {
+			m.overflow = map[string]map[uint64]struct{}{}
+		}
+		seen = map[uint64]struct{}{}
+		m.overflow[k] = seen
+	}
+	if _, ok := seen[sum]; ok {
+		return false
 	}
+	seen[sum] = struct{}{}
+	return true
 }
 
 func (m *Memory) AllDiscovered() []*domain.LinkMeta {
@@ -83,6 +276,98 @@ func (m *Memory) AllDiscovered() []*domain.LinkMeta {
 	return out
 }
 
+func (m *Memory) Discovered(linkURL string) (*domain.LinkMeta, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	meta, ok := m.links[m.id(linkURL)]
+	return meta, ok
+}
+
+// RecordPageResult keeps res under pageURL's identity, with pageURL's
+// link key as its URL; a later result for the same page replaces it.
+// With WithVisitedBloom only dead results are kept.
+func (m *Memory) RecordPageResult(pageURL string, res domain.Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.visitedBloom != nil && !res.IsDead() {
+		delete(m.pages, m.id(pageURL))
+		return
+	}
+	res.URL = m.key(pageURL)
+	m.pages[m.id(pageURL)] = res
+}
+
+func (m *Memory) PageResult(pageURL string) (domain.Result, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	res, ok := m.pages[m.id(pageURL)]
+	return res, ok
+}
+
+func (m *Memory) PageResults() []domain.Result {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]domain.Result, 0, len(m.pages))
+	for _, r := range m.pages {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// RecordExtractError keeps why the links of pageURL could not be
+// extracted.
+func (m *Memory) RecordExtractError(pageURL string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.extractErrors = append(m.extractErrors, domain.PageError{Page: pageURL, Err: err})
+}
+
+// ExtractErrors returns the recorded extraction errors, sorted by page.
+func (m *Memory) ExtractErrors() []domain.PageError {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := append([]domain.PageError(nil), m.extractErrors...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Page < out[j].Page })
+	return out
+}
+
+// stripQueryParams removes the named parameters from raw's query, keeping
+// the others in their original order and encoding.
+func stripQueryParams(raw string, names map[string]bool) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.RawQuery == "" {
+		return raw
+	}
+	var kept []string
+	for _, p := range strings.Split(u.RawQuery, "&") {
+		name, _, _ := strings.Cut(p, "=")
+		if n, err := url.QueryUnescape(name); err == nil {
+			name = n
+		}
+		if !names[name] {
+			kept = append(kept, p)
+		}
+	}
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+func normalizeEscapes(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	urlnorm.NormalizeEscapes(u)
+	return u.String()
+}
+
 // normalizeForKey is a small normalization to improve deduping:
 // - strip fragment
 // - lowercase hostname
@@ -0,0 +1,298 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// stripSession drops the "sid" query parameter on top of the default
+// normalization.
+func stripSession(raw string) string {
+	u, err := url.Parse(normalizeForKey(raw))
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	q.Del("sid")
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func TestMemory_URLKeyFunc(t *testing.T) {
+	links := []string{
+		"https://example.com/a?sid=1",
+		"https://example.com/a?sid=2",
+		"https://EXAMPLE.com/a",
+		"https://example.com/b?sid=1&page=2",
+	}
+
+	record := func(m *Memory) {
+		for _, l := range links {
+			m.RecordDiscoveredLink(domain.LinkMeta{URL: l, Kind: domain.LinkKindPage}, "https://example.com/?sid=9")
+		}
+	}
+
+	def := NewMemory()
+	record(def)
+	if n := len(def.AllDiscovered()); n != 4 {
+		t.Fatalf("default key: expected 4 links, got %d", n)
+	}
+
+	custom := NewMemory(WithURLKeyFunc(stripSession))
+	record(custom)
+	got := custom.AllDiscovered()
+	if len(got) != 2 {
+		t.Fatalf("custom key: expected 2 links, got %d", len(got))
+	}
+	if got[0].URL != "https://example.com/a" || got[1].URL != "https://example.com/b?page=2" {
+		t.Fatalf("custom key: unexpected links %s, %s", got[0].URL, got[1].URL)
+	}
+	if _, ok := got[0].Sources["https://example.com/"]; !ok || len(got[0].Sources) != 1 {
+		t.Fatalf("custom key: sources should be keyed too, got %v", got[0].Sources)
+	}
+
+	if !custom.MarkVisitedPage("https://example.com/a?sid=1") {
+		t.Fatal("first visit should be new")
+	}
+	if custom.MarkVisitedPage("https://example.com/a?sid=2") {
+		t.Fatal("same page with another session should already be visited")
+	}
+}
+
+func TestMemory_StrippedParams(t *testing.T) {
+	m := NewMemory(WithStrippedParams([]string{"v", "hash"}))
+	for _, l := range []string{
+		"https://example.com/app.js?v=1",
+		"https://example.com/app.js?v=2",
+		"https://example.com/app.css?hash=abc&media=print",
+		"https://example.com/app.css?media=print&hash=def",
+		"https://example.com/page?id=7",
+	} {
+		m.RecordDiscoveredLink(domain.LinkMeta{URL: l, Kind: domain.LinkKindAsset}, "https://example.com/")
+	}
+
+	// Variants are one link, checked as first seen.
+	var got []string
+	for _, l := range m.AllDiscovered() {
+		got = append(got, l.URL)
+	}
+	want := []string{
+		"https://example.com/app.css?hash=abc&media=print",
+		"https://example.com/app.js?v=1",
+		"https://example.com/page?id=7",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if l, ok := m.Discovered("https://example.com/app.js?v=9"); !ok || l.URL != "https://example.com/app.js?v=1" {
+		t.Fatalf("lookup by another variant: got %v, %v", l, ok)
+	}
+
+	m.RecordPageResult("https://example.com/?v=3", domain.Result{StatusCode: 200})
+	if !m.MarkVisitedPage("https://example.com/?v=3") || m.MarkVisitedPage("https://example.com/?v=4") {
+		t.Fatal("page variants should be visited once")
+	}
+	if r, ok := m.PageResult("https://example.com/?v=4"); !ok || r.URL != "https://example.com/?v=3" {
+		t.Fatalf("page result by another variant: got %+v, %v", r, ok)
+	}
+}
+
+func TestMemory_EscapeNormalization(t *testing.T) {
+	links := []string{
+		"https://example.com/~user",
+		"https://example.com/%7Euser",
+		"https://example.com/%7euser",
+		"https://example.com/a%2fb",
+		"https://example.com/a%2Fb",
+		"https://example.com/a/b",
+		"https://example.com/a b",
+		"https://example.com/a%20b",
+	}
+	record := func(m *Memory) []string {
+		for _, l := range links {
+			m.RecordDiscoveredLink(domain.LinkMeta{URL: l, Kind: domain.LinkKindPage}, "https://example.com/%7Euser")
+		}
+		var got []string
+		for _, l := range m.AllDiscovered() {
+			got = append(got, l.URL)
+		}
+		return got
+	}
+
+	if got := record(NewMemory()); len(got) != 7 {
+		t.Fatalf("default key: expected 7 links, got %v", got)
+	}
+
+	m := NewMemory(WithEscapeNormalization(true))
+	got := record(m)
+	want := []string{
+		"https://example.com/a%20b",
+		"https://example.com/a%2Fb",
+		"https://example.com/a/b",
+		"https://example.com/~user",
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, ok := m.AllDiscovered()[3].Sources["https://example.com/~user"]; !ok {
+		t.Fatalf("sources should be normalized too, got %v", m.AllDiscovered()[3].Sources)
+	}
+}
+
+func TestMemory_PageResults(t *testing.T) {
+	m := NewMemory()
+	m.RecordPageResult("https://EXAMPLE.com/b#top", domain.Result{StatusCode: 500})
+	m.RecordPageResult("https://example.com/a", domain.Result{StatusCode: 404})
+	m.RecordPageResult("https://example.com/a", domain.Result{StatusCode: 200})
+
+	// Pages are keyed like links; a later result replaces an earlier one.
+	res, ok := m.PageResult("https://example.com/b")
+	if !ok || res.StatusCode != 500 || res.URL != "https://example.com/b" {
+		t.Fatalf("PageResult(/b) = %+v, %v", res, ok)
+	}
+	all := m.PageResults()
+	if len(all) != 2 || all[0].URL != "https://example.com/a" || all[0].StatusCode != 200 {
+		t.Fatalf("PageResults() = %+v", all)
+	}
+	if _, ok := m.PageResult("https://example.com/c"); ok {
+		t.Fatal("PageResult of an uncrawled page should not be found")
+	}
+}
+
+func TestMemory_VisitedBloom(t *testing.T) {
+	const expected = 200_000
+	m := NewMemory(WithVisitedBloom(expected))
+	size := m.visitedBloom.size()
+	// ~14.4 bits per key at 0.1%, far below a map of URL strings.
+	if size > expected*2 {
+		t.Fatalf("bloom uses %d bytes for %d pages", size, expected)
+	}
+
+	page := func(i int) string { return fmt.Sprintf("https://example.com/section/%d/page-%d?q=%d", i%97, i, i*7) }
+	missed := 0
+	for i := range expected {
+		if !m.MarkVisitedPage(page(i)) {
+			missed++ // false positive: a new page taken as visited
+		}
+	}
+	if rate := float64(missed) / expected; rate > 0.005 {
+		t.Fatalf("%d of %d new pages taken as visited (%.3f%%)", missed, expected, rate*100)
+	}
+	if n := m.VisitedCount(); n != expected-missed {
+		t.Fatalf("VisitedCount = %d, want %d", n, expected-missed)
+	}
+	// No false negatives: every page is still known after the run.
+	for i := range expected {
+		if m.MarkVisitedPage(page(i)) {
+			t.Fatalf("page %d marked new twice", i)
+		}
+	}
+
+	// Past the expected count the filter stays the same size.
+	for i := expected; i < 2*expected; i++ {
+		m.MarkVisitedPage(page(i))
+	}
+	if got := m.visitedBloom.size(); got != size {
+		t.Fatalf("bloom grew from %d to %d bytes", size, got)
+	}
+}
+
+func TestMemory_MaxSources(t *testing.T) {
+	m := NewMemory(WithMaxSources(3))
+	footer := domain.LinkMeta{URL: "https://example.com/privacy", Kind: domain.LinkKindPage}
+	for i := range 100 {
+		m.RecordDiscoveredLink(footer, fmt.Sprintf("https://example.com/p/%d", i))
+	}
+	// Pages already counted are not counted again, kept or not.
+	m.RecordDiscoveredLink(footer, "https://example.com/p/0")
+	m.RecordDiscoveredLink(footer, "https://EXAMPLE.com/p/1")
+	m.RecordDiscoveredLink(footer, "https://example.com/p/50")
+	m.RecordDiscoveredLink(footer, "https://example.com/p/99")
+
+	got := m.AllDiscovered()[0]
+	if len(got.Sources) != 3 || got.SourceCount != 100 {
+		t.Fatalf("got %d sources and a count of %d, want 3 and 100", len(got.Sources), got.SourceCount)
+	}
+	for _, src := range []string{"https://example.com/p/0", "https://example.com/p/1", "https://example.com/p/2"} {
+		if _, ok := got.Sources[src]; !ok {
+			t.Fatalf("the first sources should be kept, got %v", got.Sources)
+		}
+	}
+
+	// No cap by default.
+	m = NewMemory()
+	for i := range 100 {
+		m.RecordDiscoveredLink(footer, fmt.Sprintf("https://example.com/p/%d", i))
+	}
+	if got := m.AllDiscovered()[0]; len(got.Sources) != 100 || got.SourceCount != 100 {
+		t.Fatalf("got %d sources and a count of %d, want 100 each", len(got.Sources), got.SourceCount)
+	}
+}
+
+func TestMemory_SkippedInEitherOrder(t *testing.T) {
+	const link = "https://other.example/x"
+	checkable := domain.LinkMeta{URL: link, Kind: domain.LinkKindPage}
+	for _, tc := range []struct {
+		skipped domain.SkipReason
+		want    domain.SkipReason
+	}{
+		// Found within the external depth on some page: checked.
+		{domain.SkipExternalDepth, ""},
+		{domain.SkipHostBudget, ""},
+		// Not checkable wherever it is found.
+		{domain.SkipInvalidURL, domain.SkipInvalidURL},
+		{domain.SkipUnsupportedScheme, domain.SkipUnsupportedScheme},
+	} {
+		skipped := checkable
+		skipped.Skipped = tc.skipped
+		for name, order := range map[string][]domain.LinkMeta{
+			"skipped first":   {skipped, checkable},
+			"checkable first": {checkable, skipped},
+		} {
+			m := NewMemory()
+			for i, meta := range order {
+				m.RecordDiscoveredLink(meta, fmt.Sprintf("https://example.com/p/%d", i))
+			}
+			if got := m.AllDiscovered()[0].Skipped; got != tc.want {
+				t.Errorf("%s, %s: got skip reason %q, want %q", tc.skipped, name, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestMemory_VisitedBloomKeepsOnlyFailedPages(t *testing.T) {
+	const pages = 10_000
+	for _, bloom := range []bool{false, true} {
+		var opts []Option
+		if bloom {
+			opts = append(opts, WithVisitedBloom(pages))
+		}
+		m := NewMemory(opts...)
+		for i := range pages {
+			page := fmt.Sprintf("https://example.com/page-%d", i)
+			m.MarkVisitedPage(page)
+			status := 200
+			if i%1000 == 0 {
+				status = 404
+			}
+			m.RecordPageResult(page, domain.Result{StatusCode: status})
+			m.RecordDiscoveredLink(domain.LinkMeta{URL: "https://example.com/", Kind: domain.LinkKindPage}, page)
+		}
+
+		// Without self-links the only link is the one every page shares,
+		// and the bloom filter keeps the page results down to the failures.
+		want := pages
+		if bloom {
+			want = pages / 1000
+		}
+		if got := len(m.pages); got != want {
+			t.Errorf("bloom %v: %d page results kept, want %d", bloom, got, want)
+		}
+		if got := len(m.links); got != 1 {
+			t.Errorf("bloom %v: %d links kept, want 1", bloom, got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package wscheck
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+var _ ports.SchemeChecker = (*Checker)(nil)
+
+// acceptGUID is appended to the client key to form Sec-WebSocket-Accept
+// (RFC 6455, section 1.3).
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Checker checks ws:// and wss:// links by opening a WebSocket handshake
+// and closing the connection once the server has switched protocols. No
+// messages are exchanged.
+type Checker struct {
+	timeout   time.Duration
+	userAgent string
+	client    *http.Client
+}
+
+// New returns a Checker sending handshakes through rt (nil for the
+// default transport), so they go where HTTP checks go (resolve overrides,
+// unix socket, minimum TLS version), with userAgent as User-Agent.
+func New(timeout time.Duration, rt http.RoundTripper, userAgent string) *Checker {
+	return &Checker{
+		timeout:   timeout,
+		userAgent: userAgent,
+		client: &http.Client{
+			Transport: rt,
+			// A redirected handshake is not one a browser would complete.
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+	}
+}
+
+// Check sends the HTTP Upgrade request and expects 101 Switching
+// Protocols with the matching Sec-WebSocket-Accept; the answer's status
+// becomes the result's status code. Any other answer is an error.
+func (c *Checker) Check(ctx context.Context, rawURL string) domain.Result {
+	start := time.Now()
+	res := domain.Result{URL: rawURL, FinalURL: rawURL, Method: http.MethodGet}
+
+	code, err := c.handshake(ctx, rawURL)
+	res.Elapsed = time.Since(start)
+	res.StatusCode = code
+	if err != nil {
+		res.Err = fmt.Errorf("websocket: %w", err)
+	}
+	return res
+}
+
+func (c *Checker) handshake(ctx context.Context, rawURL string) (int, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return 0, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, err
+	}
+	key := newKey()
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// After a 101 the body is the raw connection; closing it hangs up.
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return resp.StatusCode, fmt.Errorf("no protocol switch: %s", resp.Status)
+	}
+	if !strings.EqualFold(resp.Header.Get("Upgrade"), "websocket") {
+		return resp.StatusCode, errors.New("switched to a protocol other than websocket")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return resp.StatusCode, errors.New("bad Sec-WebSocket-Accept")
+	}
+	return resp.StatusCode, nil
+}
+
+// newKey returns a random Sec-WebSocket-Key.
+func newKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// acceptKey is the Sec-WebSocket-Accept a server answers key with.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,89 @@
+package wscheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upgrade completes a WebSocket handshake and hangs up.
+func upgrade(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		http.Error(w, "websocket only", http.StatusBadRequest)
+		return
+	}
+	conn, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+	_ = buf.Flush()
+}
+
+func TestChecker_Check(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/socket", upgrade)
+	mux.HandleFunc("/page", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	ws := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	c := New(2*time.Second, nil, "")
+	ctx := context.Background()
+
+	if r := c.Check(ctx, ws+"/socket"); r.Err != nil || r.StatusCode != http.StatusSwitchingProtocols || r.IsDead() {
+		t.Fatalf("expected a live 101 result, got %+v", r)
+	}
+
+	// A plain HTTP endpoint answers without switching protocols.
+	if r := c.Check(ctx, ws+"/page"); r.StatusCode != http.StatusOK || !r.IsDead() {
+		t.Fatalf("expected a dead 200 result, got %+v", r)
+	}
+	if r := c.Check(ctx, ws+"/missing"); r.StatusCode != http.StatusNotFound || !r.IsDead() {
+		t.Fatalf("expected a dead 404 result, got %+v", r)
+	}
+}
+
+// countingTransport counts the requests it sends on.
+type countingTransport struct {
+	http.RoundTripper
+	n int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return t.RoundTripper.RoundTrip(req)
+}
+
+func TestChecker_TransportAndUserAgent(t *testing.T) {
+	var ua string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua = r.UserAgent()
+		upgrade(w, r)
+	}))
+	defer srv.Close()
+
+	tr := &countingTransport{RoundTripper: http.DefaultTransport}
+	c := New(2*time.Second, tr, "test-bot/1.0")
+	if r := c.Check(context.Background(), "ws"+strings.TrimPrefix(srv.URL, "http")); r.Err != nil {
+		t.Fatalf("check: %v", r.Err)
+	}
+	if tr.n != 1 || ua != "test-bot/1.0" {
+		t.Fatalf("expected one request through the transport as test-bot/1.0, got %d as %q", tr.n, ua)
+	}
+}
+
+func TestAcceptKey(t *testing.T) {
+	// The example handshake from RFC 6455, section 1.3.
+	if got := acceptKey("dGhlIHNhbXBsZSBub25jZQ=="); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("acceptKey = %q", got)
+	}
+}
@@ -16,3 +16,11 @@ const (
 	SkipExternal          SkipReason = "external"
 	SkipEmpty             SkipReason = "empty"
 )
+
+// LinkWarning flags a link that can be checked but is probably not what the
+// author meant.
+type LinkWarning string
+
+const (
+	WarnSchemeLessHost LinkWarning = "scheme_less_host"
+)
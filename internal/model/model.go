@@ -7,6 +7,42 @@ type Result struct {
 	StatusCode int
 	Err        error
 	Elapsed    time.Duration
+
+	// FinalURL is where the request ended up after following redirects.
+	FinalURL string
+	// RedirectChain lists each redirect response in order; empty if none.
+	RedirectChain []Hop
+	// Attempts records the outcome of each try when retries are enabled.
+	Attempts []Attempt
+	// Empty is set when the response declared Content-Length: 0 or a GET
+	// body drained to zero bytes.
+	Empty bool
+	// ContentLength is the declared body size, -1 if unknown.
+	ContentLength int64
+	// Method lists the request methods used, in order: "HEAD", "GET", or
+	// "HEAD,GET" when HEAD fell back to GET.
+	Method string
+	// LastModified is the response's Last-Modified header; zero if it was
+	// missing or unparseable.
+	LastModified time.Time
+	// Headers holds the response headers the checker was asked to
+	// capture, by canonical name; nil if none were present.
+	Headers map[string]string
+	// Title is the <title> of the HTML error page a dead GET returned;
+	// empty otherwise.
+	Title string
+}
+
+// Attempt is the outcome of one try: a status code or an error message.
+type Attempt struct {
+	StatusCode int
+	Err        string
+}
+
+// Hop is one redirect response: the URL requested and the 3xx it returned.
+type Hop struct {
+	URL        string
+	StatusCode int
 }
 
 func (r Result) IsDead() bool {
@@ -7,5 +7,11 @@ import (
 )
 
 type Extractor interface {
-	Extract(baseUrl string, r io.Reader) ([]domain.FoundLink, error)
+	Extract(baseUrl string, r io.Reader) (domain.Page, error)
+}
+
+// CSSExtractor finds what a stylesheet references: its url() values and
+// @import rules, all as assets.
+type CSSExtractor interface {
+	ExtractCSS(baseURL string, r io.Reader) (domain.Page, error)
 }
@@ -6,3 +6,10 @@ type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 	Timeout() (seconds float64) // optional hook (can return 0)
 }
+
+// PageSaver keeps the pages a crawl fetched, so it can be replayed
+// without the network. finalURL is where redirects ended (url when there
+// were none).
+type PageSaver interface {
+	SavePage(url, finalURL string, status int, header http.Header, body []byte) error
+}
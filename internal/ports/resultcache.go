@@ -0,0 +1,10 @@
+package ports
+
+import "github.com/rojanmagar2001/godeadlink/internal/domain"
+
+// ResultCache holds check results by URL so a link need not be checked
+// over the network again.
+type ResultCache interface {
+	Get(url string) (domain.Result, bool)
+	Put(url string, r domain.Result)
+}
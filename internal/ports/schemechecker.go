@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// SchemeChecker checks links with a non-HTTP scheme (ftp://...).
+type SchemeChecker interface {
+	Check(ctx context.Context, rawURL string) domain.Result
+}
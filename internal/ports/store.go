@@ -5,8 +5,25 @@ import "github.com/rojanmagar2001/godeadlink/internal/domain"
 // Store is in-memory for now. Later we can swap for sqlite/bolt.
 type Store interface {
 	MarkVisitedPage(url string) bool // returns true if it was newly marked
+	// VisitKey is the key MarkVisitedPage dedups url by.
+	VisitKey(url string) string
 	VisitedCount() int
 
 	RecordDiscoveredLink(linkURL domain.LinkMeta, sourcePage string)
 	AllDiscovered() []*domain.LinkMeta
+	// Discovered looks up a discovered link by URL, normalized as when it
+	// was recorded.
+	Discovered(linkURL string) (*domain.LinkMeta, bool)
+
+	// RecordPageResult keeps the result of fetching a crawled page, apart
+	// from its links; PageResult looks it up by the page's link URL.
+	RecordPageResult(pageURL string, res domain.Result)
+	PageResult(pageURL string) (domain.Result, bool)
+	PageResults() []domain.Result
+
+	// RecordExtractError keeps why the links of a page (crawled, or
+	// captured in a HAR or WARC file) could not be extracted;
+	// ExtractErrors lists them, sorted by page.
+	RecordExtractError(pageURL string, err error)
+	ExtractErrors() []domain.PageError
 }
@@ -0,0 +1,123 @@
+// Package robots reads the Allow and Disallow rules of a robots.txt file
+// (RFC 9309) for one user agent.
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Rules are the rules of the group that applies to one user agent. The
+// zero value allows everything.
+type Rules struct {
+	rules []rule
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// Parse reads robots.txt from r and keeps the rules for agent, a User-Agent
+// header such as "deadlink/0.1": those of every group naming its product
+// token ("deadlink", case-insensitively), or else of the "*" groups.
+func Parse(r io.Reader, agent string) (*Rules, error) {
+	token, _, _ := strings.Cut(agent, "/")
+	token = strings.ToLower(strings.TrimSpace(token))
+
+	var mine, star []rule
+	var agents []string
+	named := false   // some group names token, even with no rules
+	inRules := false // a rule ends the group's user-agent lines
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				agents, inRules = nil, false
+			}
+			a := strings.ToLower(value)
+			agents = append(agents, a)
+			named = named || a == token
+		case "allow", "disallow":
+			inRules = true
+			// An empty Disallow allows everything, like no rule at all.
+			if value == "" {
+				continue
+			}
+			ru := rule{allow: key == "allow", pattern: value}
+			for _, a := range agents {
+				switch a {
+				case token:
+					mine = append(mine, ru)
+				case "*":
+					star = append(star, ru)
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if !named {
+		mine = star
+	}
+	return &Rules{rules: mine}, nil
+}
+
+// DisallowAll returns rules that disallow every path, as a robots.txt
+// that is unreachable (a 5xx answer) must be taken to.
+func DisallowAll() *Rules {
+	return &Rules{rules: []rule{{pattern: "/"}}}
+}
+
+// Allowed reports whether path (with its query, if any) may be crawled:
+// the longest matching rule decides, and Allow wins a tie.
+func (r *Rules) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	best, allowed := -1, true
+	for _, ru := range r.rules {
+		if !match(ru.pattern, path) {
+			continue
+		}
+		if n := len(ru.pattern); n > best || n == best && ru.allow {
+			best, allowed = n, ru.allow
+		}
+	}
+	return allowed
+}
+
+// match reports whether pattern matches the start of path; "*" matches
+// any run of characters and a final "$" anchors the end.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	rest := path[len(parts[0]):]
+	for i, p := range parts[1:] {
+		last := i == len(parts)-2
+		if last && anchored {
+			return strings.HasSuffix(rest, p)
+		}
+		j := strings.Index(rest, p)
+		if j < 0 {
+			return false
+		}
+		rest = rest[j+len(p):]
+	}
+	return !anchored || rest == ""
+}
@@ -0,0 +1,61 @@
+package robots
+
+import (
+	"strings"
+	"testing"
+)
+
+const sample = `# example
+User-agent: *
+Disallow: /private/
+Disallow: /*.pdf$
+Allow: /private/public/
+
+User-agent: deadlink
+User-agent: other
+Disallow: /drafts
+Allow: /drafts/ok
+Disallow: /search?*q=
+
+User-agent: quiet
+Disallow:
+`
+
+func TestRules_Allowed(t *testing.T) {
+	cases := []struct {
+		agent string
+		path  string
+		want  bool
+	}{
+		{"somebot/1.0", "/", true},
+		{"somebot/1.0", "/private/x", false},
+		{"somebot/1.0", "/private/public/x", true},
+		{"somebot/1.0", "/docs/a.pdf", false},
+		{"somebot/1.0", "/docs/a.pdf?v=1", true},
+		{"somebot/1.0", "/drafts", true},
+
+		// A named group replaces the * group entirely.
+		{"DeadLink/0.1", "/private/x", true},
+		{"deadlink/0.1", "/drafts/2024", false},
+		{"deadlink/0.1", "/drafts/ok/page", true},
+		{"deadlink/0.1", "/search?lang=en&q=x", false},
+		{"deadlink/0.1", "/search", true},
+		{"other", "/drafts", false},
+
+		// An empty Disallow allows everything.
+		{"quiet/1", "/private/x", true},
+	}
+	for _, tc := range cases {
+		rules, err := Parse(strings.NewReader(sample), tc.agent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rules.Allowed(tc.path); got != tc.want {
+			t.Errorf("%s %s: allowed=%v, want %v", tc.agent, tc.path, got, tc.want)
+		}
+	}
+
+	if !(&Rules{}).Allowed("/anything") {
+		t.Fatal("the zero Rules should allow everything")
+	}
+}
@@ -0,0 +1,69 @@
+// Package urlnorm holds URL normalizations that make equivalent spellings
+// of a URL compare equal.
+package urlnorm
+
+import (
+	"net/url"
+	"strings"
+)
+
+const upperhex = "0123456789ABCDEF"
+
+// NormalizeEscapes applies RFC 3986 percent-encoding normalization
+// (section 6.2.2) to u's path and query: escaped unreserved characters
+// (letters, digits, "-", ".", "_", "~") are decoded, and the remaining
+// escapes get uppercase hex digits. Escaped reserved characters stay
+// escaped, as decoding them can change the meaning: /a%2Fb is not /a/b.
+func NormalizeEscapes(u *url.URL) {
+	if p := escapes(u.EscapedPath()); p != u.EscapedPath() {
+		if path, err := url.PathUnescape(p); err == nil {
+			u.Path, u.RawPath = path, p
+		}
+	}
+	u.RawQuery = escapes(u.RawQuery)
+}
+
+// escapes normalizes the percent-encoding of s, an escaped URL component.
+// Malformed escapes are left as they are.
+func escapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if unreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&15])
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func unreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
@@ -0,0 +1,44 @@
+package urlnorm
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestEscapes(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"no escapes", "/a/b", "/a/b"},
+		{"unreserved letters and digits decoded", "/%41%62%30", "/Ab0"},
+		{"unreserved marks decoded", "/%2D%2e%5F%7e", "/-._~"},
+		{"reserved kept, hex uppercased", "/a%2fb%3a%3F", "/a%2Fb%3A%3F"},
+		{"other bytes kept, hex uppercased", "/caf%c3%a9%20x", "/caf%C3%A9%20x"},
+		{"escaped percent kept", "/100%25", "/100%25"},
+		{"malformed escapes left alone", "/%zz/%4/%", "/%zz/%4/%"},
+	} {
+		if got := escapes(tc.in); got != tc.want {
+			t.Errorf("%s: escapes(%q) = %q, want %q", tc.name, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeEscapes(t *testing.T) {
+	for _, tc := range []struct {
+		in, want string
+	}{
+		{"https://example.com/%7Euser/a%2fb?q=%7e%2f#f%7E", "https://example.com/~user/a%2Fb?q=~%2F#f%7E"},
+		{"https://example.com/a%20b", "https://example.com/a%20b"},
+		{"https://example.com/a b", "https://example.com/a%20b"},
+		{"https://example.com/path%2Ffile", "https://example.com/path%2Ffile"},
+	} {
+		u, err := url.Parse(tc.in)
+		if err != nil {
+			t.Fatalf("parse %q: %v", tc.in, err)
+		}
+		NormalizeEscapes(u)
+		if got := u.String(); got != tc.want {
+			t.Errorf("NormalizeEscapes(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package usecase
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+const (
+	// adaptWindow is how many results each concurrency decision looks at.
+	adaptWindow = 10
+	// adaptThreshold is the share of errors and throttles in a window above
+	// which concurrency is halved.
+	adaptThreshold = 0.2
+)
+
+// governor caps how many workers check at once, between min and max. It
+// watches the results: a window with too many errors, 429s or 503s halves
+// the cap, a window without any raises it by one. A nil governor lets every
+// worker through.
+type governor struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	min, max int
+	limit    int
+	active   int
+
+	seen, bad int
+}
+
+func newGovernor(min, max int) *governor {
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		min = max
+	}
+	g := &governor{min: min, max: max, limit: max}
+	g.cond = sync.NewCond(&g.mu)
+	return g
+}
+
+// acquire waits for a free slot under the current cap.
+func (g *governor) acquire() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	for g.active >= g.limit {
+		g.cond.Wait()
+	}
+	g.active++
+	g.mu.Unlock()
+}
+
+func (g *governor) release() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.active--
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// observe counts r toward the current window and adjusts the cap when the
+// window is full. Cached results say nothing about the server and are
+// ignored.
+func (g *governor) observe(r domain.Result) {
+	if g == nil || r.FromCache {
+		return
+	}
+	g.mu.Lock()
+	g.seen++
+	if r.Err != nil || r.StatusCode == http.StatusTooManyRequests || r.StatusCode == http.StatusServiceUnavailable {
+		g.bad++
+	}
+	if g.seen >= adaptWindow {
+		switch {
+		case float64(g.bad)/float64(g.seen) > adaptThreshold:
+			g.limit = max(g.min, g.limit/2)
+		case g.bad == 0:
+			g.limit = min(g.max, g.limit+1)
+		}
+		g.seen, g.bad = 0, 0
+	}
+	g.mu.Unlock()
+	g.cond.Broadcast()
+}
+
+// Limit returns the current cap.
+func (g *governor) Limit() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.limit
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+func TestGovernor(t *testing.T) {
+	g := newGovernor(2, 8)
+	window := func(r domain.Result, n int) {
+		for range n {
+			g.observe(r)
+		}
+	}
+	unavailable := domain.Result{StatusCode: http.StatusServiceUnavailable}
+	ok := domain.Result{StatusCode: http.StatusOK}
+
+	for _, step := range []struct {
+		name string
+		feed func()
+		want int
+	}{
+		{"503s halve", func() { window(unavailable, adaptWindow) }, 4},
+		{"errors halve", func() { window(domain.Result{Err: errors.New("reset")}, adaptWindow) }, 2},
+		{"never below min", func() { window(unavailable, adaptWindow) }, 2},
+		{"healthy adds one", func() { window(ok, adaptWindow) }, 3},
+		{"a few failures hold", func() { window(ok, adaptWindow-2); window(unavailable, 2) }, 3},
+		{"cached results ignored", func() { window(domain.Result{StatusCode: 503, FromCache: true}, adaptWindow) }, 3},
+		{"never above max", func() { window(ok, 10*adaptWindow) }, 8},
+	} {
+		step.feed()
+		if got := g.Limit(); got != step.want {
+			t.Fatalf("%s: limit %d, want %d", step.name, got, step.want)
+		}
+	}
+}
+
+func TestOrchestrator_AdaptiveConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	var inFlight []int // in-flight requests as each request arrived
+	active := 0
+	mux := http.NewServeMux()
+	var links strings.Builder
+	for i := range 60 {
+		fmt.Fprintf(&links, `<a href="/busy/%d">%d</a>`, i, i)
+	}
+	mux.HandleFunc("/{$}", htmlHandler(links.String()))
+	mux.HandleFunc("/busy/", func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		active++
+		inFlight = append(inFlight, active)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{Concurrency: 8, AdaptiveConcurrency: true})
+	orch.crawler.maxDepth = 0 // only checks reach /busy/
+	if _, err := orch.Run(context.Background(), srv.URL+"/", io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// Three windows of 503s take the limit from 8 to 1; by the last
+	// requests, checks run one at a time.
+	mu.Lock()
+	defer mu.Unlock()
+	if len(inFlight) != 60 {
+		t.Fatalf("got %d requests, want 60", len(inFlight))
+	}
+	if peak := slices.Max(inFlight[:10]); peak < 2 {
+		t.Fatalf("first requests ran one at a time (%v); want concurrency to start high", inFlight[:10])
+	}
+	if peak := slices.Max(inFlight[45:]); peak != 1 {
+		t.Fatalf("last requests peaked at %d in flight (%v), want 1", peak, inFlight[45:])
+	}
+}
+
+func TestDefaultConcurrency(t *testing.T) {
+	tests := []struct {
+		cpus, hosts, want int
+	}{
+		{cpus: 8, hosts: 0, want: 4},
+		{cpus: 8, hosts: 1, want: 4},
+		{cpus: 8, hosts: 5, want: 20},
+		{cpus: 8, hosts: 100, want: 64},
+		{cpus: 1, hosts: 100, want: 20},
+	}
+	for _, tt := range tests {
+		if got := defaultConcurrency(tt.cpus, tt.hosts); got != tt.want {
+			t.Errorf("defaultConcurrency(%d cpus, %d hosts) = %d, want %d", tt.cpus, tt.hosts, got, tt.want)
+		}
+	}
+
+	links := func(urls ...string) []*domain.LinkMeta {
+		out := make([]*domain.LinkMeta, len(urls))
+		for i, u := range urls {
+			out[i] = &domain.LinkMeta{URL: u}
+		}
+		return out
+	}
+	o := newTestOrchestrator(Config{})
+	single := o.checkConcurrency(links("https://a.test/1", "https://A.test/2", "https://a.test/3"))
+	many := o.checkConcurrency(links("https://a.test/", "https://b.test/", "https://c.test/", "https://d.test/", "https://e.test/"))
+	if single != workersPerHost || many <= single {
+		t.Fatalf("single host: %d workers, five hosts: %d", single, many)
+	}
+	if n := newTestOrchestrator(Config{Concurrency: 7}).checkConcurrency(links("https://a.test/")); n != 7 {
+		t.Fatalf("configured concurrency: got %d, want 7", n)
+	}
+}
@@ -0,0 +1,60 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// DiscoveredBreakdown splits Report.Discovered into parts that add up to
+// it.
+type DiscoveredBreakdown struct {
+	// Pages and Assets are the links to check, by kind, that some page
+	// links to.
+	Pages  int
+	Assets int
+	// SelfOnly are links to check found only on themselves: crawled pages,
+	// like the start page, that no other crawled page links to.
+	SelfOnly int
+	// External are external links left unchecked; Skipped are those left
+	// out for any other reason (see Report.SkippedCounts).
+	External int
+	Skipped  int
+}
+
+func breakdownDiscovered(toCheck []*domain.LinkMeta, skippedCounts map[domain.SkipReason]int) *DiscoveredBreakdown {
+	b := &DiscoveredBreakdown{External: skippedCounts[domain.SkipExternal]}
+	for reason, n := range skippedCounts {
+		if reason != domain.SkipExternal {
+			b.Skipped += n
+		}
+	}
+	for _, m := range toCheck {
+		switch {
+		case foundOnlyOnItself(m):
+			b.SelfOnly++
+		case m.Kind == domain.LinkKindAsset:
+			b.Assets++
+		default:
+			b.Pages++
+		}
+	}
+	return b
+}
+
+func foundOnlyOnItself(m *domain.LinkMeta) bool {
+	if len(m.Sources) == 0 {
+		return false
+	}
+	for src := range m.Sources {
+		if src != m.URL {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *DiscoveredBreakdown) String() string {
+	return fmt.Sprintf("pages %d, assets %d, self-only %d, external %d, skipped %d",
+		b.Pages, b.Assets, b.SelfOnly, b.External, b.Skipped)
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// BrokenSource is a page that links to dead links.
+type BrokenSource struct {
+	Page string
+	Dead int // distinct dead links on the page
+}
+
+// brokenSources counts the dead links each source page links to.
+type brokenSources map[string]int
+
+// add counts m, a dead link, against each page it was found on. A crawled
+// page listing itself is not a source of its own failure.
+func (b brokenSources) add(m *domain.LinkMeta) {
+	for src := range m.Sources {
+		if src != m.URL {
+			b[src]++
+		}
+	}
+}
+
+// top returns the n pages with the most dead links, then by URL.
+func (b brokenSources) top(n int) []BrokenSource {
+	out := make([]BrokenSource, 0, len(b))
+	for page, dead := range b {
+		out = append(out, BrokenSource{Page: page, Dead: dead})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Dead != out[j].Dead {
+			return out[i].Dead > out[j].Dead
+		}
+		return out[i].Page < out[j].Page
+	})
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out
+}
+
+func writeBrokenSources(w io.Writer, sources []BrokenSource) {
+	fmt.Fprintln(w, "\nTop broken-link sources (fix these pages first):")
+	for _, s := range sources {
+		noun := "dead links"
+		if s.Dead == 1 {
+			noun = "dead link"
+		}
+		fmt.Fprintf(w, "  %4d %-10s %s\n", s.Dead, noun, s.Page)
+	}
+}
@@ -2,6 +2,11 @@ package usecase
 
 import (
 	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rojanmagar2001/godeadlink/internal/check"
@@ -13,17 +18,131 @@ type LinkCheckerService struct {
 	chk     *check.Checker
 	limiter ports.Limiter
 	timeout time.Duration
+	schemes map[string]ports.SchemeChecker
+	cache   ports.ResultCache
+	ok      []int
 }
 
-func NewLinkChecker(timeout time.Duration, headFirst bool, limiter ports.Limiter) *LinkCheckerService {
+// BodyRule makes links matching URL, a pattern with '*' wildcards, OK only
+// when they answer 2xx with a body matching Body; otherwise they are dead
+// with check.ErrUnhealthyBody.
+type BodyRule struct {
+	URL  string
+	Body *regexp.Regexp
+}
+
+type CheckerConfig struct {
+	Timeout   time.Duration
+	HeadFirst bool
+
+	Retries      int
+	RetryBackoff time.Duration
+	// RetryStatuses are the statuses retried; nil means
+	// check.DefaultRetryStatuses.
+	RetryStatuses []int
+
+	// ConfirmEmpty makes HEAD checks fall back to GET when the body size is
+	// unknown (needed to spot empty resources).
+	ConfirmEmpty bool
+
+	// ErrorTitles makes dead HEAD checks fall back to GET so the error
+	// page's title is reported (GET checks always report it).
+	ErrorTitles bool
+
+	// CaptureHeaders names response headers to keep in Result.Headers.
+	CaptureHeaders []string
+
+	// HealthyBody asserts on the content of links: the first rule whose
+	// URL pattern matches a link applies.
+	HealthyBody []BodyRule
+
+	// SchemeCheckers check links whose (lowercase) scheme is not http or
+	// https; links with other schemes go to the HTTP checker.
+	SchemeCheckers map[string]ports.SchemeChecker
+
+	// Transport, when set, replaces the default HTTP transport.
+	Transport http.RoundTripper
+	// UserAgent, when set, replaces check.DefaultUserAgent.
+	UserAgent string
+	// Jar, when set, keeps the cookies links set and sends them with later
+	// checks; share it with the crawler's client to reuse crawl cookies.
+	Jar http.CookieJar
+
+	// Cache, when set, serves results checked before (marked FromCache)
+	// and stores new ones.
+	Cache ports.ResultCache
+
+	// OKStatusCodes are statuses that are not dead even from 400 up:
+	// results with one are marked Accepted.
+	OKStatusCodes []int
+}
+
+func NewLinkChecker(cfg CheckerConfig, limiter ports.Limiter) *LinkCheckerService {
+	chk := check.NewChecker(cfg.Timeout, cfg.HeadFirst)
+	chk.Retries = cfg.Retries
+	chk.RetryBackoff = cfg.RetryBackoff
+	chk.RetryStatuses = cfg.RetryStatuses
+	chk.ConfirmEmpty = cfg.ConfirmEmpty
+	chk.ErrorTitles = cfg.ErrorTitles
+	chk.CaptureHeaders = cfg.CaptureHeaders
+	if len(cfg.HealthyBody) > 0 {
+		rules := cfg.HealthyBody
+		chk.HealthyBody = func(link string) *regexp.Regexp {
+			for _, r := range rules {
+				if matchGlob(r.URL, link) {
+					return r.Body
+				}
+			}
+			return nil
+		}
+	}
+	if cfg.Transport != nil {
+		chk.Client.Transport = cfg.Transport
+	}
+	if cfg.UserAgent != "" {
+		chk.UserAgent = cfg.UserAgent
+	}
+	chk.Client.Jar = cfg.Jar
+
+	// The per-link deadline has to cover every attempt and the waits between them.
+	budget := cfg.Timeout * time.Duration(cfg.Retries+1)
+	for i := 1; i <= cfg.Retries; i++ {
+		budget += cfg.RetryBackoff * time.Duration(i)
+	}
+
 	return &LinkCheckerService{
-		chk:     check.NewChecker(timeout, headFirst),
+		chk:     chk,
 		limiter: limiter,
-		timeout: timeout,
+		timeout: budget,
+		schemes: cfg.SchemeCheckers,
+		cache:   cfg.Cache,
+		ok:      cfg.OKStatusCodes,
+	}
+}
+
+// Check checks url. A non-empty referer is sent as the Referer header.
+func (s *LinkCheckerService) Check(ctx context.Context, url, referer string) domain.Result {
+	if s.cache != nil {
+		if res, ok := s.cache.Get(url); ok {
+			res.FromCache = true
+			return s.accept(res)
+		}
+	}
+	res := s.check(ctx, url, referer)
+	if s.cache != nil {
+		s.cache.Put(url, res)
 	}
+	return s.accept(res)
 }
 
-func (s *LinkCheckerService) Check(ctx context.Context, url string) domain.Result {
+// accept marks res Accepted when its status is one of OKStatusCodes. It
+// runs on cached results too, which may come from a run accepting others.
+func (s *LinkCheckerService) accept(res domain.Result) domain.Result {
+	res.Accepted = res.Err == nil && slices.Contains(s.ok, res.StatusCode)
+	return res
+}
+
+func (s *LinkCheckerService) check(ctx context.Context, url, referer string) domain.Result {
 	// Limiting happens before network call
 	_ = s.limiter.Take(ctx, url)
 
@@ -31,11 +150,76 @@ func (s *LinkCheckerService) Check(ctx context.Context, url string) domain.Resul
 	linkCtx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	r := s.chk.Check(linkCtx, url)
-	return domain.Result{
-		URL:        r.URL,
-		StatusCode: r.StatusCode,
-		Err:        r.Err,
-		Elapsed:    r.Elapsed,
+	if sc := s.schemeChecker(url); sc != nil {
+		res := sc.Check(linkCtx, url)
+		res.CheckedAt = time.Now()
+		return res
+	}
+
+	r := s.chk.CheckFrom(linkCtx, url, referer)
+	res := domain.Result{
+		URL:          r.URL,
+		StatusCode:   r.StatusCode,
+		Err:          r.Err,
+		Elapsed:      r.Elapsed,
+		FinalURL:     r.FinalURL,
+		Empty:        r.Empty,
+		Method:       r.Method,
+		LastModified: r.LastModified,
+		Headers:      r.Headers,
+		Title:        r.Title,
+		CheckedAt:    time.Now(),
+	}
+	for _, h := range r.RedirectChain {
+		res.RedirectChain = append(res.RedirectChain, domain.Hop{URL: h.URL, StatusCode: h.StatusCode})
+	}
+	for _, a := range r.Attempts {
+		res.Attempts = append(res.Attempts, domain.Attempt{StatusCode: a.StatusCode, Err: a.Err})
+	}
+	return res
+}
+
+// VerifyIntegrity checks res's resource against its Subresource Integrity
+// metadata. A mismatch or failed download becomes res's error, which makes
+// the link dead.
+func (s *LinkCheckerService) VerifyIntegrity(ctx context.Context, res domain.Result, integrity, referer string) domain.Result {
+	_ = s.limiter.Take(ctx, res.URL)
+
+	linkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.chk.VerifyIntegrity(linkCtx, res.URL, referer, integrity); err != nil {
+		res.Err = err
+	}
+	return res
+}
+
+// Reuses reports whether res, the result of a crawler's page fetch, can
+// stand for checking the page: it is a direct answer (no error, no
+// redirect, not a 304) that the check would neither retry nor look into
+// further for headers or body rules.
+func (s *LinkCheckerService) Reuses(res domain.Result) bool {
+	if res.Err != nil || res.StatusCode == http.StatusNotModified || res.FinalURL != res.URL {
+		return false
+	}
+	if len(s.chk.CaptureHeaders) > 0 || s.chk.HealthyBody != nil && s.chk.HealthyBody(res.URL) != nil {
+		return false
+	}
+	retry := s.chk.RetryStatuses
+	if retry == nil {
+		retry = check.DefaultRetryStatuses
+	}
+	return s.chk.Retries == 0 || !slices.Contains(retry, res.StatusCode)
+}
+
+// schemeChecker returns the checker registered for rawURL's scheme, if any.
+func (s *LinkCheckerService) schemeChecker(rawURL string) ports.SchemeChecker {
+	if len(s.schemes) == 0 {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
 	}
+	return s.schemes[strings.ToLower(u.Scheme)]
 }
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"net/url"
+	"runtime"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// workersPerHost is how many concurrent checks the default gives each
+// host; the per-host rate limit paces them anyway, so more would only
+// wait.
+const workersPerHost = 4
+
+// defaultConcurrency is the number of concurrent checks when none is
+// configured: workersPerHost for each distinct host to check, so a run
+// over many hosts is not starved by the hosts' rate limits while a
+// single (possibly slow) host gets just a few, and at least
+// workersPerHost, at most max(20, 8 × cpus).
+func defaultConcurrency(cpus, hosts int) int {
+	return min(max(workersPerHost*hosts, workersPerHost), max(20, 8*cpus))
+}
+
+// checkConcurrency is the configured concurrency, or defaultConcurrency
+// for the hosts in toCheck.
+func (o *Orchestrator) checkConcurrency(toCheck []*domain.LinkMeta) int {
+	if o.concurrency > 0 {
+		return o.concurrency
+	}
+	hosts := map[string]bool{}
+	for _, m := range toCheck {
+		if u, err := url.Parse(m.URL); err == nil {
+			hosts[strings.ToLower(u.Host)] = true
+		}
+	}
+	return defaultConcurrency(runtime.NumCPU(), len(hosts))
+}
@@ -1,15 +1,28 @@
 package usecase
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/rojanmagar2001/godeadlink/internal/check"
 	"github.com/rojanmagar2001/godeadlink/internal/domain"
 	"github.com/rojanmagar2001/godeadlink/internal/ports"
+	"github.com/rojanmagar2001/godeadlink/internal/robots"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/net/publicsuffix"
+	"golang.org/x/text/transform"
 )
 
 type Crawler struct {
@@ -23,8 +36,111 @@ type Crawler struct {
 	maxDepth    int
 	maxPages    int
 	checkAssets bool
+	// ignoreMetaRobots crawls the links of pages marked nofollow too.
+	ignoreMetaRobots bool
+
+	// StartRetries is how many extra attempts a seed gets when fetching it
+	// fails transiently (network error, 429, 5xx); a run whose seeds all
+	// fail finds nothing. StartRetryBackoff grows linearly per attempt.
+	StartRetries      int
+	StartRetryBackoff time.Duration
+
+	// ReportDuplicates collects the links each crawled page repeats; see
+	// Duplicates.
+	ReportDuplicates bool
+	duplicates       []domain.DuplicateLink
+
+	// MaxLinksPerPage keeps only the first MaxLinksPerPage links of a page
+	// (0 = no limit); see Truncated.
+	MaxLinksPerPage int
+	truncated       []domain.Warning
+
+	// depthLimited holds the pages maxDepth kept from crawling; see
+	// DepthLimited.
+	depthLimited []string
+
+	// incomplete is set when a crawl stopped with pages left; see
+	// Incomplete.
+	incomplete bool
+
+	// Prior is the link graph of an earlier crawl, by page URL. Its pages
+	// are fetched conditionally, and one that answers 304 Not Modified
+	// keeps its prior links instead of being extracted again; see Graph.
+	Prior  map[string]domain.CrawledPage
+	graph  []domain.CrawledPage
+	reused int
+
+	// ExcludePages are exact page URLs marked visited before crawling, so
+	// they are never fetched even when linked (links to them are still
+	// checked); see Excluded.
+	ExcludePages []string
+	excluded     atomic.Int64 // read by progress reports mid-crawl
+
+	// MaxPagesPerHost caps the pages crawled from any one host (0 = no
+	// limit). Page links that would go over it are recorded as skipped with
+	// SkipHostBudget, so other hosts still get their share of maxPages.
+	// Seeds are always crawled but count toward their host's budget.
+	MaxPagesPerHost int
+
+	// CSS, when set, makes the crawler fetch the internal stylesheets
+	// linked from crawled pages (<link rel="stylesheet"> served as text/css,
+	// whatever its path) and record what they reference, so broken background images, fonts and
+	// imports get checked too. CSSDepth is how many levels of stylesheets
+	// to fetch: 1 (or less) the linked ones, 2 also those they @import,
+	// and so on.
+	CSS      ports.CSSExtractor
+	CSSDepth int
+
+	// SavePages, when set, gets every page, stylesheet and robots.txt
+	// fetched for crawling with its final URL, status, headers and, for
+	// HTML, CSS and robots.txt, raw body, so the crawl can be replayed with
+	// a client serving them. One that cannot be saved ends the crawl with
+	// an error.
+	SavePages ports.PageSaver
+
+	// RespectRobots makes the crawler read each host's /robots.txt and
+	// leave the pages it disallows for the user agent uncrawled; links to
+	// them are still checked. A disallowed seed ends the crawl with
+	// ErrStartDisallowed. As RFC 9309 has it, a robots.txt answering 5xx
+	// disallows everything, while one that cannot be fetched or answers
+	// anything else but 2xx allows everything.
+	RespectRobots bool
+	robots        map[string]*robots.Rules // by scheme://host
+	disallowed    map[string]bool
+
+	// NoSelfLinks stops recording each crawled page as a discovered link
+	// of its own, so "discovered" counts only links found on pages. The
+	// store still gets every page's fetch result (RecordPageResult).
+	NoSelfLinks bool
+
+	// HostScope selects which hosts are internal: HostScopeExact (the
+	// default) the seeds' hosts only, HostScopeETLD1 every host under a
+	// seed's registrable domain.
+	HostScope string
+
+	// ExternalDepth records external links only from pages at most this
+	// deep; deeper ones are recorded as skipped. Negative means no limit,
+	// which is what NewCrawler sets.
+	ExternalDepth int
+
+	// ScopeQuery keeps the crawl within the seeds' query strings: a page
+	// is crawled only if it carries every query parameter of some seed
+	// with the same value, so a seed with ?lang=en crawls just the
+	// lang=en pages. Links to other pages are still checked. A seed
+	// without a query puts no limit on the crawl.
+	ScopeQuery  bool
+	seedQueries []url.Values
 }
 
+// Host scopes.
+const (
+	HostScopeExact = "host"
+	// HostScopeETLD1 groups hosts by registrable domain (eTLD+1) per the
+	// public suffix list, so shop.example.co.uk and www.example.co.uk are
+	// both internal to example.co.uk, but evilexample.co.uk is not.
+	HostScopeETLD1 = "etld1"
+)
+
 type PageJob struct {
 	URL   string
 	Depth int
@@ -38,6 +154,7 @@ func NewCrawler(
 	timeout time.Duration,
 	maxDepth, maxPages int,
 	checkAssets bool,
+	ignoreMetaRobots bool,
 ) *Crawler {
 	return &Crawler{
 		client:      client,
@@ -48,19 +165,83 @@ func NewCrawler(
 		maxDepth:    maxDepth,
 		maxPages:    maxPages,
 		checkAssets: checkAssets,
+
+		ignoreMetaRobots: ignoreMetaRobots,
+		ExternalDepth:    -1,
 	}
 }
 
-func (c *Crawler) Crawl(ctx context.Context, startUrl string, store ports.Store) (startHost string, err error) {
-	start, err := url.Parse(startUrl)
-	if err != nil {
-		return "", fmt.Errorf("parse start url: %w", err)
+// Crawl crawls breadth-first from the given seeds and records every link it
+// finds in store. It returns the seeds' hosts (keyed per HostScope), which
+// define what counts as internal.
+//
+// Depth semantics: every seed is depth 0 and is always fetched, and the links
+// on a fetched page are always recorded (and so checked). A page linked from
+// depth d has depth d+1 and is only fetched while d+1 <= maxDepth:
+//
+//	max-depth 0: fetch the seeds; check the links on them
+//	max-depth 1: also fetch the pages the seeds link to; check their links
+//
+// All seeds are queued before any discovered page, so a seed that is also
+// linked from another seed still counts as depth 0.
+//
+// Links on a page whose <meta name="robots"> says nofollow are recorded
+// (and so checked) but not crawled, unless ignoreMetaRobots is set.
+//
+// Scope is by host alone: a seed's query string does not limit which of its
+// host's pages are crawled, unless ScopeQuery is set.
+func (c *Crawler) Crawl(ctx context.Context, seeds []string, store ports.Store) (startHosts map[string]bool, err error) {
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no start url")
+	}
+
+	if c.RespectRobots {
+		c.robots = map[string]*robots.Rules{}
+		c.disallowed = map[string]bool{}
+	}
+	startHosts = make(map[string]bool, len(seeds))
+	queue := make([]PageJob, 0, len(seeds))
+	budget := newHostBudget(c.MaxPagesPerHost)
+	c.seedQueries = nil
+	for _, seed := range seeds {
+		start, err := url.Parse(seed)
+		if err != nil {
+			return nil, fmt.Errorf("parse start url: %w", err)
+		}
+		if c.RespectRobots {
+			allowed, err := c.robotsAllow(ctx, seed)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				return nil, fmt.Errorf("%w: %s", ErrStartDisallowed, seed)
+			}
+		}
+		startHosts[c.scopeKey(start.Hostname())] = true
+		if c.ScopeQuery {
+			c.seedQueries = append(c.seedQueries, start.Query())
+		}
+		queue = append(queue, PageJob{URL: seed, Depth: 0})
+		budget.claim(store.VisitKey(seed), start.Hostname())
 	}
 
-	startHost = strings.ToLower(start.Hostname())
+	for _, page := range c.ExcludePages {
+		if store.MarkVisitedPage(page) {
+			c.excluded.Add(1)
+		}
+	}
 
-	queue := []PageJob{{URL: startUrl, Depth: 0}}
 	crawled := 0
+	var sheets []sheetJob
+	// fetched holds the pages crawled (or excluded); beyond the pages
+	// linked from the deepest ones, which max-depth kept from crawling.
+	// Both are keyed by the store's visit key, so variants of one page's
+	// URL are one page; beyond keeps the URL first linked.
+	fetched := map[string]bool{}
+	for _, page := range c.ExcludePages {
+		fetched[store.VisitKey(page)] = true
+	}
+	beyond := map[string]string{}
 
 	for len(queue) > 0 && crawled < c.maxPages {
 		job := queue[0]
@@ -69,71 +250,95 @@ func (c *Crawler) Crawl(ctx context.Context, startUrl string, store ports.Store)
 		if job.Depth > c.maxDepth {
 			continue
 		}
+		if c.RespectRobots && job.Depth > 0 {
+			allowed, err := c.robotsAllow(ctx, job.URL)
+			if err != nil {
+				return nil, err
+			}
+			if !allowed {
+				c.disallowed[job.URL] = true
+				fetched[store.VisitKey(job.URL)] = true
+				continue
+			}
+		}
 
 		if !store.MarkVisitedPage(job.URL) {
 			continue
 		}
 		crawled++
+		fetched[store.VisitKey(job.URL)] = true
 
-		_ = c.limiter.Take(ctx, job.URL)
-
-		pageCtx, cancel := context.WithTimeout(ctx, c.timeout)
-		req, err := http.NewRequestWithContext(pageCtx, http.MethodGet, job.URL, nil)
+		prior, hasPrior := c.Prior[job.URL]
+		resp, cancel, elapsed, err := c.fetchPage(ctx, job, prior)
 		if err != nil {
-			cancel()
-			store.RecordDiscoveredLink(domain.LinkMeta{
-				URL:            job.URL,
-				FirstSeenDepth: job.Depth,
-				Kind:           domain.LinkKindPage,
-			}, job.URL)
+			c.recordPage(store, job, domain.Result{URL: job.URL, Err: err, Method: http.MethodGet, CheckedAt: time.Now()})
 			continue
 		}
-		req.Header.Set("User-Agent", c.userAgent)
-
-		resp, err := c.client.Do(req)
-		if err != nil {
-			cancel()
-			store.RecordDiscoveredLink(domain.LinkMeta{
-				URL:            job.URL,
-				FirstSeenDepth: job.Depth,
-				Kind:           domain.LinkKindPage,
-			}, job.URL)
-			continue
+		if c.SavePages != nil && resp.StatusCode != http.StatusNotModified {
+			if err := c.savePage(job.URL, resp, isHTML(resp)); err != nil {
+				_ = resp.Body.Close()
+				cancel()
+				return nil, fmt.Errorf("save page %s: %w", job.URL, err)
+			}
 		}
 
-		ct := strings.ToLower(resp.Header.Get("Content-Type"))
-		if !strings.Contains(ct, "text/html") && !strings.Contains(ct, "application/xhtml") {
+		var page domain.Page
+		if hasPrior && resp.StatusCode == http.StatusNotModified {
 			_ = resp.Body.Close()
-
 			cancel()
-			store.RecordDiscoveredLink(domain.LinkMeta{
-				URL:            job.URL,
-				FirstSeenDepth: job.Depth,
-				Kind:           domain.LinkKindPage,
-			}, job.URL)
-			continue
-		}
+			page = prior.Page
+			c.graph = append(c.graph, prior)
+			c.reused++
+			c.recordPage(store, job, fetchResult(job, resp, elapsed, ""))
+		} else {
+			if !isHTML(resp) {
+				_ = resp.Body.Close()
 
-		found, exErr := c.extractor.Extract(job.URL, resp.Body)
-		_ = resp.Body.Close()
-		cancel()
-		if exErr != nil {
-			store.RecordDiscoveredLink(domain.LinkMeta{
-				URL:            job.URL,
-				FirstSeenDepth: job.Depth,
-				Kind:           domain.LinkKindPage,
-			}, job.URL)
-			continue
+				cancel()
+				c.recordPage(store, job, fetchResult(job, resp, elapsed, ""))
+				continue
+			}
 
+			// An error page is kept in part for its title, as the checker
+			// would.
+			body := utf8Body(resp.Body, resp.Header.Get("Content-Type"))
+			var head *headBuffer
+			if resp.StatusCode >= 400 {
+				head = &headBuffer{max: maxTitleScan}
+				body = io.TeeReader(body, head)
+			}
+			var exErr error
+			page, exErr = c.extractor.Extract(job.URL, body)
+			_ = resp.Body.Close()
+			cancel()
+			var title string
+			if head != nil {
+				title = check.PageTitle(head.buf)
+			}
+			c.recordPage(store, job, fetchResult(job, resp, elapsed, title))
+			if exErr != nil {
+				store.RecordExtractError(job.URL, exErr)
+				continue
+			}
+			c.graph = append(c.graph, domain.CrawledPage{
+				URL:          job.URL,
+				ETag:         resp.Header.Get("ETag"),
+				LastModified: resp.Header.Get("Last-Modified"),
+				Page:         page,
+			})
 		}
 
-		store.RecordDiscoveredLink(domain.LinkMeta{
-			URL:            job.URL,
-			FirstSeenDepth: job.Depth,
-			Kind:           domain.LinkKindPage,
-		}, job.URL)
+		if c.MaxLinksPerPage > 0 && len(page.Links) > c.MaxLinksPerPage {
+			c.truncated = append(c.truncated, domain.Warning{
+				URL:    job.URL,
+				Kind:   domain.WarnLinksTruncated,
+				Detail: fmt.Sprintf("%d links; only the first %d were recorded", len(page.Links), c.MaxLinksPerPage),
+			})
+			page.Links = page.Links[:c.MaxLinksPerPage]
+		}
 
-		for _, fl := range found {
+		follow := !page.NoFollow || c.ignoreMetaRobots
+		for _, fl := range page.Links {
 			if fl.SkipReason != "" || fl.URL == "" {
 				store.RecordDiscoveredLink(domain.LinkMeta{
 					URL:            fl.Raw,
@@ -147,32 +352,534 @@ func (c *Crawler) Crawl(ctx context.Context, startUrl string, store ports.Store)
 			if fl.Kind == domain.LinkKindAsset && !c.checkAssets {
 				continue
 			}
+			if c.ReportDuplicates && fl.Occurrences > 1 {
+				c.duplicates = append(c.duplicates, domain.DuplicateLink{Page: job.URL, URL: fl.URL, Count: fl.Occurrences})
+			}
+
+			u, err := url.Parse(fl.URL)
+			external := err == nil && u.Hostname() != "" && !startHosts[c.scopeKey(u.Hostname())]
+			if external && c.ExternalDepth >= 0 && job.Depth > c.ExternalDepth {
+				store.RecordDiscoveredLink(domain.LinkMeta{
+					URL:            fl.URL,
+					FirstSeenDepth: job.Depth,
+					Kind:           fl.Kind,
+					Skipped:        domain.SkipExternalDepth,
+				}, job.URL)
+				continue
+			}
+
+			// Only crawl page links (same host)
+			crawlable := fl.Kind == domain.LinkKindPage && follow && !external &&
+				err == nil && (u.Scheme == "http" || u.Scheme == "https") && c.inQueryScope(u)
+			crawl := crawlable && job.Depth < c.maxDepth
+			if crawlable && !crawl {
+				if k := store.VisitKey(fl.URL); beyond[k] == "" {
+					beyond[k] = fl.URL
+				}
+			}
+			if crawl && !budget.claim(store.VisitKey(fl.URL), u.Hostname()) {
+				store.RecordDiscoveredLink(domain.LinkMeta{
+					URL:            fl.URL,
+					FirstSeenDepth: job.Depth,
+					Kind:           fl.Kind,
+					Skipped:        domain.SkipHostBudget,
+				}, job.URL)
+				continue
+			}
 
 			store.RecordDiscoveredLink(domain.LinkMeta{
 				URL:            fl.URL,
 				FirstSeenDepth: job.Depth,
 				Kind:           fl.Kind,
+				Warning:        fl.Warning,
+				Integrity:      fl.Integrity,
 			}, job.URL)
 
-			// Only crawl page links (same host)
-			if fl.Kind != domain.LinkKindPage {
+			if crawl {
+				queue = append(queue, PageJob{URL: fl.URL, Depth: job.Depth + 1})
+			}
+			if c.CSS != nil && !external && err == nil && mayBeStylesheet(fl, u) {
+				sheets = append(sheets, sheetJob{PageJob: PageJob{URL: fl.URL, Depth: job.Depth}, level: 1})
+			}
+		}
+
+	}
+
+	for k, page := range beyond {
+		if !fetched[k] {
+			c.depthLimited = append(c.depthLimited, page)
+		}
+	}
+	if len(queue) > 0 || ctx.Err() != nil {
+		c.incomplete = true
+	}
+	if err := c.crawlStylesheets(ctx, sheets, store, startHosts); err != nil {
+		return nil, err
+	}
+	return startHosts, nil
+}
+
+// sheetJob is a stylesheet to fetch: Depth is that of the page it hangs
+// off, level counts the stylesheets on the way (1 = linked from the page).
+type sheetJob struct {
+	PageJob
+	level int
+}
+
+// crawlStylesheets fetches the queued stylesheets and records the links
+// in them, following @imports of internal stylesheets up to CSSDepth
+// levels. Like a page, a stylesheet that cannot be saved (see SavePages)
+// ends the crawl with an error.
+func (c *Crawler) crawlStylesheets(ctx context.Context, queue []sheetJob, store ports.Store, startHosts map[string]bool) error {
+	seen := map[string]bool{}
+	for len(queue) > 0 && ctx.Err() == nil {
+		job := queue[0]
+		queue = queue[1:]
+		if seen[job.URL] {
+			continue
+		}
+		seen[job.URL] = true
+
+		sheet, ok, err := c.fetchStylesheet(ctx, job, store)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for _, fl := range sheet.Links {
+			if fl.SkipReason != "" || fl.URL == "" {
+				store.RecordDiscoveredLink(domain.LinkMeta{
+					URL:            fl.Raw,
+					FirstSeenDepth: job.Depth,
+					Kind:           fl.Kind,
+					Skipped:        fl.SkipReason,
+				}, job.URL)
 				continue
 			}
+			store.RecordDiscoveredLink(domain.LinkMeta{
+				URL:            fl.URL,
+				FirstSeenDepth: job.Depth,
+				Kind:           fl.Kind,
+			}, job.URL)
+
 			u, err := url.Parse(fl.URL)
-			if err != nil {
-				continue
+			if err == nil && job.level < c.CSSDepth && mayBeStylesheet(fl, u) && startHosts[c.scopeKey(u.Hostname())] {
+				queue = append(queue, sheetJob{PageJob: PageJob{URL: fl.URL, Depth: job.Depth}, level: job.level + 1})
 			}
+		}
+	}
+	return nil
+}
+
+// fetchStylesheet fetches and extracts a stylesheet; ok is false when it
+// could not be fetched or is not served as text/css, or when its links
+// could not be extracted (recorded in store). Whether it is alive is left
+// to the check. err is only set when it could not be saved.
+func (c *Crawler) fetchStylesheet(ctx context.Context, job sheetJob, store ports.Store) (sheet domain.Page, ok bool, err error) {
+	// A stylesheet is never a start page, so it gets no start retries.
+	resp, cancel, _, err := c.fetchPage(ctx, PageJob{URL: job.URL, Depth: job.Depth + 1}, domain.CrawledPage{})
+	if err != nil {
+		return domain.Page{}, false, nil
+	}
+	defer cancel()
+	defer resp.Body.Close()
+	css := strings.Contains(strings.ToLower(resp.Header.Get("Content-Type")), "text/css")
+	if c.SavePages != nil {
+		if err := c.savePage(job.URL, resp, css); err != nil {
+			return domain.Page{}, false, fmt.Errorf("save stylesheet %s: %w", job.URL, err)
+		}
+	}
+	if resp.StatusCode >= 400 || !css {
+		return domain.Page{}, false, nil
+	}
+	sheet, err = c.CSS.ExtractCSS(job.URL, resp.Body)
+	if err != nil {
+		store.RecordExtractError(job.URL, err)
+		return domain.Page{}, false, nil
+	}
+	return sheet, true, nil
+}
+
+// mayBeStylesheet reports whether fl, parsed as u, is worth fetching as a
+// stylesheet: the extractor found it in <link rel="stylesheet"> or an
+// @import. fetchStylesheet then goes by the Content-Type it is served
+// with, since paths like /theme?v=3 or /styles carry no extension.
+func mayBeStylesheet(fl domain.FoundLink, u *url.URL) bool {
+	return fl.Stylesheet && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+// recordPage stores the result of fetching a crawled page and, unless
+// NoSelfLinks is set, records the page as a link of its own so it is
+// reported like any other.
+func (c *Crawler) recordPage(store ports.Store, job PageJob, res domain.Result) {
+	store.RecordPageResult(job.URL, res)
+	if c.NoSelfLinks {
+		return
+	}
+	store.RecordDiscoveredLink(domain.LinkMeta{
+		URL:            job.URL,
+		FirstSeenDepth: job.Depth,
+		Kind:           domain.LinkKindPage,
+	}, job.URL)
+}
+
+// fetchResult is the result of a page fetch that got an answer; title is
+// that of an error page.
+func fetchResult(job PageJob, resp *http.Response, elapsed time.Duration, title string) domain.Result {
+	final := finalURL(job.URL, resp)
+	lastModified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return domain.Result{
+		URL:          job.URL,
+		StatusCode:   resp.StatusCode,
+		Elapsed:      elapsed,
+		FinalURL:     final,
+		Method:       http.MethodGet,
+		LastModified: lastModified,
+		Title:        title,
+		CheckedAt:    time.Now(),
+	}
+}
+
+// maxTitleScan bounds how much of an error page is kept to find its title.
+const maxTitleScan = 64 << 10
+
+// headBuffer keeps the first max bytes written to it.
+type headBuffer struct {
+	buf []byte
+	max int
+}
+
+func (h *headBuffer) Write(p []byte) (int, error) {
+	if room := h.max - len(h.buf); room > 0 {
+		h.buf = append(h.buf, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// hostBudget hands out up to max page crawls per host; max 0 means no
+// limit. A page counts once queued, whether or not it is crawled in the end.
+type hostBudget struct {
+	max     int
+	claimed map[string]bool
+	perHost map[string]int
+}
+
+func newHostBudget(max int) *hostBudget {
+	return &hostBudget{max: max, claimed: map[string]bool{}, perHost: map[string]int{}}
+}
+
+// claim reserves a crawl of the page with store visit key key, on host,
+// reporting false if host has used up its budget. Claiming a page again,
+// under any URL with the same key, is free.
+func (b *hostBudget) claim(key, host string) bool {
+	if b.max <= 0 || b.claimed[key] {
+		return true
+	}
+	host = strings.ToLower(host)
+	if b.perHost[host] >= b.max {
+		return false
+	}
+	b.claimed[key] = true
+	b.perHost[host]++
+	return true
+}
+
+// scopeKey is the key host has in the start hosts Crawl returns: the
+// lowercase host, or its registrable domain with HostScopeETLD1. Hosts
+// without one (IP addresses, "localhost") stand for themselves.
+func (c *Crawler) scopeKey(host string) string {
+	host = strings.ToLower(host)
+	if c.HostScope != HostScopeETLD1 || net.ParseIP(host) != nil {
+		return host
+	}
+	if d, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return d
+	}
+	return host
+}
+
+// inQueryScope reports whether u carries all the query parameters of some
+// seed (see ScopeQuery).
+func (c *Crawler) inQueryScope(u *url.URL) bool {
+	if !c.ScopeQuery {
+		return true
+	}
+	q := u.Query()
+	for _, want := range c.seedQueries {
+		if hasParams(q, want) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasParams(q, want url.Values) bool {
+	for k := range want {
+		if !q.Has(k) || q.Get(k) != want.Get(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// Duplicates returns the repeated links found by the last crawls with
+// ReportDuplicates set, sorted by page and URL.
+func (c *Crawler) Duplicates() []domain.DuplicateLink {
+	out := append([]domain.DuplicateLink(nil), c.duplicates...)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Page != out[j].Page {
+			return out[i].Page < out[j].Page
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+// DepthLimited returns the internal pages the last crawls found linked
+// but did not crawl because they lay beyond maxDepth, sorted. Their links
+// are the coverage the depth limit gives up; the pages themselves are
+// still checked.
+func (c *Crawler) DepthLimited() []string {
+	out := append([]string(nil), c.depthLimited...)
+	sort.Strings(out)
+	return out
+}
+
+// Truncated returns a warning per page of the last crawls that had more
+// than MaxLinksPerPage links, in crawl order.
+func (c *Crawler) Truncated() []domain.Warning {
+	return append([]domain.Warning(nil), c.truncated...)
+}
+
+// Incomplete reports whether one of the last crawls stopped before its
+// queue ran out, at maxPages or because ctx was done, so Graph misses
+// pages a full crawl would have.
+func (c *Crawler) Incomplete() bool {
+	return c.incomplete
+}
+
+// utf8Body transcodes an HTML body to UTF-8, which the extractor assumes,
+// when it declares another charset: by a byte order mark, in contentType
+// (the full Content-Type header) or in a <meta> within its first 1024
+// bytes. Undeclared bodies are passed through as they are: a guess from
+// an ASCII head would mangle UTF-8 further down. Read errors still reach
+// the extractor.
+func utf8Body(body io.Reader, contentType string) io.Reader {
+	br := bufio.NewReaderSize(body, 1024)
+	preview, _ := br.Peek(1024)
+	enc, name, certain := charset.DetermineEncoding(preview, contentType)
+	if name == "utf-8" || !certain && !declaresCharset(preview) {
+		return br
+	}
+	return transform.NewReader(br, enc.NewDecoder())
+}
 
-			host := strings.ToLower(u.Hostname())
-			if host != "" && host != startHost {
+// declaresCharset reports whether head has a <meta charset> or a <meta
+// content> with a charset parameter, the declarations DetermineEncoding
+// reads without being certain of them.
+func declaresCharset(head []byte) bool {
+	z := html.NewTokenizer(bytes.NewReader(head))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "meta" {
 				continue
 			}
-			if job.Depth < c.maxDepth {
-				queue = append(queue, PageJob{URL: fl.URL, Depth: job.Depth + 1})
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "charset":
+					return true
+				case "content":
+					if strings.Contains(strings.ToLower(string(val)), "charset=") {
+						return true
+					}
+				}
 			}
 		}
+	}
+}
+
+// Graph returns the pages of the last crawls whose links were extracted or
+// reused, sorted by URL; it can be the Prior of a later crawl.
+func (c *Crawler) Graph() []domain.CrawledPage {
+	out := append([]domain.CrawledPage(nil), c.graph...)
+	sort.Slice(out, func(i, j int) bool { return out[i].URL < out[j].URL })
+	return out
+}
+
+// Excluded returns how many ExcludePages the last crawls marked visited,
+// which the store counts as visited but were never fetched.
+func (c *Crawler) Excluded() int {
+	return int(c.excluded.Load())
+}
+
+// Reused returns how many pages of the last crawls answered 304 Not
+// Modified and kept their prior links.
+func (c *Crawler) Reused() int {
+	return c.reused
+}
+
+// ErrStartDisallowed is returned by Crawl when RespectRobots is set and
+// robots.txt disallows a seed, which would leave nothing to crawl.
+var ErrStartDisallowed = errors.New("start URL is disallowed by robots.txt")
+
+// maxRobotsSize is how much of a robots.txt is read (RFC 9309 asks for at
+// least 500 KiB).
+const maxRobotsSize = 512 << 10
+
+// robotsAllow reports whether robots.txt lets the crawler fetch rawURL,
+// fetching the host's robots.txt the first time. err is only set when
+// the robots.txt could not be saved (see SavePages).
+func (c *Crawler) robotsAllow(ctx context.Context, rawURL string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return true, nil
+	}
+	origin := u.Scheme + "://" + u.Host
+	rules, ok := c.robots[origin]
+	if !ok {
+		if rules, err = c.fetchRobots(ctx, origin); err != nil {
+			return false, err
+		}
+		c.robots[origin] = rules
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return rules.Allowed(path), nil
+}
 
+func (c *Crawler) fetchRobots(ctx context.Context, origin string) (*robots.Rules, error) {
+	robotsURL := origin + "/robots.txt"
+	_ = c.limiter.Take(ctx, robotsURL)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return &robots.Rules{}, nil
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &robots.Rules{}, nil
+	}
+	defer resp.Body.Close()
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(resp.Body, maxRobotsSize), resp.Body}
+	if c.SavePages != nil {
+		if err := c.savePage(robotsURL, resp, true); err != nil {
+			return nil, fmt.Errorf("save %s: %w", robotsURL, err)
+		}
+	}
+	if resp.StatusCode >= 500 {
+		return robots.DisallowAll(), nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &robots.Rules{}, nil
 	}
+	rules, err := robots.Parse(resp.Body, c.userAgent)
+	if err != nil {
+		return &robots.Rules{}, nil
+	}
+	return rules, nil
+}
+
+// RobotsDisallowed returns the pages robots.txt kept from being crawled
+// (with RespectRobots), sorted.
+func (c *Crawler) RobotsDisallowed() []string {
+	return sortedKeys(c.disallowed)
+}
 
-	return startHost, nil
+// savePage hands a fetched page, stylesheet or robots.txt to SavePages.
+// With withBody its body is read in full first and put back in front of
+// resp.Body for the caller; a body that cannot be read in full cannot be
+// saved, which is an error too.
+func (c *Crawler) savePage(pageURL string, resp *http.Response, withBody bool) error {
+	var body []byte
+	if withBody {
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{bytes.NewReader(b), resp.Body}
+		body = b
+	}
+	return c.SavePages.SavePage(pageURL, finalURL(pageURL, resp), resp.StatusCode, resp.Header, body)
+}
+
+// isHTML reports whether resp is served as an HTML page.
+func isHTML(resp *http.Response) bool {
+	ct := strings.ToLower(resp.Header.Get("Content-Type"))
+	return strings.Contains(ct, "text/html") || strings.Contains(ct, "application/xhtml")
+}
+
+// finalURL is where the request for rawURL ended up after redirects.
+func finalURL(rawURL string, resp *http.Response) string {
+	if resp.Request != nil {
+		return resp.Request.URL.String()
+	}
+	return rawURL
+}
+
+// fetchPage GETs job's page, conditionally when prior has validators. The
+// caller closes the body and calls cancel. Seeds (depth 0) are retried on
+// transient failures per StartRetries.
+func (c *Crawler) fetchPage(ctx context.Context, job PageJob, prior domain.CrawledPage) (*http.Response, context.CancelFunc, time.Duration, error) {
+	retries := 0
+	if job.Depth == 0 {
+		retries = c.StartRetries
+	}
+
+	for i := 0; ; i++ {
+		_ = c.limiter.Take(ctx, job.URL)
+
+		pageCtx, cancel := context.WithTimeout(ctx, c.timeout)
+		req, err := http.NewRequestWithContext(pageCtx, http.MethodGet, job.URL, nil)
+		if err != nil {
+			cancel()
+			return nil, nil, 0, err
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+		if prior.ETag != "" {
+			req.Header.Set("If-None-Match", prior.ETag)
+		}
+		if prior.LastModified != "" {
+			req.Header.Set("If-Modified-Since", prior.LastModified)
+		}
+
+		start := time.Now()
+		resp, err := c.client.Do(req)
+		elapsed := time.Since(start)
+		transient := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if !transient || i >= retries || ctx.Err() != nil {
+			if err != nil {
+				cancel()
+				return nil, nil, elapsed, err
+			}
+			return resp, cancel, elapsed, nil
+		}
+
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, 0, ctx.Err()
+		case <-time.After(c.StartRetryBackoff * time.Duration(i+1)):
+		}
+	}
 }
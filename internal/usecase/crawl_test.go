@@ -0,0 +1,700 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/extractor"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient/mock"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/snapshot"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/store"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+// fetchLog records which paths were requested.
+type fetchLog struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+func (f *fetchLog) wrap(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.paths = append(f.paths, r.URL.Path)
+		f.mu.Unlock()
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (f *fetchLog) sorted() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := append([]string(nil), f.paths...)
+	sort.Strings(out)
+	return out
+}
+
+func TestCrawler_SeedDepthMatrix(t *testing.T) {
+	// /a -> /a1 -> /a2, /a -> /b (also a seed), /b -> /b1
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", htmlHandler(`<a href="/a1">a1</a><a href="/b">b</a>`))
+	mux.HandleFunc("/a1", htmlHandler(`<a href="/a2">a2</a>`))
+	mux.HandleFunc("/a2", htmlHandler(`leaf`))
+	mux.HandleFunc("/b", htmlHandler(`<a href="/b1">b1</a>`))
+	mux.HandleFunc("/b1", htmlHandler(`leaf`))
+
+	cases := []struct {
+		maxDepth    int
+		wantFetched []string
+		wantLinks   int
+	}{
+		// Seeds only; links on them (a1, b1) are still recorded.
+		{0, []string{"/a", "/b"}, 4},
+		// Seeds and their direct links.
+		{1, []string{"/a", "/a1", "/b", "/b1"}, 5},
+		{2, []string{"/a", "/a1", "/a2", "/b", "/b1"}, 5},
+	}
+
+	for _, tc := range cases {
+		log := &fetchLog{}
+		srv := httptest.NewServer(log.wrap(mux))
+
+		timeout := 2 * time.Second
+		c := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, tc.maxDepth, 50, true, false)
+		st := store.NewMemory()
+
+		hosts, err := c.Crawl(context.Background(), []string{srv.URL + "/a", srv.URL + "/b"}, st)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("max-depth %d: crawl: %v", tc.maxDepth, err)
+		}
+		if len(hosts) != 1 {
+			t.Fatalf("max-depth %d: expected one start host, got %v", tc.maxDepth, hosts)
+		}
+
+		got := log.sorted()
+		if len(got) != len(tc.wantFetched) {
+			t.Fatalf("max-depth %d: fetched %v, want %v", tc.maxDepth, got, tc.wantFetched)
+		}
+		for i := range got {
+			if got[i] != tc.wantFetched[i] {
+				t.Fatalf("max-depth %d: fetched %v, want %v", tc.maxDepth, got, tc.wantFetched)
+			}
+		}
+
+		if n := len(st.AllDiscovered()); n != tc.wantLinks {
+			t.Fatalf("max-depth %d: expected %d discovered links, got %d", tc.maxDepth, tc.wantLinks, n)
+		}
+	}
+}
+
+func TestCrawler_MetaRobotsNoFollow(t *testing.T) {
+	// /nofollow's links are recorded but not crawled; /normal's are crawled.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/nofollow", htmlHandler(`<meta name="robots" content="noindex, nofollow"><a href="/n1">n1</a>`))
+	mux.HandleFunc("/normal", htmlHandler(`<meta name="robots" content="noindex"><a href="/m1">m1</a>`))
+	mux.HandleFunc("/n1", htmlHandler(`leaf`))
+	mux.HandleFunc("/m1", htmlHandler(`leaf`))
+
+	for _, ignore := range []bool{false, true} {
+		log := &fetchLog{}
+		srv := httptest.NewServer(log.wrap(mux))
+
+		timeout := 2 * time.Second
+		c := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 1, 50, true, ignore)
+		st := store.NewMemory()
+
+		_, err := c.Crawl(context.Background(), []string{srv.URL + "/nofollow", srv.URL + "/normal"}, st)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("ignore=%v: crawl: %v", ignore, err)
+		}
+
+		want := "[/m1 /nofollow /normal]"
+		if ignore {
+			want = "[/m1 /n1 /nofollow /normal]"
+		}
+		if got := fmt.Sprint(log.sorted()); got != want {
+			t.Fatalf("ignore=%v: fetched %s, want %s", ignore, got, want)
+		}
+		if n := len(st.AllDiscovered()); n != 4 {
+			t.Fatalf("ignore=%v: expected 4 discovered links, got %d", ignore, n)
+		}
+	}
+}
+
+func TestCrawler_IgnoreQueryForCrawl(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/list?page=1">1</a><a href="/list?page=2">2</a><a href="/list?page=3">3</a>`))
+	mux.HandleFunc("/list", htmlHandler(`<a href="/list?page=1">1</a><a href="/list?page=2">2</a>`))
+
+	for _, ignore := range []bool{false, true} {
+		log := &fetchLog{}
+		srv := httptest.NewServer(log.wrap(mux))
+
+		timeout := 2 * time.Second
+		c := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+		st := store.NewMemory(store.WithVisitIgnoringQuery(ignore))
+
+		_, err := c.Crawl(context.Background(), []string{srv.URL + "/"}, st)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("ignore=%v: crawl: %v", ignore, err)
+		}
+
+		want := "[/ /list /list /list]"
+		if ignore {
+			want = "[/ /list]"
+		}
+		if got := fmt.Sprint(log.sorted()); got != want {
+			t.Fatalf("ignore=%v: fetched %s, want %s", ignore, got, want)
+		}
+		// Every variant is still recorded, and so checked.
+		if n := len(st.AllDiscovered()); n != 4 {
+			t.Fatalf("ignore=%v: expected 4 discovered links, got %d", ignore, n)
+		}
+	}
+}
+
+func TestCrawler_StartRetries(t *testing.T) {
+	var mu sync.Mutex
+	failures := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fail := failures > 0
+		if fail {
+			failures--
+		}
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		htmlHandler(`<a href="/next">next</a>`)(w, r)
+	})
+	mux.HandleFunc("/next", htmlHandler(`leaf`))
+
+	for _, retries := range []int{0, 1} {
+		failures = 1
+		log := &fetchLog{}
+		srv := httptest.NewServer(log.wrap(mux))
+
+		timeout := 2 * time.Second
+		c := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 1, 50, true, false)
+		c.StartRetries = retries
+		c.StartRetryBackoff = time.Millisecond
+		st := store.NewMemory()
+
+		_, err := c.Crawl(context.Background(), []string{srv.URL + "/"}, st)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("retries=%d: crawl: %v", retries, err)
+		}
+
+		want := "[/]"
+		if retries > 0 {
+			want = "[/ / /next]"
+		}
+		if got := fmt.Sprint(log.sorted()); got != want {
+			t.Fatalf("retries=%d: fetched %s, want %s", retries, got, want)
+		}
+	}
+}
+
+func TestCrawler_ExternalDepth(t *testing.T) {
+	// /d0 is linked again from depth 2; it stays checkable.
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="https://ext.test/d0">d0</a><a href="/p1">p1</a>`))
+	mux.HandleFunc("/p1", htmlHandler(`<a href="https://ext.test/d1">d1</a><a href="/p2">p2</a>`))
+	mux.HandleFunc("/p2", htmlHandler(`<a href="https://ext.test/d2">d2</a><a href="https://ext.test/d0">d0</a>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	c := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+	c.ExternalDepth = 1
+	st := store.NewMemory()
+	if _, err := c.Crawl(context.Background(), []string{srv.URL + "/"}, st); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	got := map[string]string{}
+	for _, m := range st.AllDiscovered() {
+		got[m.URL] = string(m.Skipped)
+	}
+	want := map[string]string{
+		"https://ext.test/d0": "",
+		"https://ext.test/d1": "",
+		"https://ext.test/d2": string(domain.SkipExternalDepth),
+	}
+	for u, reason := range want {
+		if r, ok := got[u]; !ok || r != reason {
+			t.Fatalf("%s: expected skip reason %q, got %q (recorded: %v)", u, reason, r, ok)
+		}
+	}
+}
+
+func TestCrawler_DepthAndScope(t *testing.T) {
+	// Runs against canned responses, no server.
+	client := mock.New().
+		HTML("https://site.test/", `<a href="/a">a</a><a href="https://other.test/x">x</a><img src="/logo.png">`).
+		HTML("https://site.test/a", `<a href="/b">b</a><a href="https://other.test/y">y</a>`).
+		HTML("https://site.test/b", `<a href="/c">c</a>`).
+		Set("https://other.test/x", mock.Response{Err: errors.New("must not be fetched")})
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+	st := store.NewMemory()
+	hosts, err := c.Crawl(context.Background(), []string{"https://site.test/"}, st)
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	if !reflect.DeepEqual(hosts, map[string]bool{"site.test": true}) {
+		t.Fatalf("start hosts: %v", hosts)
+	}
+	// max-depth 1: the seed and /a are fetched; /b is only recorded, and
+	// external links and assets are never fetched.
+	wantFetched := []string{"GET https://site.test/", "GET https://site.test/a"}
+	if got := client.Requests(); !reflect.DeepEqual(got, wantFetched) {
+		t.Fatalf("fetched %v, want %v", got, wantFetched)
+	}
+
+	got := map[string]int{}
+	for _, m := range st.AllDiscovered() {
+		got[m.URL] = m.FirstSeenDepth
+	}
+	want := map[string]int{
+		"https://site.test/":         0,
+		"https://site.test/a":        0,
+		"https://site.test/logo.png": 0,
+		"https://other.test/x":       0,
+		"https://site.test/b":        1,
+		"https://other.test/y":       1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("recorded %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_HostScopeETLD1(t *testing.T) {
+	site := func() *mock.Client {
+		return mock.New().
+			HTML("https://www.example.co.uk/", `<a href="https://shop.example.co.uk/">shop</a><a href="https://evilexample.co.uk/">evil</a><a href="https://example.co.uk.evil.test/">lookalike</a>`).
+			HTML("https://shop.example.co.uk/", `leaf`).
+			Set("https://evilexample.co.uk/", mock.Response{Err: errors.New("must not be fetched")}).
+			Set("https://example.co.uk.evil.test/", mock.Response{Err: errors.New("must not be fetched")})
+	}
+
+	for _, tc := range []struct {
+		scope       string
+		wantHosts   map[string]bool
+		wantFetched []string
+	}{
+		{HostScopeExact, map[string]bool{"www.example.co.uk": true}, []string{"GET https://www.example.co.uk/"}},
+		{HostScopeETLD1, map[string]bool{"example.co.uk": true}, []string{"GET https://www.example.co.uk/", "GET https://shop.example.co.uk/"}},
+	} {
+		client := site()
+		c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+		c.HostScope = tc.scope
+		hosts, err := c.Crawl(context.Background(), []string{"https://www.example.co.uk/"}, store.NewMemory())
+		if err != nil {
+			t.Fatalf("%s: crawl: %v", tc.scope, err)
+		}
+		if !reflect.DeepEqual(hosts, tc.wantHosts) {
+			t.Fatalf("%s: start hosts %v, want %v", tc.scope, hosts, tc.wantHosts)
+		}
+		if got := client.Requests(); !reflect.DeepEqual(got, tc.wantFetched) {
+			t.Fatalf("%s: fetched %v, want %v", tc.scope, got, tc.wantFetched)
+		}
+	}
+
+	c := &Crawler{HostScope: HostScopeETLD1}
+	for host, want := range map[string]string{
+		"WWW.Example.CO.UK": "example.co.uk",
+		"a.b.example.com":   "example.com",
+		"evilexample.co.uk": "evilexample.co.uk",
+		"user.github.io":    "user.github.io",
+		"127.0.0.1":         "127.0.0.1",
+		"localhost":         "localhost",
+	} {
+		if got := c.scopeKey(host); got != want {
+			t.Errorf("scopeKey(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
+
+func TestCrawler_PriorGraphReusedOn304(t *testing.T) {
+	// The home page changes its links but keeps its ETag, so a conditional
+	// GET gets 304 and the crawler must keep the links it saw before.
+	home := `<a href="/a">a</a><a href="mailto:x@example.com">mail</a>`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, home)
+	})
+	mux.HandleFunc("/a", htmlHandler(`leaf`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	crawl := func(prior map[string]domain.CrawledPage) (*Crawler, []string) {
+		t.Helper()
+		c := NewCrawler(httpclient.New(time.Second), extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+		c.Prior = prior
+		st := store.NewMemory()
+		if _, err := c.Crawl(context.Background(), []string{srv.URL + "/"}, st); err != nil {
+			t.Fatalf("crawl: %v", err)
+		}
+		var urls []string
+		for _, m := range st.AllDiscovered() {
+			urls = append(urls, m.URL)
+		}
+		return c, urls
+	}
+
+	first, want := crawl(nil)
+	if first.Reused() != 0 {
+		t.Fatalf("first crawl reused %d pages", first.Reused())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraph(&buf, first.Graph()); err != nil {
+		t.Fatalf("write graph: %v", err)
+	}
+	prior, err := ReadGraph(&buf)
+	if err != nil {
+		t.Fatalf("read graph: %v", err)
+	}
+
+	home = `<a href="/b">b</a>`
+	second, got := crawl(prior)
+	if second.Reused() != 1 {
+		t.Fatalf("second crawl reused %d pages, want 1", second.Reused())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("discovered %v, want the prior %v", got, want)
+	}
+	if !reflect.DeepEqual(second.Graph(), first.Graph()) {
+		t.Fatalf("graph changed:\n%+v\nwant\n%+v", second.Graph(), first.Graph())
+	}
+}
+
+func TestCrawler_TranscodesDeclaredCharset(t *testing.T) {
+	// "/café" in ISO-8859-1: é is the single byte 0xE9.
+	latin1 := "<a href=\"/caf\xe9\">caf\xe9</a>"
+	mux := http.NewServeMux()
+	mux.HandleFunc("/header", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		_, _ = fmt.Fprint(w, latin1)
+	})
+	mux.HandleFunc("/meta", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html><head><meta charset="iso-8859-1"></head><body>`+latin1+`</body></html>`)
+	})
+	// Nothing declared and an ASCII head: the UTF-8 link after the first
+	// 1024 bytes is left as it is, not taken for windows-1252.
+	mux.HandleFunc("/undeclared", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = fmt.Fprint(w, `<html><head><title>`+strings.Repeat("x", 1100)+`</title></head><body><a href="/café">café</a></body></html>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, page := range []string{"/header", "/meta", "/undeclared"} {
+		c := NewCrawler(httpclient.New(time.Second), extractor.New(), noLimit{}, "test-bot", time.Second, 0, 50, true, false)
+		st := store.NewMemory()
+		if _, err := c.Crawl(context.Background(), []string{srv.URL + page}, st); err != nil {
+			t.Fatalf("%s: crawl: %v", page, err)
+		}
+
+		var got []string
+		for _, m := range st.AllDiscovered() {
+			got = append(got, m.URL)
+		}
+		want := []string{srv.URL + "/caf%C3%A9", srv.URL + page}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: discovered %v, want %v", page, got, want)
+		}
+	}
+}
+
+func TestCrawler_MaxPagesPerHost(t *testing.T) {
+	client := mock.New()
+	for _, host := range []string{"https://a.example", "https://b.example"} {
+		client.HTML(host+"/", `<a href="/p1">1</a><a href="/p2">2</a><a href="/p3">3</a><img src="/logo.png">`)
+		for _, p := range []string{"/p1", "/p2", "/p3"} {
+			client.HTML(host+p, `<a href="/">home</a>`)
+		}
+	}
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+	c.MaxPagesPerHost = 2
+	st := store.NewMemory()
+	if _, err := c.Crawl(context.Background(), []string{"https://a.example/", "https://b.example/"}, st); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	// Each host gets its seed and one more page, whatever the other fetched.
+	want := []string{"GET https://a.example/", "GET https://b.example/", "GET https://a.example/p1", "GET https://b.example/p1"}
+	if got := client.Requests(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("fetched %v, want %v", got, want)
+	}
+
+	var skipped []string
+	for _, m := range st.AllDiscovered() {
+		if m.Skipped == domain.SkipHostBudget {
+			skipped = append(skipped, m.URL)
+		}
+	}
+	wantSkipped := []string{"https://a.example/p2", "https://a.example/p3", "https://b.example/p2", "https://b.example/p3"}
+	if !reflect.DeepEqual(skipped, wantSkipped) {
+		t.Fatalf("skipped %v, want %v", skipped, wantSkipped)
+	}
+
+	// Spellings of one page share its claim.
+	client = mock.New().
+		HTML("https://a.example/", `<a href="/p1">1</a><a href="https://A.EXAMPLE/p1">1 again</a><a href="/p2">2</a>`).
+		HTML("https://a.example/p1", `p1`).
+		HTML("https://a.example/p2", `p2`)
+	c = NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+	c.MaxPagesPerHost = 3
+	if _, err := c.Crawl(context.Background(), []string{"https://a.example/"}, store.NewMemory()); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+	want = []string{"GET https://a.example/", "GET https://a.example/p1", "GET https://a.example/p2"}
+	if got := client.Requests(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("fetched %v, want %v", got, want)
+	}
+}
+
+func TestCrawler_ExcludePages(t *testing.T) {
+	client := mock.New().
+		HTML("https://site.example/", `<a href="/ok">ok</a><a href="/admin/">admin</a>`).
+		HTML("https://site.example/ok", `<a href="/admin/">admin</a>`).
+		Set("https://site.example/admin/", mock.Response{Err: errors.New("must not be fetched")})
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 2, 50, true, false)
+	c.ExcludePages = []string{"https://site.example/admin/"}
+	st := store.NewMemory()
+
+	// Progress reports read Excluded while the crawl runs.
+	polled := make(chan struct{})
+	go func() {
+		defer close(polled)
+		for c.Excluded() == 0 {
+		}
+	}()
+	_, err := c.Crawl(context.Background(), []string{"https://site.example/"}, st)
+	<-polled
+	if err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	want := []string{"GET https://site.example/", "GET https://site.example/ok"}
+	if got := client.Requests(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("fetched %v, want %v", got, want)
+	}
+	if c.Excluded() != 1 || st.VisitedCount()-c.Excluded() != 2 {
+		t.Fatalf("excluded %d of %d visited, want 1 of 3", c.Excluded(), st.VisitedCount())
+	}
+
+	// The link itself is still recorded for checking.
+	var found bool
+	for _, m := range st.AllDiscovered() {
+		found = found || m.URL == "https://site.example/admin/" && m.Skipped == ""
+	}
+	if !found {
+		t.Fatal("link to the excluded page was not recorded")
+	}
+}
+
+func TestCrawler_DepthLimited(t *testing.T) {
+	client := mock.New().
+		HTML("https://site.example/", `<a href="/a">a</a><a href="/b">b</a>`).
+		HTML("https://site.example/a", `<a href="/a/1">1</a><a href="/a/2">2</a><a href="https://other.example/">other</a>`).
+		HTML("https://site.example/b", `<a href="/">home</a><a href="https://SITE.example/a">a</a><a href="/b/1">1</a><a href="https://SITE.example/b/1">1 again</a>`).
+		HTML("https://site.example/a/1", `<a href="/deep">deep</a>`)
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 1, 50, true, false)
+	st := store.NewMemory()
+	if _, err := c.Crawl(context.Background(), []string{"https://site.example/"}, st); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	// Links back to crawled pages and to other hosts are no coverage gap,
+	// however their URLs are spelled; a page is listed once.
+	want := []string{"https://site.example/a/1", "https://site.example/a/2", "https://site.example/b/1"}
+	if got := c.DepthLimited(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("depth limited: got %v, want %v", got, want)
+	}
+	for _, m := range st.AllDiscovered() {
+		if m.URL == "https://site.example/deep" {
+			t.Fatal("a link on an uncrawled page was discovered")
+		}
+	}
+}
+
+func TestCrawler_SaveAndReplayPages(t *testing.T) {
+	client := mock.New().
+		Set("https://site.example/robots.txt", mock.Response{ContentType: "text/plain", Body: "User-agent: *\nDisallow: /private\n"}).
+		HTML("https://site.example/", `<link rel="stylesheet" href="/site.css"><a href="/a">a</a><a href="/moved">moved</a><a href="/private">private</a><a href="/gone">gone</a><a href="/doc.pdf">pdf</a><img src="/logo.png">`).
+		Set("https://site.example/site.css", mock.Response{ContentType: "text/css", Body: `body { background: url(/img/bg.png) }`}).
+		Set("https://site.example/a", mock.Response{ContentType: "text/html; charset=iso-8859-1", Body: "<a href=\"/caf\xe9\">caf\xe9</a><a href=\"#top\">top</a>"}).
+		Set("https://site.example/moved", mock.Response{ContentType: "text/html", Body: `new`, FinalURL: "https://site.example/new/"}).
+		Set("https://site.example/doc.pdf", mock.Response{ContentType: "application/pdf", Body: "%PDF"})
+	dir := t.TempDir()
+
+	crawl := func(client ports.HTTPClient, save ports.PageSaver) (*store.Memory, *Crawler) {
+		t.Helper()
+		ext := extractor.New()
+		c := NewCrawler(client, ext, noLimit{}, "test-bot", time.Second, 2, 50, true, false)
+		c.SavePages = save
+		c.RespectRobots = true
+		c.CSS, c.CSSDepth = ext, 1
+		st := store.NewMemory()
+		if _, err := c.Crawl(context.Background(), []string{"https://site.example/"}, st); err != nil {
+			t.Fatalf("crawl: %v", err)
+		}
+		return st, c
+	}
+	live, liveCrawler := crawl(client, snapshot.NewSaver(dir))
+	replayed, replayCrawler := crawl(snapshot.NewReplay(dir), nil)
+
+	// The stylesheet's image and the robots.txt rules hold in the replay
+	// too, and redirected pages keep their final URL.
+	if got, want := replayed.AllDiscovered(), live.AllDiscovered(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed links differ:\ngot  %v\nwant %v", describeLinks(got), describeLinks(want))
+	}
+	if got, want := replayCrawler.RobotsDisallowed(), liveCrawler.RobotsDisallowed(); !reflect.DeepEqual(got, want) || len(got) != 1 {
+		t.Fatalf("replayed robots disallowed %v, want %v", got, want)
+	}
+	status := func(st *store.Memory) map[string]string {
+		out := map[string]string{}
+		for _, r := range st.PageResults() {
+			out[r.URL] = fmt.Sprintf("%d %s", r.StatusCode, r.FinalURL)
+		}
+		return out
+	}
+	if got, want := status(replayed), status(live); !reflect.DeepEqual(got, want) {
+		t.Fatalf("replayed page results %v, want %v", got, want)
+	}
+	if _, err := snapshot.NewReplay(dir).Do(httptest.NewRequest(http.MethodGet, "https://site.example/never", nil)); err == nil {
+		t.Fatal("a page that was never saved should be an error")
+	}
+}
+
+func TestCrawler_SavePagesBodyError(t *testing.T) {
+	client := mock.New().Set("https://site.example/", mock.Response{
+		ContentType: "text/html",
+		Body:        `<a href="/a">a</a>`,
+		BodyErr:     errors.New("connection reset"),
+	})
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 2, 50, true, false)
+	c.SavePages = snapshot.NewSaver(t.TempDir())
+	_, err := c.Crawl(context.Background(), []string{"https://site.example/"}, store.NewMemory())
+	if err == nil || !strings.Contains(err.Error(), "save page https://site.example/: read body: connection reset") {
+		t.Fatalf("expected the unsaved page to end the crawl, got %v", err)
+	}
+}
+
+func describeLinks(metas []*domain.LinkMeta) []string {
+	out := make([]string, len(metas))
+	for i, m := range metas {
+		out[i] = fmt.Sprintf("%s(%s,%d)", m.URL, m.Kind, len(m.Sources))
+	}
+	return out
+}
+
+func TestCrawler_RespectRobots(t *testing.T) {
+	client := mock.New().
+		Set("https://site.example/robots.txt", mock.Response{ContentType: "text/plain", Body: "User-agent: test-bot\nDisallow: /private\n\nUser-agent: *\nDisallow: /\n"}).
+		HTML("https://site.example/", `<a href="/private/a">private</a><a href="/pub">pub</a>`).
+		HTML("https://site.example/pub", `<a href="/private/a">private</a>`).
+		HTML("https://site.example/private/a", `<a href="/secret">secret</a>`)
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot/1.0", time.Second, 2, 50, true, false)
+	c.RespectRobots = true
+	st := store.NewMemory()
+	if _, err := c.Crawl(context.Background(), []string{"https://site.example/"}, st); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	want := []string{"GET https://site.example/robots.txt", "GET https://site.example/", "GET https://site.example/pub"}
+	if got := client.Requests(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("requests: got %v, want %v", got, want)
+	}
+	if got := c.RobotsDisallowed(); !reflect.DeepEqual(got, []string{"https://site.example/private/a"}) {
+		t.Fatalf("disallowed: got %v", got)
+	}
+	// The disallowed page is still a discovered link, so it gets checked.
+	var found bool
+	for _, m := range st.AllDiscovered() {
+		found = found || m.URL == "https://site.example/private/a"
+	}
+	if !found {
+		t.Fatal("a disallowed page should still be discovered")
+	}
+
+	_, err := c.Crawl(context.Background(), []string{"https://site.example/private/a"}, store.NewMemory())
+	if !errors.Is(err, ErrStartDisallowed) {
+		t.Fatalf("expected ErrStartDisallowed, got %v", err)
+	}
+
+	// An unreachable robots.txt (5xx) disallows everything; a missing one
+	// (4xx) nothing.
+	for status, wantErr := range map[int]bool{http.StatusServiceUnavailable: true, http.StatusNotFound: false} {
+		client := mock.New().
+			Set("https://site.example/robots.txt", mock.Response{Status: status}).
+			HTML("https://site.example/", `home`)
+		c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot/1.0", time.Second, 2, 50, true, false)
+		c.RespectRobots = true
+		_, err := c.Crawl(context.Background(), []string{"https://site.example/"}, store.NewMemory())
+		if errors.Is(err, ErrStartDisallowed) != wantErr {
+			t.Fatalf("robots.txt %d: got %v", status, err)
+		}
+	}
+}
+
+func TestCrawler_ScopeQuery(t *testing.T) {
+	client := mock.New().
+		HTML("https://site.test/?lang=en", `<a href="/a?lang=en&amp;page=2">en</a><a href="/a?lang=fr">fr</a><a href="/b">none</a>`).
+		HTML("https://site.test/a?lang=en&page=2", `leaf`).
+		Set("https://site.test/a?lang=fr", mock.Response{Err: errors.New("must not be fetched")}).
+		Set("https://site.test/b", mock.Response{Err: errors.New("must not be fetched")})
+
+	c := NewCrawler(client, extractor.New(), noLimit{}, "test-bot", time.Second, 2, 50, true, false)
+	c.ScopeQuery = true
+	st := store.NewMemory()
+	if _, err := c.Crawl(context.Background(), []string{"https://site.test/?lang=en"}, st); err != nil {
+		t.Fatalf("crawl: %v", err)
+	}
+
+	// Only pages with lang=en are crawled; the others are still recorded
+	// to be checked.
+	wantFetched := []string{"GET https://site.test/?lang=en", "GET https://site.test/a?lang=en&page=2"}
+	if got := client.Requests(); !reflect.DeepEqual(got, wantFetched) {
+		t.Fatalf("fetched %v, want %v", got, wantFetched)
+	}
+	for _, u := range []string{"https://site.test/a?lang=fr", "https://site.test/b"} {
+		if _, ok := st.Discovered(u); !ok {
+			t.Fatalf("%s not recorded", u)
+		}
+	}
+}
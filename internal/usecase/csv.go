@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// CSVColumns are the columns FormatCSV can write, in their default order:
+// the URL, its status (or ERR), the first page it was found on, the check
+// time in milliseconds, the crawl depth it was first seen at, and its kind.
+var CSVColumns = []string{"url", "status", "source", "elapsed", "depth", "kind"}
+
+// ValidateColumns checks that every name in cols is one of CSVColumns.
+func ValidateColumns(cols []string) error {
+	for _, c := range cols {
+		if !slices.Contains(CSVColumns, c) {
+			return fmt.Errorf("unknown column %q (want any of %s)", c, strings.Join(CSVColumns, ", "))
+		}
+	}
+	return nil
+}
+
+// csvWriter writes one row per checked link with the chosen columns.
+type csvWriter struct {
+	w    *csv.Writer
+	cols []string
+}
+
+// newCSVWriter writes the header row for cols (CSVColumns when empty).
+func newCSVWriter(w io.Writer, cols []string) (*csvWriter, error) {
+	if len(cols) == 0 {
+		cols = CSVColumns
+	}
+	cw := &csvWriter{w: csv.NewWriter(w), cols: cols}
+	return cw, cw.w.Write(cols)
+}
+
+func (cw *csvWriter) row(r domain.Result, m *domain.LinkMeta) error {
+	rec := make([]string, len(cw.cols))
+	for i, c := range cw.cols {
+		switch c {
+		case "url":
+			rec[i] = r.URL
+		case "status":
+			rec[i] = codeOrErr(r)
+		case "source":
+			rec[i] = firstSource(m)
+		case "elapsed":
+			rec[i] = strconv.FormatInt(r.Elapsed.Milliseconds(), 10)
+		case "depth":
+			rec[i] = strconv.Itoa(m.FirstSeenDepth)
+		case "kind":
+			rec[i] = string(m.Kind)
+		}
+	}
+	return cw.w.Write(rec)
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
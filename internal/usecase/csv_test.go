@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrchestrator_CSVColumns(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/ok">ok</a><a href="/dead">dead</a>`))
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{Format: FormatCSV, Columns: []string{"kind", "url", "status", "depth", "source"}})
+	var out bytes.Buffer
+	if _, err := orch.Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"kind,url,status,depth,source",
+		"page," + srv.URL + "/,200,0," + srv.URL + "/",
+		"page," + srv.URL + "/dead,404,0," + srv.URL + "/",
+		"page," + srv.URL + "/ok,200,0," + srv.URL + "/",
+	}, "\n") + "\n"
+	if out.String() != want {
+		t.Fatalf("csv:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	if err := ValidateColumns([]string{"url", "elapsed"}); err != nil {
+		t.Fatalf("known columns: %v", err)
+	}
+	err := ValidateColumns([]string{"url", "latency"})
+	if err == nil || !strings.Contains(err.Error(), `unknown column "latency"`) {
+		t.Fatalf("unknown column: got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RunDiff is the difference between two runs' results.
+type RunDiff struct {
+	NewlyDead []PriorResult // dead now, alive or absent before
+	Fixed     []PriorResult // dead before, alive now
+	StillDead []PriorResult
+}
+
+// DiffRuns compares two runs read with ReadNDJSON. Links only present in
+// the old run are ignored.
+func DiffRuns(before, after []PriorResult) RunDiff {
+	old := make(map[string]PriorResult, len(before))
+	for _, r := range before {
+		old[r.URL] = r
+	}
+
+	var d RunDiff
+	for _, r := range after {
+		prev, seen := old[r.URL]
+		switch {
+		case r.Dead && seen && prev.Dead:
+			d.StillDead = append(d.StillDead, r)
+		case r.Dead:
+			d.NewlyDead = append(d.NewlyDead, r)
+		case seen && prev.Dead:
+			d.Fixed = append(d.Fixed, r)
+		}
+	}
+
+	for _, list := range [][]PriorResult{d.NewlyDead, d.Fixed, d.StillDead} {
+		sort.Slice(list, func(i, j int) bool { return list[i].URL < list[j].URL })
+	}
+	return d
+}
+
+func (d RunDiff) Write(w io.Writer) error {
+	for _, r := range d.NewlyDead {
+		if _, err := fmt.Fprintf(w, "NEW   %-5s %s\n", priorCode(r), r.URL); err != nil {
+			return err
+		}
+	}
+	for _, r := range d.Fixed {
+		if _, err := fmt.Fprintf(w, "FIXED %-5s %s\n", priorCode(r), r.URL); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nNewly dead: %d  Fixed: %d  Still dead: %d\n", len(d.NewlyDead), len(d.Fixed), len(d.StillDead))
+	return err
+}
+
+func priorCode(r PriorResult) string {
+	if r.Error != "" {
+		return "ERR"
+	}
+	return fmt.Sprintf("%d", r.Status)
+}
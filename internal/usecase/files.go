@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// RunFiles extracts links from local HTML/Markdown files instead of crawling
+// and checks them. Every absolute link is checked (there is no start host to
+// scope against); relative links resolve to file:// URLs, which are checked
+// when the extractor and checker take the "file" scheme and reported as
+// skipped otherwise. A root-relative link ("/docs/a.md") resolves against
+// the filesystem root, not the repository's.
+func (o *Orchestrator) RunFiles(ctx context.Context, files []string, stdout io.Writer) (*Report, error) {
+	for _, name := range files {
+		if err := o.extractFile(name); err != nil {
+			return nil, err
+		}
+	}
+	return o.checkDiscovered(ctx, nil, true, stdout)
+}
+
+func (o *Orchestrator) extractFile(name string) error {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return fmt.Errorf("resolve %s: %w", name, err)
+	}
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	if !o.store.MarkVisitedPage(fileURL) {
+		return nil
+	}
+
+	f, err := os.Open(abs)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	page, err := o.crawler.extractor.Extract(fileURL, f)
+	if err != nil {
+		return fmt.Errorf("extract %s: %w", name, err)
+	}
+	o.recordLinks(page, abs)
+	return nil
+}
+
+// recordLinks stores the links extracted from a page that was not crawled
+// (a local file or a captured response), with source as their source.
+func (o *Orchestrator) recordLinks(page domain.Page, source string) {
+	for _, fl := range page.Links {
+		if fl.SkipReason != "" || fl.URL == "" {
+			o.store.RecordDiscoveredLink(domain.LinkMeta{
+				URL:     fl.Raw,
+				Kind:    fl.Kind,
+				Skipped: fl.SkipReason,
+			}, source)
+			continue
+		}
+		if fl.Kind == domain.LinkKindAsset && !o.crawler.checkAssets {
+			continue
+		}
+		o.store.RecordDiscoveredLink(domain.LinkMeta{
+			URL:       fl.URL,
+			Kind:      fl.Kind,
+			Warning:   fl.Warning,
+			Integrity: fl.Integrity,
+		}, source)
+	}
+}
+
+// RunURLs checks a fixed list of URLs without crawling.
+func (o *Orchestrator) RunURLs(ctx context.Context, urls []string, stdout io.Writer) (*Report, error) {
+	for _, u := range urls {
+		o.store.RecordDiscoveredLink(domain.LinkMeta{URL: u, Kind: domain.LinkKindPage}, "")
+	}
+	return o.checkDiscovered(ctx, nil, true, stdout)
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOrchestrator_RunFiles(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/dead", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	md := filepath.Join(dir, "doc.md")
+	body := "See [ok](" + srv.URL + "/ok), ![img](" + srv.URL + "/dead) and [local](other.md).\n"
+	if err := os.WriteFile(md, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	orch := newTestOrchestrator(Config{})
+	var out bytes.Buffer
+	rep, err := orch.RunFiles(context.Background(), []string{md}, &out)
+	if err != nil {
+		t.Fatalf("run files: %v", err)
+	}
+
+	if rep.Checked != 2 || rep.Failures != 1 {
+		t.Fatalf("expected 2 checked and 1 failure, got %+v", rep)
+	}
+	if !strings.Contains(out.String(), "DEAD 404   "+srv.URL+"/dead") {
+		t.Fatalf("missing dead line:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "found on : "+md) {
+		t.Fatalf("expected source file in output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+type graphPage struct {
+	URL          string      `json:"url"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"last_modified,omitempty"`
+	NoIndex      bool        `json:"no_index,omitempty"`
+	NoFollow     bool        `json:"no_follow,omitempty"`
+	Links        []graphLink `json:"links"`
+}
+
+type graphLink struct {
+	URL         string `json:"url,omitempty"`
+	Raw         string `json:"raw,omitempty"`
+	Kind        string `json:"kind"`
+	Skipped     string `json:"skipped,omitempty"`
+	Warning     string `json:"warning,omitempty"`
+	Integrity   string `json:"integrity,omitempty"`
+	Occurrences int    `json:"occurrences,omitempty"`
+}
+
+// WriteGraph writes a crawl's link graph (see Crawler.Graph) as one
+// indented JSON array, for ReadGraph to load into a later crawl.
+func WriteGraph(w io.Writer, pages []domain.CrawledPage) error {
+	out := make([]graphPage, 0, len(pages))
+	for _, p := range pages {
+		gp := graphPage{
+			URL:          p.URL,
+			ETag:         p.ETag,
+			LastModified: p.LastModified,
+			NoIndex:      p.Page.NoIndex,
+			NoFollow:     p.Page.NoFollow,
+			Links:        make([]graphLink, 0, len(p.Page.Links)),
+		}
+		for _, fl := range p.Page.Links {
+			gp.Links = append(gp.Links, graphLink{
+				URL:         fl.URL,
+				Raw:         fl.Raw,
+				Kind:        string(fl.Kind),
+				Skipped:     string(fl.SkipReason),
+				Warning:     string(fl.Warning),
+				Integrity:   fl.Integrity,
+				Occurrences: fl.Occurrences,
+			})
+		}
+		out = append(out, gp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// ReadGraph reads a link graph written by WriteGraph, keyed by page URL
+// (the form of Crawler.Prior).
+func ReadGraph(r io.Reader) (map[string]domain.CrawledPage, error) {
+	var in []graphPage
+	if err := json.NewDecoder(r).Decode(&in); err != nil {
+		return nil, fmt.Errorf("read link graph: %w", err)
+	}
+
+	out := make(map[string]domain.CrawledPage, len(in))
+	for _, gp := range in {
+		p := domain.CrawledPage{
+			URL:          gp.URL,
+			ETag:         gp.ETag,
+			LastModified: gp.LastModified,
+			Page:         domain.Page{NoIndex: gp.NoIndex, NoFollow: gp.NoFollow},
+		}
+		for _, gl := range gp.Links {
+			p.Page.Links = append(p.Page.Links, domain.FoundLink{
+				URL:         gl.URL,
+				Raw:         gl.Raw,
+				Kind:        domain.LinkKind(gl.Kind),
+				SkipReason:  domain.SkipReason(gl.Skipped),
+				Warning:     domain.WarningKind(gl.Warning),
+				Integrity:   gl.Integrity,
+				Occurrences: gl.Occurrences,
+			})
+		}
+		out[p.URL] = p
+	}
+	return out, nil
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// harFile is the part of a HAR (HTTP Archive) capture we read.
+type harFile struct {
+	Log struct {
+		Pages []struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"pages"`
+		Entries []harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Pageref string `json:"pageref"`
+	Request struct {
+		URL     string `json:"url"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+	} `json:"request"`
+	Response struct {
+		Content struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+			Encoding string `json:"encoding"`
+		} `json:"content"`
+	} `json:"response"`
+}
+
+// captureEntry is one request of a capture (HAR or WARC): its URL, the
+// page it was loaded for ("" if unknown), and its response.
+type captureEntry struct {
+	name     string // "har entry 3", for errors
+	URL      string
+	Source   string
+	MimeType string
+	Body     string
+	// BodyErr is why the captured body could not be decoded.
+	BodyErr error
+}
+
+// RunHAR checks every request URL in a HAR capture, as a browser session
+// loaded it, found on the page its entry belongs to: the page's URL when
+// the HAR names its pages by URL (as Chrome does), else the request's
+// Referer. With extractHTML set, links are also extracted from the
+// captured HTML responses and checked, resolved against the request URL.
+// Requests that are not http(s) (data:, blob:, ...) are reported as
+// skipped. A captured body that cannot be decoded or extracted is
+// reported as an extraction error of its page, and the run goes on.
+func (o *Orchestrator) RunHAR(ctx context.Context, har io.Reader, extractHTML bool, stdout io.Writer) (*Report, error) {
+	var h harFile
+	if err := json.NewDecoder(har).Decode(&h); err != nil {
+		return nil, fmt.Errorf("read har: %w", err)
+	}
+
+	pages := make(map[string]string, len(h.Log.Pages))
+	for _, p := range h.Log.Pages {
+		if isHTTPURL(p.Title) {
+			pages[p.ID] = p.Title
+		}
+	}
+	entries := make([]captureEntry, 0, len(h.Log.Entries))
+	for i, e := range h.Log.Entries {
+		c := captureEntry{
+			name:     fmt.Sprintf("har entry %d", i),
+			URL:      e.Request.URL,
+			Source:   pages[e.Pageref],
+			MimeType: e.Response.Content.MimeType,
+			Body:     e.Response.Content.Text,
+		}
+		if c.Source == "" {
+			for _, hd := range e.Request.Headers {
+				if strings.EqualFold(hd.Name, "Referer") && isHTTPURL(hd.Value) {
+					c.Source = hd.Value
+				}
+			}
+		}
+		if e.Response.Content.Encoding == "base64" {
+			b, err := base64.StdEncoding.DecodeString(c.Body)
+			if err != nil {
+				c.BodyErr = fmt.Errorf("decode body: %w", err)
+			}
+			c.Body = string(b)
+		}
+		entries = append(entries, c)
+	}
+	return o.checkCapture(ctx, entries, extractHTML, stdout)
+}
+
+// checkCapture records the entries' URLs as links found on their source
+// pages, and with extractHTML the links of their HTML bodies, then checks
+// them all.
+func (o *Orchestrator) checkCapture(ctx context.Context, entries []captureEntry, extractHTML bool, stdout io.Writer) (*Report, error) {
+	for _, e := range entries {
+		if !isHTTPURL(e.URL) {
+			o.store.RecordDiscoveredLink(domain.LinkMeta{URL: e.URL, Skipped: domain.SkipUnsupportedScheme}, e.Source)
+			continue
+		}
+
+		isHTML := harIsHTML(e.MimeType)
+		kind := domain.LinkKindAsset
+		if isHTML {
+			kind = domain.LinkKindPage
+		}
+		o.store.RecordDiscoveredLink(domain.LinkMeta{URL: e.URL, Kind: kind}, e.Source)
+
+		if !extractHTML || !isHTML || (e.Body == "" && e.BodyErr == nil) || !o.store.MarkVisitedPage(e.URL) {
+			continue
+		}
+		if e.BodyErr != nil {
+			o.store.RecordExtractError(e.URL, fmt.Errorf("%s: %w", e.name, e.BodyErr))
+			continue
+		}
+		page, err := o.crawler.extractor.Extract(e.URL, strings.NewReader(e.Body))
+		if err != nil {
+			o.store.RecordExtractError(e.URL, fmt.Errorf("%s: %w", e.name, err))
+			continue
+		}
+		o.recordLinks(page, e.URL)
+	}
+	return o.checkDiscovered(ctx, nil, true, stdout)
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
+func harIsHTML(mimeType string) bool {
+	mt, _, err := mime.ParseMediaType(mimeType)
+	return err == nil && (mt == "text/html" || mt == "application/xhtml+xml")
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// harFixture is a capture of one page load: the page, a stylesheet that
+// has since disappeared, and an inline data: image. BASE is replaced with
+// the test server's URL and PAGE with the base64 page body.
+const harFixture = `{
+  "log": {
+    "version": "1.2",
+    "entries": [
+      {
+        "request": {"method": "GET", "url": "BASE/"},
+        "response": {"status": 200, "content": {"mimeType": "text/html; charset=utf-8", "encoding": "base64", "text": "PAGE"}}
+      },
+      {
+        "request": {"method": "GET", "url": "BASE/style.css"},
+        "response": {"status": 200, "content": {"mimeType": "text/css", "text": "body{}"}}
+      },
+      {
+        "request": {"method": "GET", "url": "data:image/png;base64,iVBORw0KGgo="},
+        "response": {"status": 200, "content": {"mimeType": "image/png"}}
+      }
+    ]
+  }
+}`
+
+func TestOrchestrator_RunHAR(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`home`))
+	mux.HandleFunc("/about", htmlHandler(`about`))
+	srv := httptest.NewServer(mux) // /style.css and /old are 404s now
+	defer srv.Close()
+
+	page := base64.StdEncoding.EncodeToString([]byte(`<a href="/about">about</a><a href="/old">old</a>`))
+	har := strings.NewReplacer("BASE", srv.URL, "PAGE", page).Replace(harFixture)
+
+	t.Run("request URLs", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{})
+		var out bytes.Buffer
+		rep, err := orch.RunHAR(context.Background(), strings.NewReader(har), false, &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if rep.Checked != 2 || rep.DeadHTTP != 1 || rep.SkippedCounts[domain.SkipUnsupportedScheme] != 1 {
+			t.Fatalf("expected 2 checked, 1 dead, 1 skipped data: URL, got %+v", rep)
+		}
+		if !strings.Contains(out.String(), "DEAD 404   "+srv.URL+"/style.css") {
+			t.Fatalf("missing DEAD line for the stylesheet:\n%s", out.String())
+		}
+	})
+
+	t.Run("with links from captured HTML", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{})
+		var out bytes.Buffer
+		rep, err := orch.RunHAR(context.Background(), strings.NewReader(har), true, &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if rep.Checked != 4 || rep.DeadHTTP != 2 {
+			t.Fatalf("expected 4 checked and 2 dead, got %+v", rep)
+		}
+		if !strings.Contains(out.String(), "DEAD 404   "+srv.URL+"/old\n       found on : "+srv.URL+"/") {
+			t.Fatalf("missing DEAD line for the captured page's link:\n%s", out.String())
+		}
+	})
+
+	t.Run("undecodable body", func(t *testing.T) {
+		bad := strings.NewReplacer("BASE", srv.URL, "PAGE", "not base64!").Replace(harFixture)
+		orch := newTestOrchestrator(Config{})
+		var out bytes.Buffer
+		rep, err := orch.RunHAR(context.Background(), strings.NewReader(bad), true, &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		// The entry itself is still checked, as are the others.
+		if rep.Checked != 2 || rep.DeadHTTP != 1 {
+			t.Fatalf("expected 2 checked and 1 dead, got %+v", rep)
+		}
+		if len(rep.ExtractErrors) != 1 || rep.ExtractErrors[0].Page != srv.URL+"/" || !strings.Contains(rep.ExtractErrors[0].Err.Error(), "har entry 0: decode body") {
+			t.Fatalf("extract errors: got %+v", rep.ExtractErrors)
+		}
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{})
+		if _, err := orch.RunHAR(context.Background(), strings.NewReader("{"), false, &bytes.Buffer{}); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestOrchestrator_RunHARSources(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	// Chrome names pages by URL; Firefox by title, leaving the Referer.
+	har := strings.ReplaceAll(`{
+  "log": {
+    "pages": [{"id": "page_1", "title": "BASE/"}, {"id": "page_2", "title": "About us"}],
+    "entries": [
+      {"pageref": "page_1", "request": {"url": "BASE/app.js"}, "response": {"content": {"mimeType": "text/javascript"}}},
+      {"pageref": "page_2", "request": {"url": "BASE/logo.png", "headers": [{"name": "referer", "value": "BASE/about"}]}, "response": {"content": {"mimeType": "image/png"}}},
+      {"request": {"url": "BASE/font.woff"}, "response": {"content": {"mimeType": "font/woff"}}}
+    ]
+  }
+}`, "BASE", srv.URL)
+
+	orch := newTestOrchestrator(Config{})
+	if _, err := orch.RunHAR(context.Background(), strings.NewReader(har), false, io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for link, want := range map[string][]string{
+		"/app.js":    {srv.URL + "/"},
+		"/logo.png":  {srv.URL + "/about"},
+		"/font.woff": {},
+	} {
+		m, ok := orch.store.Discovered(srv.URL + link)
+		if !ok {
+			t.Fatalf("%s not discovered", link)
+		}
+		if got := sources(m); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: sources %q, want %q", link, got, want)
+		}
+	}
+}
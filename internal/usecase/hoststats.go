@@ -0,0 +1,90 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// HostStats summarizes how one host answered over a run, to spot hosts
+// that went down partway through.
+type HostStats struct {
+	Host     string
+	OK       int
+	Failures int
+	// FirstOK and LastOK are the CheckedAt of the host's first and last
+	// successful result; zero if it never answered successfully.
+	FirstOK time.Time
+	LastOK  time.Time
+
+	// AvgLatency and P95Latency are over the host's network checks, dead
+	// or alive; zero if all its results came from a cache.
+	AvgLatency time.Duration
+	P95Latency time.Duration
+}
+
+// hostStatsSet aggregates results by host.
+type hostStatsSet map[string]*hostStats
+
+type hostStats struct {
+	HostStats
+	elapsed latencies
+}
+
+func (s hostStatsSet) add(r domain.Result) {
+	host := r.URL
+	if u, err := url.Parse(r.URL); err == nil && u.Host != "" {
+		host = strings.ToLower(u.Host)
+	}
+
+	st, ok := s[host]
+	if !ok {
+		st = &hostStats{HostStats: HostStats{Host: host}}
+		s[host] = st
+	}
+
+	st.elapsed.add(r)
+	if r.IsDead() {
+		st.Failures++
+		return
+	}
+	st.OK++
+	if st.FirstOK.IsZero() || r.CheckedAt.Before(st.FirstOK) {
+		st.FirstOK = r.CheckedAt
+	}
+	if r.CheckedAt.After(st.LastOK) {
+		st.LastOK = r.CheckedAt
+	}
+}
+
+// sorted returns the stats ordered by host.
+func (s hostStatsSet) sorted() []HostStats {
+	out := make([]HostStats, 0, len(s))
+	for _, st := range s {
+		st.AvgLatency, st.P95Latency = st.elapsed.mean(), st.elapsed.percentile(95)
+		out = append(out, st.HostStats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+func writeHostStats(w io.Writer, stats []HostStats) {
+	fmt.Fprintln(w, "\nPer-host stats:")
+	for _, st := range stats {
+		fmt.Fprintf(w, "  %s  ok=%d failures=%d first_ok=%s last_ok=%s avg=%s p95=%s\n",
+			st.Host, st.OK, st.Failures, formatStamp(st.FirstOK), formatStamp(st.LastOK),
+			st.AvgLatency.Round(time.Millisecond), st.P95Latency.Round(time.Millisecond))
+	}
+}
+
+func formatStamp(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339Nano)
+}
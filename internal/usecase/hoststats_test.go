@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+func TestHostStatsSet_FirstAndLastOK(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+
+	s := hostStatsSet{}
+	// Added out of time order, as results are sorted by URL.
+	s.add(domain.Result{URL: "https://a.test/3", StatusCode: 200, CheckedAt: at(30)})
+	s.add(domain.Result{URL: "https://a.test/1", StatusCode: 200, CheckedAt: at(10)})
+	s.add(domain.Result{URL: "https://a.test/2", StatusCode: 200, CheckedAt: at(20)})
+	s.add(domain.Result{URL: "https://a.test/4", StatusCode: 503, CheckedAt: at(40)})
+	s.add(domain.Result{URL: "https://A.test/5", Err: errors.New("refused"), CheckedAt: at(50)})
+	s.add(domain.Result{URL: "https://b.test/", StatusCode: 404, CheckedAt: at(5)})
+
+	got := s.sorted()
+	want := []HostStats{
+		{Host: "a.test", OK: 3, Failures: 2, FirstOK: at(10), LastOK: at(30)},
+		{Host: "b.test", Failures: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d hosts, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("host %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestHostStatsSet_Latency(t *testing.T) {
+	ms := func(n int) time.Duration { return time.Duration(n) * time.Millisecond }
+
+	s := hostStatsSet{}
+	for i := 1; i <= 20; i++ {
+		// fast.test: 1ms to 20ms; slow.test: 10 times that.
+		s.add(domain.Result{URL: "https://fast.test/", StatusCode: 200, Elapsed: ms(i)})
+		s.add(domain.Result{URL: "https://slow.test/", StatusCode: 200, Elapsed: ms(10 * i)})
+	}
+	// Dead links count; cached results do not.
+	s.add(domain.Result{URL: "https://slow.test/gone", StatusCode: 404, Elapsed: ms(210)})
+	s.add(domain.Result{URL: "https://fast.test/cached", StatusCode: 200, Elapsed: time.Hour, FromCache: true})
+
+	got := s.sorted()
+	if len(got) != 2 {
+		t.Fatalf("expected 2 hosts, got %+v", got)
+	}
+	if fast := got[0]; fast.AvgLatency != 10500*time.Microsecond || fast.P95Latency != ms(19) {
+		t.Fatalf("fast.test: avg %s p95 %s", fast.AvgLatency, fast.P95Latency)
+	}
+	if slow := got[1]; slow.AvgLatency != ms(110) || slow.P95Latency != ms(200) {
+		t.Fatalf("slow.test: avg %s p95 %s", slow.AvgLatency, slow.P95Latency)
+	}
+}
+
+func TestOrchestrator_PerHostStatsNDJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/ok">ok</a><a href="/dead">dead</a>`))
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/dead", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	start := time.Now()
+	orch := newTestOrchestrator(Config{Format: FormatNDJSON, PerHostStats: true})
+	var out bytes.Buffer
+	if _, err := orch.Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var sum ndjsonSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &sum); err != nil {
+		t.Fatal(err)
+	}
+	if len(sum.Hosts) != 1 {
+		t.Fatalf("expected one host, got %+v", sum.Hosts)
+	}
+	h := sum.Hosts[0]
+	if h.Host != strings.TrimPrefix(srv.URL, "http://") || h.OK != 2 || h.Failures != 1 {
+		t.Fatalf("unexpected host stats %+v", h)
+	}
+	if h.FirstOK == nil || h.LastOK == nil || h.FirstOK.Before(start) || h.LastOK.Before(*h.FirstOK) {
+		t.Fatalf("unexpected timestamps %+v", h)
+	}
+
+	rep, err := newTestOrchestrator(Config{}).Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.HostStats != nil {
+		t.Fatalf("host stats collected without PerHostStats: %+v", rep.HostStats)
+	}
+}
@@ -0,0 +1,63 @@
+package usecase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// WriteDiscovered lists discovered links, one per line, for crawl-only runs.
+func WriteDiscovered(w io.Writer, links []*domain.LinkMeta, crawled int) error {
+	for _, m := range links {
+		var err error
+		if m.Skipped != "" {
+			_, err = fmt.Fprintf(w, "%-5s skipped=%s %s\n", m.Kind, m.Skipped, m.URL)
+		} else {
+			_, err = fmt.Fprintf(w, "%-5s depth=%d %s\n", m.Kind, m.FirstSeenDepth, m.URL)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nCrawled pages: %d\nDiscovered links: %d\n", crawled, len(links))
+	return err
+}
+
+type inventoryRecord struct {
+	URL            string   `json:"url"`
+	Kind           string   `json:"kind"`
+	FirstSeenDepth int      `json:"first_seen_depth"`
+	Skipped        string   `json:"skipped,omitempty"`
+	Warning        string   `json:"warning,omitempty"`
+	Sources        []string `json:"sources"`
+	SourceCount    int      `json:"source_count"`
+}
+
+// WriteInventory writes every discovered link, checked or skipped, as one
+// indented JSON array with its metadata and sorted source pages.
+func WriteInventory(w io.Writer, links []*domain.LinkMeta) error {
+	out := make([]inventoryRecord, 0, len(links))
+	for _, m := range links {
+		sources := make([]string, 0, len(m.Sources))
+		for src := range m.Sources {
+			sources = append(sources, src)
+		}
+		slices.Sort(sources)
+		out = append(out, inventoryRecord{
+			URL:            m.URL,
+			Kind:           string(m.Kind),
+			FirstSeenDepth: m.FirstSeenDepth,
+			Skipped:        string(m.Skipped),
+			Warning:        string(m.Warning),
+			Sources:        sources,
+			SourceCount:    m.SourceCount,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,87 @@
+package usecase
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// jsonReport is a whole report as one JSON document, built from the same
+// records the ndjson output streams.
+type jsonReport struct {
+	// Error is why the run failed, when WriteJSONError is given one.
+	Error    string         `json:"error,omitempty"`
+	Summary  ndjsonSummary  `json:"summary"`
+	Results  []ndjsonResult `json:"results"`
+	Skipped  []jsonSkipped  `json:"skipped"`
+	Warnings []jsonWarning  `json:"warnings"`
+}
+
+type jsonWarning struct {
+	URL    string `json:"url"`
+	Kind   string `json:"kind"`
+	Detail string `json:"detail"`
+}
+
+type jsonSkipped struct {
+	URL     string   `json:"url"`
+	Reason  string   `json:"reason"`
+	Sources []string `json:"sources"`
+}
+
+// WriteJSON writes rep as a single JSON object, the same document the json
+// output format writes: the summary, every result with where it was
+// found, and every skipped link. Report.Results must be populated, so it
+// does not work with low-memory runs.
+func WriteJSON(w io.Writer, rep *Report, withHosts bool) error {
+	return WriteJSONError(w, rep, withHosts, nil)
+}
+
+// WriteJSONError is WriteJSON with runErr, when not nil, as the document's
+// "error": why the run failed, e.g. its verdict or an unreachable start
+// page. rep may be nil when the run failed before it had a report.
+func WriteJSONError(w io.Writer, rep *Report, withHosts bool, runErr error) error {
+	if rep == nil {
+		rep = &Report{}
+	}
+	out := &jsonReport{Results: make([]ndjsonResult, 0, len(rep.Results))}
+	if runErr != nil {
+		out.Error = runErr.Error()
+	}
+	for _, r := range rep.Results {
+		out.add(r, rep.links[r.URL], rep.downgrade != nil && rep.downgrade(r) != "")
+	}
+	return out.write(w, rep, withHosts)
+}
+
+// add records r, checked for the discovered link m (nil if unknown);
+// downgraded results are not dead, as in the ndjson output.
+func (j *jsonReport) add(r domain.Result, m *domain.LinkMeta, downgraded bool) {
+	rec := resultRecord(r)
+	if m != nil {
+		rec = linkRecord(r, m)
+	}
+	if downgraded {
+		rec.Dead = false
+	}
+	j.Results = append(j.Results, rec)
+}
+
+// write completes the document with rep's summary, skipped links and
+// warnings, and encodes it.
+func (j *jsonReport) write(w io.Writer, rep *Report, withHosts bool) error {
+	j.Summary = summaryRecord(rep, withHosts)
+	if j.Results == nil {
+		j.Results = []ndjsonResult{}
+	}
+	j.Skipped = make([]jsonSkipped, 0, len(rep.Skipped))
+	for _, s := range rep.Skipped {
+		j.Skipped = append(j.Skipped, jsonSkipped{URL: s.URL, Reason: string(s.Reason), Sources: s.Sources})
+	}
+	j.Warnings = make([]jsonWarning, 0, len(rep.Warnings))
+	for _, wn := range rep.Warnings {
+		j.Warnings = append(j.Warnings, jsonWarning{URL: wn.URL, Kind: string(wn.Kind), Detail: wn.Detail})
+	}
+	return json.NewEncoder(w).Encode(j)
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// Latency holds response-time percentiles over a run's network checks.
+type Latency struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+}
+
+// maxLatencySamples caps the Elapsed values latencies keeps: past it, a
+// uniform sample of the checks stands in for all of them.
+const maxLatencySamples = 10000
+
+// latencies collects the Elapsed of results that went over the network;
+// cached results say nothing about the site's speed. Percentiles come from
+// at most maxLatencySamples of them (reservoir sampling), the mean from all.
+type latencies struct {
+	n       int
+	sum     time.Duration
+	samples []time.Duration
+}
+
+func (l *latencies) add(r domain.Result) {
+	if r.FromCache {
+		return
+	}
+	l.n++
+	l.sum += r.Elapsed
+	if len(l.samples) < maxLatencySamples {
+		l.samples = append(l.samples, r.Elapsed)
+	} else if i := rand.IntN(l.n); i < maxLatencySamples {
+		l.samples[i] = r.Elapsed
+	}
+}
+
+// percentiles returns the nearest-rank percentiles; zero with no samples.
+func (l latencies) percentiles() Latency {
+	if len(l.samples) == 0 {
+		return Latency{}
+	}
+	sorted := slices.Clone(l.samples)
+	slices.Sort(sorted)
+	return Latency{P50: rank(sorted, 50), P90: rank(sorted, 90), P99: rank(sorted, 99)}
+}
+
+// percentile returns the nearest-rank percentile p; zero with no samples.
+func (l latencies) percentile(p float64) time.Duration {
+	if len(l.samples) == 0 {
+		return 0
+	}
+	sorted := slices.Clone(l.samples)
+	slices.Sort(sorted)
+	return rank(sorted, p)
+}
+
+// mean returns the average; zero with no samples.
+func (l latencies) mean() time.Duration {
+	if l.n == 0 {
+		return 0
+	}
+	return l.sum / time.Duration(l.n)
+}
+
+// rank returns the nearest-rank percentile p of sorted.
+func rank(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	return sorted[max(i, 0)]
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+func TestLatencies_Percentiles(t *testing.T) {
+	var l latencies
+	if got := l.percentiles(); got != (Latency{}) {
+		t.Fatalf("no samples: got %+v", got)
+	}
+
+	// 1ms..100ms, added out of order, plus a cached result that must not
+	// count.
+	for i := 100; i >= 1; i-- {
+		l.add(domain.Result{Elapsed: time.Duration(i) * time.Millisecond})
+	}
+	l.add(domain.Result{Elapsed: time.Hour, FromCache: true})
+
+	want := Latency{P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P99: 99 * time.Millisecond}
+	if got := l.percentiles(); got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	// Nearest rank: with three samples p50 is the second and p90/p99 the third.
+	var small latencies
+	for _, ms := range []time.Duration{30, 10, 20} {
+		small.add(domain.Result{Elapsed: ms * time.Millisecond})
+	}
+	want = Latency{P50: 20 * time.Millisecond, P90: 30 * time.Millisecond, P99: 30 * time.Millisecond}
+	if got := small.percentiles(); got != want {
+		t.Fatalf("three samples: got %+v, want %+v", got, want)
+	}
+}
+
+func TestLatencies_BoundedSamples(t *testing.T) {
+	var l latencies
+	for i := 1; i <= 3*maxLatencySamples; i++ {
+		l.add(domain.Result{Elapsed: time.Duration(i) * time.Microsecond})
+	}
+	if len(l.samples) != maxLatencySamples {
+		t.Fatalf("kept %d samples, want %d", len(l.samples), maxLatencySamples)
+	}
+	// The mean is over every check, not just the sample.
+	if want := time.Duration(3*maxLatencySamples+1) * time.Microsecond / 2; l.mean() != want {
+		t.Fatalf("mean: got %s, want %s", l.mean(), want)
+	}
+	// A uniform sample puts the median near the middle of 1..30000µs.
+	if p50 := l.percentiles().P50; p50 < 13*time.Millisecond || p50 > 17*time.Millisecond {
+		t.Fatalf("p50: got %s, want about 15ms", p50)
+	}
+}
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// lintFailing lists the lint findings that are certainly broken, as opposed
+// to links that are merely not checkable (mailto:, #top).
+var lintFailing = map[string]bool{
+	string(domain.SkipInvalidURL):     true,
+	string(domain.SkipEmpty):          true,
+	string(domain.WarnSchemeLessHost): true,
+}
+
+// WriteLint lists the discovered links whose syntax alone rules them out:
+// malformed URLs, empty hrefs, unsupported schemes, fragment-only links and
+// hrefs that look like a host without a scheme. It needs no network checks.
+// It returns how many of them are certainly broken (see lintFailing).
+func WriteLint(w io.Writer, links []*domain.LinkMeta, crawled int) (int, error) {
+	found, failing := 0, 0
+	for _, m := range links {
+		var kind string
+		switch {
+		case m.Skipped == domain.SkipInvalidURL, m.Skipped == domain.SkipEmpty,
+			m.Skipped == domain.SkipUnsupportedScheme, m.Skipped == domain.SkipFragmentOnly:
+			kind = string(m.Skipped)
+		case m.Skipped == "" && m.Warning == domain.WarnSchemeLessHost:
+			kind = string(m.Warning)
+		default:
+			continue
+		}
+		found++
+		if lintFailing[kind] {
+			failing++
+		}
+
+		if _, err := fmt.Fprintf(w, "%-18s %q\n", kind, m.URL); err != nil {
+			return 0, err
+		}
+		for _, src := range sources(m) {
+			if _, err := fmt.Fprintf(w, "    found on : %s\n", src); err != nil {
+				return 0, err
+			}
+		}
+		if more := m.SourceCount - len(m.Sources); more > 0 {
+			if _, err := fmt.Fprintf(w, "    and %d more pages\n", more); err != nil {
+				return 0, err
+			}
+		}
+	}
+	_, err := fmt.Fprintf(w, "\nCrawled pages: %d\nDiscovered links: %d\nLint findings: %d (%d malformed)\n", crawled, len(links), found, failing)
+	return failing, err
+}
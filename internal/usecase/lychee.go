@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// lycheeReport collects what FormatLychee prints: the failures grouped by
+// the page they were found on, then lychee's one-line summary. It follows
+// lychee's compact text output without colors (as lychee writes it when
+// stdout is not a terminal), so parsers written for lychee keep working.
+// It follows lychee's documentation and has not been compared with the
+// output of a lychee release (see TestOrchestrator_LycheeFormat).
+type lycheeReport struct {
+	bySource map[string][]string
+
+	total, ok, errors, timeouts, redirects int
+	excluded, unsupported                  int
+}
+
+func newLycheeReport() *lycheeReport {
+	return &lycheeReport{bySource: map[string][]string{}}
+}
+
+// add counts a result; failed ones get a line under each page linking
+// them.
+func (l *lycheeReport) add(r domain.Result, m *domain.LinkMeta, failed bool) {
+	l.total++
+	switch {
+	case failed && isTimeout(r.Err):
+		l.timeouts++
+	case failed:
+		l.errors++
+	case r.StatusCode >= 300 && r.StatusCode <= 399:
+		l.redirects++
+	default:
+		l.ok++
+	}
+	if !failed {
+		return
+	}
+	// A crawled page is recorded as its own source; lychee lists a link
+	// under its own URL only when nothing else links it (a start page).
+	var srcs []string
+	for _, src := range sources(m) {
+		if src != r.URL {
+			srcs = append(srcs, src)
+		}
+	}
+	if len(srcs) == 0 {
+		srcs = []string{r.URL}
+	}
+	for _, src := range srcs {
+		l.bySource[src] = append(l.bySource[src], lycheeLine(r))
+	}
+}
+
+// skipped counts links that were not checked: lychee's "unsupported" for
+// unsupported schemes, "excluded" for the rest.
+func (l *lycheeReport) skipped(counts map[domain.SkipReason]int) {
+	for reason, n := range counts {
+		if reason == domain.SkipUnsupportedScheme {
+			l.unsupported += n
+		} else {
+			l.excluded += n
+		}
+	}
+}
+
+func lycheeLine(r domain.Result) string {
+	switch {
+	case isTimeout(r.Err):
+		return fmt.Sprintf("[TIMEOUT] %s | Timeout", r.URL)
+	case r.Err != nil:
+		return fmt.Sprintf("[ERROR] %s | Network error: %v", r.URL, r.Err)
+	}
+	return fmt.Sprintf("[%d] %s | Rejected status code (this depends on your \"accept\" configuration): %s",
+		r.StatusCode, r.URL, http.StatusText(r.StatusCode))
+}
+
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &ne) && ne.Timeout()
+}
+
+func (l *lycheeReport) write(w io.Writer, elapsed time.Duration) error {
+	var b strings.Builder
+	if n := len(l.bySource); n > 0 {
+		inputs := "inputs"
+		if n == 1 {
+			inputs = "input"
+		}
+		fmt.Fprintf(&b, "Issues found in %d %s. Find details below.\n\n", n, inputs)
+	}
+	srcs := make([]string, 0, len(l.bySource))
+	for src := range l.bySource {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+	for _, src := range srcs {
+		fmt.Fprintf(&b, "[%s]:\n", src)
+		for _, line := range l.bySource[src] {
+			fmt.Fprintln(&b, line)
+		}
+		fmt.Fprintln(&b)
+	}
+
+	errs := "Errors"
+	if l.errors == 1 {
+		errs = "Error"
+	}
+	fmt.Fprintf(&b, "🔍 %d Total (in %s) ✅ %d OK 🚫 %d %s", l.total, humanDuration(elapsed), l.ok, l.errors, errs)
+	for _, c := range []struct {
+		n          int
+		icon, name string
+	}{
+		{l.timeouts, "⏳", "Timeouts"},
+		{l.excluded, "👻", "Excluded"},
+		{l.unsupported, "⛔", "Unsupported"},
+		{l.redirects, "🔀", "Redirects"},
+	} {
+		if c.n > 0 {
+			fmt.Fprintf(&b, " %s %d %s", c.icon, c.n, c.name)
+		}
+	}
+	fmt.Fprintln(&b)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// humanDuration formats d in whole seconds the way lychee does: "0s",
+// "42s", "1m 5s"; zero parts are left out ("2h 3s").
+func humanDuration(d time.Duration) string {
+	secs := int64(d / time.Second)
+	if secs == 0 {
+		return "0s"
+	}
+	var parts []string
+	for _, u := range []struct {
+		secs int64
+		unit string
+	}{{3600, "h"}, {60, "m"}, {1, "s"}} {
+		if n := secs / u.secs; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.unit))
+			secs -= n * u.secs
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,276 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// ndjsonWriter emits one JSON object per line: a "result" record per
+// checked link as soon as it completes, then a single "summary" record.
+// Each record is flushed when the writer buffers (a gzipped -output), so
+// a reader sees results as they come.
+type ndjsonWriter struct {
+	w   io.Writer
+	enc *json.Encoder
+
+	// downgrade, when set, names results reported as warnings rather than
+	// dead (see Orchestrator.downgrade).
+	downgrade func(domain.Result) domain.WarningKind
+}
+
+type ndjsonResult struct {
+	Type      string            `json:"type"`
+	URL       string            `json:"url"`
+	Status    int               `json:"status"`
+	Error     string            `json:"error,omitempty"`
+	ElapsedMS int64             `json:"elapsed_ms"`
+	FinalURL  string            `json:"final_url,omitempty"`
+	Method    string            `json:"method,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Dead      bool              `json:"dead"`
+	Cached    bool              `json:"cached"`
+
+	// RedirectChain is every redirect followed, in order: the URL
+	// requested and the 3xx it answered. It is empty, not null, for a
+	// link that did not redirect.
+	RedirectChain []ndjsonHop `json:"redirect_chain"`
+
+	// Depth and Sources say where the link was found, when that is known:
+	// the depth of the first page it was seen on, and its pages, sorted
+	// (as many as the store keeps; see store.WithMaxSources).
+	Depth   *int     `json:"depth,omitempty"`
+	Sources []string `json:"sources,omitempty"`
+}
+
+type ndjsonHop struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+}
+
+type ndjsonSummary struct {
+	Type       string `json:"type"`
+	Crawled    int    `json:"crawled"`
+	Discovered int    `json:"discovered"`
+	Checked    int    `json:"checked"`
+	OK         int    `json:"ok"`
+	Redirects  int    `json:"redirects"`
+	DeadHTTP   int    `json:"dead_http"`
+	Errors     int    `json:"errors"`
+	Warnings   int    `json:"warnings"`
+	Failures   int    `json:"failures"`
+
+	// HealthScore is the percentage of checked links that are healthy
+	// (see Report.HealthScore).
+	HealthScore float64 `json:"health_score"`
+
+	AuthRequired int `json:"auth_required,omitempty"`
+	Tolerated    int `json:"tolerated_errors,omitempty"`
+	DepthLimited int `json:"depth_limited,omitempty"`
+	Disallowed   int `json:"robots_disallowed,omitempty"`
+	Partial      int `json:"partial_content,omitempty"`
+
+	NetworkChecks int `json:"network_checks"`
+	Cached        int `json:"cached_checks"`
+
+	LatencyP50MS int64 `json:"latency_p50_ms"`
+	LatencyP90MS int64 `json:"latency_p90_ms"`
+	LatencyP99MS int64 `json:"latency_p99_ms"`
+
+	DiscoveredBy *ndjsonBreakdown `json:"discovered_by,omitempty"`
+
+	// SitemapOrphans and NotInSitemap list the sitemap audit's findings
+	// (see Report.Sitemap).
+	SitemapOrphans []string `json:"sitemap_orphans,omitempty"`
+	NotInSitemap   []string `json:"not_in_sitemap,omitempty"`
+
+	ByKind map[string]ndjsonKind `json:"by_kind"`
+	Hosts  []ndjsonHost          `json:"hosts,omitempty"`
+}
+
+type ndjsonKind struct {
+	OK        int `json:"ok"`
+	Redirects int `json:"redirects"`
+	DeadHTTP  int `json:"dead_http"`
+	Errors    int `json:"errors"`
+	Partial   int `json:"partial_content,omitempty"`
+}
+
+type ndjsonBreakdown struct {
+	Pages    int `json:"pages"`
+	Assets   int `json:"assets"`
+	SelfOnly int `json:"self_only"`
+	External int `json:"external"`
+	Skipped  int `json:"skipped"`
+}
+
+type ndjsonHost struct {
+	Host     string     `json:"host"`
+	OK       int        `json:"ok"`
+	Failures int        `json:"failures"`
+	FirstOK  *time.Time `json:"first_ok,omitempty"`
+	LastOK   *time.Time `json:"last_ok,omitempty"`
+
+	AvgLatencyMS int64 `json:"avg_latency_ms"`
+	P95LatencyMS int64 `json:"p95_latency_ms"`
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	return &ndjsonWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+// result writes r's record; m, if not nil, is the discovered link r is
+// the check of.
+func (n *ndjsonWriter) result(r domain.Result, m *domain.LinkMeta) error {
+	rec := resultRecord(r)
+	if m != nil {
+		rec = linkRecord(r, m)
+	}
+	if n.downgrade != nil && n.downgrade(r) != "" {
+		rec.Dead = false
+	}
+	if err := n.enc.Encode(rec); err != nil {
+		return err
+	}
+	return n.flush()
+}
+
+func (n *ndjsonWriter) flush() error {
+	if f, ok := n.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// summary writes the closing record; withHosts adds the per-host stats.
+func (n *ndjsonWriter) summary(rep *Report, withHosts bool) error {
+	return n.enc.Encode(summaryRecord(rep, withHosts))
+}
+
+func resultRecord(r domain.Result) ndjsonResult {
+	rec := ndjsonResult{
+		Type:      "result",
+		URL:       r.URL,
+		Status:    r.StatusCode,
+		ElapsedMS: r.Elapsed.Milliseconds(),
+		FinalURL:  r.FinalURL,
+		Method:    r.Method,
+		Headers:   r.Headers,
+		Title:     r.Title,
+		Dead:      r.IsDead(),
+		Cached:    r.FromCache,
+
+		RedirectChain: make([]ndjsonHop, 0, len(r.RedirectChain)),
+	}
+	for _, h := range r.RedirectChain {
+		rec.RedirectChain = append(rec.RedirectChain, ndjsonHop{URL: h.URL, Status: h.StatusCode})
+	}
+	if r.Err != nil {
+		rec.Error = r.Err.Error()
+	}
+	return rec
+}
+
+// linkRecord is resultRecord with where m, the link r checked, was found.
+func linkRecord(r domain.Result, m *domain.LinkMeta) ndjsonResult {
+	rec := resultRecord(r)
+	depth := m.FirstSeenDepth
+	rec.Depth, rec.Sources = &depth, sources(m)
+	return rec
+}
+
+func summaryRecord(rep *Report, withHosts bool) ndjsonSummary {
+	sum := ndjsonSummary{
+		Type:       "summary",
+		Crawled:    rep.Crawled,
+		Discovered: rep.Discovered,
+		Checked:    rep.Checked,
+		OK:         rep.OK,
+		Redirects:  rep.Redirects,
+		DeadHTTP:   rep.DeadHTTP,
+		Errors:     rep.Errors,
+		Warnings:   len(rep.Warnings),
+		Failures:   rep.Failures,
+
+		HealthScore: rep.HealthScore(),
+
+		AuthRequired: rep.AuthRequired,
+		Tolerated:    rep.Tolerated,
+		DepthLimited: len(rep.DepthLimited),
+		Disallowed:   len(rep.RobotsDisallowed),
+		Partial:      rep.Partial,
+
+		NetworkChecks: rep.NetworkChecks,
+		Cached:        rep.Cached,
+
+		LatencyP50MS: rep.Latency.P50.Milliseconds(),
+		LatencyP90MS: rep.Latency.P90.Milliseconds(),
+		LatencyP99MS: rep.Latency.P99.Milliseconds(),
+
+		ByKind: make(map[string]ndjsonKind, len(rep.ByKind)),
+	}
+	if b := rep.DiscoveredBreakdown; b != nil {
+		sum.DiscoveredBy = &ndjsonBreakdown{Pages: b.Pages, Assets: b.Assets, SelfOnly: b.SelfOnly, External: b.External, Skipped: b.Skipped}
+	}
+	if rep.Sitemap != nil {
+		sum.SitemapOrphans, sum.NotInSitemap = rep.Sitemap.Orphans, rep.Sitemap.Unlisted
+	}
+	for kind, c := range rep.ByKind {
+		sum.ByKind[string(kind)] = ndjsonKind{OK: c.OK, Redirects: c.Redirects, DeadHTTP: c.DeadHTTP, Errors: c.Errors, Partial: c.Partial}
+	}
+	if withHosts {
+		for _, st := range rep.HostStats {
+			h := ndjsonHost{
+				Host:         st.Host,
+				OK:           st.OK,
+				Failures:     st.Failures,
+				AvgLatencyMS: st.AvgLatency.Milliseconds(),
+				P95LatencyMS: st.P95Latency.Milliseconds(),
+			}
+			if !st.FirstOK.IsZero() {
+				h.FirstOK, h.LastOK = &st.FirstOK, &st.LastOK
+			}
+			sum.Hosts = append(sum.Hosts, h)
+		}
+	}
+	return sum
+}
+
+// PriorResult is one result record read back from a previous ndjson run.
+type PriorResult struct {
+	URL    string
+	Status int
+	Error  string
+	Dead   bool
+}
+
+// ReadNDJSON reads the result records of an ndjson run, ignoring the
+// summary line.
+func ReadNDJSON(r io.Reader) ([]PriorResult, error) {
+	var out []PriorResult
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var rec ndjsonResult
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("ndjson line %d: %w", line, err)
+		}
+		if rec.Type != "result" {
+			continue
+		}
+		out = append(out, PriorResult{URL: rec.URL, Status: rec.Status, Error: rec.Error, Dead: rec.Dead})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read ndjson: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrchestrator_NDJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/ok">ok</a><a href="/dead">dead</a>`))
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/dead", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{Format: FormatNDJSON})
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != rep.Checked+1 {
+		t.Fatalf("expected %d lines, got %d:\n%s", rep.Checked+1, len(lines), out.String())
+	}
+
+	for i, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line %d is not valid JSON: %v\n%s", i, err, line)
+		}
+		want := "result"
+		if i == len(lines)-1 {
+			want = "summary"
+		}
+		if rec["type"] != want {
+			t.Fatalf("line %d: expected type %q, got %v", i, want, rec["type"])
+		}
+	}
+
+	var sum ndjsonSummary
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &sum); err != nil {
+		t.Fatal(err)
+	}
+	if sum.DeadHTTP != 1 || sum.Checked != 3 {
+		t.Fatalf("unexpected summary %+v", sum)
+	}
+	if k := sum.ByKind["page"]; k.OK != 2 || k.DeadHTTP != 1 {
+		t.Fatalf("unexpected page counts %+v", sum.ByKind)
+	}
+}
+
+func TestOrchestrator_JSON(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close() // links to it fail with a connection error
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/ok">ok</a><a href="/dead">dead</a><a href="`+closed.URL+`/x">down</a>`))
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux) // /dead is a 404
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{Format: FormatJSON, AllowExternal: true})
+	var out bytes.Buffer
+	if _, err := orch.Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// One document and nothing else: no text summary around it.
+	var doc struct {
+		Summary ndjsonSummary `json:"summary"`
+		Results []struct {
+			URL       string   `json:"url"`
+			Status    int      `json:"status"`
+			Error     string   `json:"error"`
+			ElapsedMS *int64   `json:"elapsed_ms"`
+			Depth     *int     `json:"depth"`
+			Sources   []string `json:"sources"`
+		} `json:"results"`
+	}
+	dec := json.NewDecoder(&out)
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("not a JSON document: %v\n%s", err, out.String())
+	}
+	if dec.More() {
+		t.Fatalf("more output after the document:\n%s", out.String())
+	}
+
+	if doc.Summary.Checked != 4 || doc.Summary.DeadHTTP != 1 || doc.Summary.Errors != 1 || len(doc.Results) != 4 {
+		t.Fatalf("unexpected summary %+v with %d results", doc.Summary, len(doc.Results))
+	}
+	byURL := map[string]int{}
+	for i, r := range doc.Results {
+		byURL[r.URL] = i
+		if r.ElapsedMS == nil || r.Depth == nil {
+			t.Fatalf("%s: missing elapsed_ms or depth", r.URL)
+		}
+	}
+	dead := doc.Results[byURL[srv.URL+"/dead"]]
+	// Depth is that of the page the link was first found on. Sources are
+	// sorted; a crawled page also lists itself.
+	if dead.Status != 404 || *dead.Depth != 0 || len(dead.Sources) == 0 || dead.Sources[0] != srv.URL+"/" {
+		t.Fatalf("unexpected record for /dead: %+v", dead)
+	}
+	down := doc.Results[byURL[closed.URL+"/x"]]
+	if down.Status != 0 || down.Error == "" {
+		t.Fatalf("expected an error string for the unreachable link: %+v", down)
+	}
+}
+
+// flushCounter is a buffered writer that counts its flushes.
+type flushCounter struct {
+	bytes.Buffer
+	lines, flushes int
+}
+
+func (f *flushCounter) Flush() error {
+	f.flushes++
+	f.lines = bytes.Count(f.Bytes(), []byte("\n"))
+	return nil
+}
+
+func TestOrchestrator_NDJSONSources(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/dead">dead</a>`))
+	srv := httptest.NewServer(mux) // /dead is a 404
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{Format: FormatNDJSON})
+	var out flushCounter
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// Each result record is flushed as it is written.
+	if out.flushes < rep.Checked || out.lines < rep.Checked {
+		t.Fatalf("expected %d flushed records, got %d flushes of %d lines", rep.Checked, out.flushes, out.lines)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var dead ndjsonResult
+	for _, line := range lines[:len(lines)-1] {
+		var rec ndjsonResult
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Depth == nil {
+			t.Fatalf("%s: missing depth", rec.URL)
+		}
+		if rec.URL == srv.URL+"/dead" {
+			dead = rec
+		}
+	}
+	if dead.Status != 404 || *dead.Depth != 0 || len(dead.Sources) == 0 || dead.Sources[0] != srv.URL+"/" {
+		t.Fatalf("unexpected record for /dead: %+v", dead)
+	}
+	if !strings.Contains(lines[len(lines)-1], `"type":"summary"`) {
+		t.Fatalf("expected the summary last, got %s", lines[len(lines)-1])
+	}
+}
+
+func TestOrchestrator_JSONRedirectChain(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/old">old</a>`))
+	mux.Handle("/old", http.RedirectHandler("/moved", http.StatusMovedPermanently))
+	mux.Handle("/moved", http.RedirectHandler("/new", http.StatusFound))
+	mux.HandleFunc("/new", htmlHandler(`new`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	if _, err := newTestOrchestrator(Config{Format: FormatJSON}).Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var doc struct {
+		Results []map[string]json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("not a JSON document: %v\n%s", err, out.String())
+	}
+	records := map[string]map[string]json.RawMessage{}
+	for _, r := range doc.Results {
+		var u string
+		_ = json.Unmarshal(r["url"], &u)
+		records[u] = r
+	}
+
+	old := records[srv.URL+"/old"]
+	if old == nil {
+		t.Fatalf("no record for /old:\n%s", out.String())
+	}
+	want := `[{"url":"` + srv.URL + `/old","status":301},{"url":"` + srv.URL + `/moved","status":302}]`
+	if got := string(old["redirect_chain"]); got != want {
+		t.Fatalf("redirect_chain: got %s, want %s", got, want)
+	}
+	if got := string(old["final_url"]); got != `"`+srv.URL+`/new"` {
+		t.Fatalf("final_url: got %s", got)
+	}
+	// A link that did not redirect has an empty chain, not null.
+	if got := string(records[srv.URL+"/"]["redirect_chain"]); got != "[]" {
+		t.Fatalf("redirect_chain of the start page: got %s, want []", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/private">private</a>`))
+	mux.HandleFunc("/private", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	rep, err := newTestOrchestrator(Config{AuthAsWarning: true}).Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var out bytes.Buffer
+	if err := WriteJSON(&out, rep, false); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Results []ndjsonResult `json:"results"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range doc.Results {
+		if r.Depth == nil || len(r.Sources) == 0 {
+			t.Fatalf("%s: missing depth or sources, unlike the json output", r.URL)
+		}
+		if r.Status == http.StatusForbidden {
+			found = true
+			if r.Dead {
+				t.Fatalf("auth-required link reported dead: %+v", r)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("no 403 result:\n%s", out.String())
+	}
+}
+
+func TestWriteJSONError(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteJSONError(&out, nil, false, errors.New("start page unreachable")); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Error   string          `json:"error"`
+		Summary json.RawMessage `json:"summary"`
+		Results []ndjsonResult  `json:"results"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if doc.Error != "start page unreachable" || doc.Summary == nil || doc.Results == nil {
+		t.Fatalf("unexpected document:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := WriteJSON(&out, &Report{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), `"error"`) {
+		t.Fatalf("error field without an error:\n%s", out.String())
+	}
+}
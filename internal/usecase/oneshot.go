@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// CheckOne checks rawURL alone, with no crawl and no worker pool, and
+// judges it as a run would: expect-dead patterns and warning downgrades
+// apply. The text format is one line: "OK", "DEAD", "WARN" or "ALIVE"
+// (expected dead), the status, the URL and how long it took, plus the
+// error or final URL when there is one. The other formats write what a
+// run checking just that link would.
+func (o *Orchestrator) CheckOne(ctx context.Context, rawURL string, stdout io.Writer) (*Report, error) {
+	started := time.Now()
+	r := o.checker.Check(ctx, rawURL, "")
+	m := &domain.LinkMeta{URL: r.URL, Kind: domain.LinkKindPage}
+
+	rep := &Report{
+		downgrade: o.downgrade,
+		links:     map[string]*domain.LinkMeta{r.URL: m},
+
+		Discovered:    1,
+		Checked:       1,
+		Results:       []domain.Result{r},
+		SkippedCounts: map[domain.SkipReason]int{},
+		ByKind: map[domain.LinkKind]*KindCounts{
+			domain.LinkKindPage:  {},
+			domain.LinkKindAsset: {},
+		},
+	}
+	down := o.downgrade(r)
+	rep.count(r, m.Kind, down)
+	expected := matchAny(o.expectDead, r.URL)
+	dead := r.IsDead() && down == "" && !expected
+	label := "OK"
+	switch {
+	case expected && r.IsDead():
+		rep.ExpectedDead++
+	case expected:
+		rep.UnexpectedAlive++
+		rep.Failures++
+		label = "ALIVE"
+	case dead:
+		rep.Failures++
+		label = "DEAD"
+	default:
+		rep.Warnings = o.warningsFor(r, m)
+		if len(rep.Warnings) > 0 {
+			label = "WARN"
+		}
+	}
+	if !expected && !dead && len(rep.Warnings) == 0 {
+		rep.Healthy++
+	}
+	if o.strict {
+		rep.Failures += len(rep.Warnings)
+	}
+	var elapsed latencies
+	elapsed.add(r)
+	rep.Latency = elapsed.percentiles()
+
+	switch o.format {
+	case FormatNDJSON:
+		nd := newNDJSONWriter(stdout)
+		nd.downgrade = o.downgrade
+		if err := nd.result(r, m); err != nil {
+			return nil, err
+		}
+		return rep, nd.summary(rep, o.perHostStats)
+	case FormatJSON:
+		js := &jsonReport{}
+		js.add(r, m, down != "")
+		return rep, js.write(stdout, rep, o.perHostStats)
+	case FormatCSV:
+		cw, err := newCSVWriter(stdout, o.columns)
+		if err != nil {
+			return nil, err
+		}
+		if err := cw.row(r, m); err != nil {
+			return nil, err
+		}
+		return rep, cw.flush()
+	case FormatLychee:
+		lyc := newLycheeReport()
+		lyc.add(r, m, dead)
+		return rep, lyc.write(stdout, time.Since(started))
+	}
+
+	if _, err := fmt.Fprintf(stdout, "%-4s %-5s %s (%s)\n", label, codeOrErr(r), r.URL, r.Elapsed.Round(time.Millisecond)); err != nil {
+		return nil, err
+	}
+	switch {
+	case r.Err != nil:
+		_, err := fmt.Fprintf(stdout, "      %v\n", r.Err)
+		return rep, err
+	case r.FinalURL != "" && r.FinalURL != r.URL:
+		_, err := fmt.Fprintf(stdout, "      -> %s\n", r.FinalURL)
+		return rep, err
+	}
+	return rep, nil
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rojanmagar2001/godeadlink/internal/domain"
@@ -23,49 +26,446 @@ type Orchestrator struct {
 	concurrency   int
 	timeout       time.Duration
 	progressEvery time.Duration
+	progress      io.Writer
+	onResult      func(domain.Result)
+
+	expectDead []string
+	strict     bool
+	format     string
+	columns    []string
+
+	sendReferer bool
+	flagEmpty   bool
+	lowMemory   bool
+	httpsAudit  bool
+	verifySRI   bool
+
+	authAsWarning  bool
+	tolerateErrors []string
+
+	warnRedirectHops int
+	perHostStats     bool
+	reportScope      string
+	reportDir        string
+	staleTop         int
+	stopOnFirstDead  bool
+
+	topBrokenSources int
+
+	reportRedirectTargets bool
+	reportRedirectHosts   bool
+
+	// sitemap is the sitemap to audit the crawl against; sitemapAudit
+	// holds the outcome of the last RunSeeds.
+	sitemap      string
+	sitemapAudit *SitemapAudit
+
+	// warmupDelay is how long to wait between crawling and checking.
+	warmupDelay time.Duration
+
+	discoveredBreakdown bool
+
+	// started is when the run began, for FormatLychee's summary.
+	started time.Time
+
+	// minConcurrency, when positive, makes concurrency adaptive: it drops
+	// as low as this while checks keep failing (see governor).
+	minConcurrency int
 }
 
 type Config struct {
 	StartURL      string
 	AllowExternal bool
+	// Concurrency is how many links are checked at once. 0 picks 4 per
+	// distinct host to check, at least 4 and at most max(20, 8 × NumCPU).
 	Concurrency   int
+	Timeout       time.Duration
 	ProgressEvery time.Duration
+
+	// Progress gets a "[crawl]" or "[check]" status line every
+	// ProgressEvery while a run is busy; nil disables progress.
+	Progress io.Writer
+
+	// ResultHandler, if set, gets every check result as soon as it
+	// completes, before the Report is built. Calls come one at a time from
+	// the goroutine running the check, in completion order.
+	ResultHandler func(domain.Result)
+
+	// ExpectDead holds URL patterns ('*' matches any run of characters) for
+	// links that are supposed to be dead. A matching dead link is fine; a
+	// matching live link is a failure.
+	ExpectDead []string
+
+	// Strict promotes every warning (see domain.WarningKind) to a failure.
+	Strict bool
+
+	// Format is FormatText (default), FormatNDJSON, FormatJSON, FormatCSV
+	// or FormatLychee.
+	Format string
+	// Columns picks and orders the FormatCSV columns (see CSVColumns);
+	// empty means all of them.
+	Columns []string
+
+	// SendReferer sends each link's first source page as its Referer.
+	SendReferer bool
+
+	// FlagEmpty warns about assets that answer 2xx with an empty body.
+	FlagEmpty bool
+
+	// LowMemory spools results to a temp file instead of holding them all;
+	// Report.Results is left nil.
+	LowMemory bool
+
+	// TolerateErrors holds patterns ('*' matches any run of characters)
+	// for error messages that are known and accepted: a link failing with
+	// a matching error is a tolerated-error warning, not a dead link. A
+	// pattern matches anywhere in the message.
+	TolerateErrors []string
+
+	// AuthAsWarning counts 401 and 403 answers as auth-required warnings
+	// instead of dead links.
+	AuthAsWarning bool
+
+	// VerifySRI downloads assets that declare Subresource Integrity
+	// metadata and fails those whose content does not match it.
+	VerifySRI bool
+
+	// HTTPSAudit warns about redirect chains that pass through or end at
+	// plain http.
+	HTTPSAudit bool
+
+	// WarnRedirectHops warns about links needing more redirect hops than
+	// this; 0 disables the warning.
+	WarnRedirectHops int
+
+	// PerHostStats prints HostStats in the text report and adds them to
+	// the ndjson summary.
+	PerHostStats bool
+
+	// ReportScope limits which dead links the text report lists:
+	// ScopeAll (default), ScopeInternal or ScopeExternal. The summary
+	// still counts every link.
+	ReportScope string
+
+	// ReportDir, when set, also writes one report file per source page
+	// into this directory, in Format.
+	ReportDir string
+
+	// StopOnFirstDead stops checking at the first dead link that fails the
+	// run; links not checked by then are left out of the report.
+	StopOnFirstDead bool
+
+	// ReportRedirectTargets groups redirected links by their final URL in
+	// Report.RedirectTargets.
+	ReportRedirectTargets bool
+
+	// ReportRedirectHosts lists in Report.RedirectHosts the external hosts
+	// internal links reach only through redirects.
+	ReportRedirectHosts bool
+
+	// AdaptiveConcurrency lowers the number of concurrent checks, down to
+	// MinConcurrency (default 1), while errors and throttling (429, 503)
+	// pile up, and raises it back to Concurrency once checks are healthy.
+	AdaptiveConcurrency bool
+	MinConcurrency      int
+
+	// StaleTop lists this many live links with the oldest Last-Modified
+	// in Report.Stale; 0 disables the list.
+	StaleTop int
+
+	// TopBrokenSources ranks this many pages by the distinct dead links
+	// they link to, in Report.BrokenSources; 0 disables the ranking.
+	TopBrokenSources int
+
+	// Sitemap, when set, is loaded before crawling and compared with what
+	// the crawl found, in Report.Sitemap. A path is resolved against the
+	// first start URL.
+	Sitemap string
+
+	// WarmupDelay pauses between the crawl and the checks, so caches and
+	// freshly deployed backends can warm up before links are judged.
+	// Crawled pages are then checked again rather than judged by what
+	// they answered during the crawl.
+	WarmupDelay time.Duration
+
+	// DiscoveredBreakdown splits the discovered-links count by kind and
+	// by whether the links were checked, in Report.DiscoveredBreakdown.
+	DiscoveredBreakdown bool
+}
+
+// KindCounts is the health of the checked links of one kind; as in
+// Report, 206 Partial Content answers are in Partial, not OK.
+type KindCounts struct {
+	OK        int
+	Redirects int
+	DeadHTTP  int
+	Errors    int
+	Partial   int
 }
 
-func NewOrchestrator(c *Crawler, chk *LinkCheckerService, st ports.Store, allowExternal bool, concurrency int, timeout, progressEvery time.Duration) *Orchestrator {
-	if concurrency <= 0 {
-		concurrency = 20
+// Output formats.
+const (
+	FormatText   = "text"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+	FormatCSV    = "csv"
+	FormatLychee = "lychee"
+)
+
+// Report scopes. A link is internal when its host is one of the start hosts.
+const (
+	ScopeAll      = "all"
+	ScopeInternal = "internal"
+	ScopeExternal = "external"
+)
+
+// Report is the outcome of one orchestrated run.
+type Report struct {
+	StartHosts []string
+	Crawled    int
+	Discovered int
+	Checked    int
+
+	// DiscoveredBreakdown splits Discovered (with DiscoveredBreakdown set).
+	DiscoveredBreakdown *DiscoveredBreakdown
+
+	// Results holds every check result sorted by URL (nil in low-memory mode).
+	Results []domain.Result
+
+	// Skipped lists discovered links that were not checked, sorted by URL;
+	// SkippedCounts tallies them by reason.
+	Skipped       []domain.SkippedLink
+	SkippedCounts map[domain.SkipReason]int
+
+	OK        int
+	Redirects int
+	DeadHTTP  int
+	Errors    int
+
+	// Partial counts 206 Partial Content answers. They are fine, but kept
+	// apart from OK so they are not taken for full 200 responses.
+	Partial int
+
+	// Healthy counts the checked links that are fine: in OK or Redirects,
+	// without a warning and not expected to be dead (see HealthScore).
+	Healthy int
+
+	// AuthRequired counts 401/403 answers when they are treated as
+	// warnings (AuthAsWarning); they are then not in DeadHTTP.
+	AuthRequired int
+	// Tolerated counts errors matching TolerateErrors; they are then not
+	// in Errors.
+	Tolerated int
+
+	// NetworkChecks and Cached split Checked by where the result came
+	// from: the network or a result cache.
+	NetworkChecks int
+	Cached        int
+
+	// ByKind splits the counts above by link kind (page or asset).
+	ByKind map[domain.LinkKind]*KindCounts
+
+	ExpectedDead    int
+	UnexpectedAlive int
+
+	Warnings []domain.Warning
+
+	// Flaky holds results whose retries disagreed with each other.
+	Flaky []domain.Result
+
+	// Duplicates lists links a crawled page repeats (with ReportDuplicates
+	// on the crawler), sorted by page.
+	Duplicates []domain.DuplicateLink
+
+	// ExtractErrors lists crawled pages whose links could not be
+	// extracted, sorted by page.
+	ExtractErrors []domain.PageError
+
+	// DepthLimited lists the internal pages found linked but not crawled
+	// because they lay beyond max-depth, sorted; their own links were
+	// never discovered.
+	DepthLimited []string
+
+	// RobotsDisallowed lists the pages found linked but not crawled because
+	// robots.txt disallows them (with Crawler.RespectRobots), sorted.
+	RobotsDisallowed []string
+
+	// BrokenPages lists crawled pages whose fetch failed or answered
+	// 4xx/5xx and that are not themselves discovered links, sorted by URL
+	// (only with NoSelfLinks on the crawler). Each counts as a failure and
+	// is written as a result in every format. After a warm-up they are
+	// checked again and only those still dead are kept.
+	BrokenPages []domain.Result
+
+	// Stopped is set when the run stopped at its first dead link
+	// (StopOnFirstDead); Checked then counts only the links checked so far.
+	Stopped bool
+
+	// RedirectTargets lists the final URLs redirected links end up at,
+	// most-linked first (with ReportRedirectTargets set).
+	RedirectTargets []RedirectTarget
+
+	// RedirectHosts lists, by name, the external hosts internal links
+	// redirect to that no link points at directly (with
+	// ReportRedirectHosts set).
+	RedirectHosts []RedirectHost
+
+	// Stale holds the live links with the oldest Last-Modified, oldest
+	// first (with StaleTop set).
+	Stale []domain.Result
+
+	// BrokenSources ranks the pages linking to the most dead links, most
+	// first (with TopBrokenSources set).
+	BrokenSources []BrokenSource
+
+	// Sitemap compares the sitemap with the crawl (with Sitemap set).
+	Sitemap *SitemapAudit
+
+	// Latency is the p50/p90/p99 response time of the network checks,
+	// estimated from a sample of them on large runs.
+	Latency Latency
+
+	// HostStats aggregates results per host, sorted by host (with
+	// PerHostStats).
+	HostStats []HostStats
+
+	// Failures counts results that should fail the run: dead links that were
+	// not expected to be dead, plus expected-dead links that are alive, plus
+	// warnings in strict mode.
+	Failures int
+
+	// downgrade names the results the run reported as warnings rather
+	// than dead (see Orchestrator.downgrade), and links holds the checked
+	// links by URL, so WriteJSON matches the json output.
+	downgrade func(domain.Result) domain.WarningKind
+	links     map[string]*domain.LinkMeta
+}
+
+func NewOrchestrator(c *Crawler, chk *LinkCheckerService, st ports.Store, cfg Config) *Orchestrator {
+	if cfg.ProgressEvery <= 0 {
+		cfg.ProgressEvery = time.Second
 	}
-	if progressEvery <= 0 {
-		progressEvery = time.Second
+	minConcurrency := 0
+	if cfg.AdaptiveConcurrency {
+		minConcurrency = max(cfg.MinConcurrency, 1)
 	}
 
 	return &Orchestrator{
 		crawler:       c,
 		checker:       chk,
 		store:         st,
-		allowExternal: allowExternal,
-		concurrency:   concurrency,
-		timeout:       timeout,
-		progressEvery: progressEvery,
+		allowExternal: cfg.AllowExternal,
+		concurrency:   cfg.Concurrency,
+		timeout:       cfg.Timeout,
+		progressEvery: cfg.ProgressEvery,
+		progress:      cfg.Progress,
+		onResult:      cfg.ResultHandler,
+		expectDead:    cfg.ExpectDead,
+		strict:        cfg.Strict,
+		format:        cfg.Format,
+		columns:       cfg.Columns,
+		sendReferer:   cfg.SendReferer,
+		flagEmpty:     cfg.FlagEmpty,
+		lowMemory:     cfg.LowMemory,
+		httpsAudit:    cfg.HTTPSAudit,
+		verifySRI:     cfg.VerifySRI,
+		authAsWarning: cfg.AuthAsWarning,
+
+		tolerateErrors: containsPatterns(cfg.TolerateErrors),
+
+		warnRedirectHops: cfg.WarnRedirectHops,
+		perHostStats:     cfg.PerHostStats,
+		reportScope:      cfg.ReportScope,
+		reportDir:        cfg.ReportDir,
+		staleTop:         cfg.StaleTop,
+		stopOnFirstDead:  cfg.StopOnFirstDead,
+
+		topBrokenSources: cfg.TopBrokenSources,
+
+		reportRedirectTargets: cfg.ReportRedirectTargets,
+		reportRedirectHosts:   cfg.ReportRedirectHosts,
+
+		sitemap:     cfg.Sitemap,
+		warmupDelay: cfg.WarmupDelay,
+
+		discoveredBreakdown: cfg.DiscoveredBreakdown,
+
+		minConcurrency: minConcurrency,
 	}
 }
 
-func (o *Orchestrator) Run(ctx context.Context, startURL string, stdout io.Writer) error {
-	startHost, err := o.crawler.Crawl(ctx, startURL, o.store)
+func (o *Orchestrator) Run(ctx context.Context, startURL string, stdout io.Writer) (*Report, error) {
+	return o.RunSeeds(ctx, []string{startURL}, stdout)
+}
+
+// RunSeeds crawls from several start URLs at once (all at depth 0) and
+// checks what was found. See Crawler.Crawl for the depth semantics.
+func (o *Orchestrator) RunSeeds(ctx context.Context, seeds []string, stdout io.Writer) (*Report, error) {
+	var sitemapURLs []string
+	if o.sitemap != "" {
+		o.sitemap = resolveSitemap(o.sitemap, seeds)
+		var err error
+		if sitemapURLs, err = o.crawler.LoadSitemap(ctx, o.sitemap); err != nil {
+			return nil, err
+		}
+	}
+
+	startHosts, err := o.Crawl(ctx, seeds)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if o.sitemap != "" {
+		o.sitemapAudit = o.auditSitemap(sitemapURLs, seeds)
+	}
+	if o.warmupDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(o.warmupDelay):
+		}
 	}
 
+	return o.checkDiscovered(ctx, startHosts, o.allowExternal, stdout)
+}
+
+// Crawl runs the crawler into the orchestrator's store, reporting progress.
+func (o *Orchestrator) Crawl(ctx context.Context, seeds []string) (startHosts map[string]bool, err error) {
+	o.started = time.Now()
+	stop := startProgress(o.progress, o.progressEvery, func() string {
+		return fmt.Sprintf("[crawl] pages: %d", o.Crawled())
+	})
+	defer stop()
+	return o.crawler.Crawl(ctx, seeds, o.store)
+}
+
+// Crawled returns how many pages were visited, leaving out the pages the
+// crawler excluded up front.
+func (o *Orchestrator) Crawled() int {
+	return o.store.VisitedCount() - o.crawler.Excluded()
+}
+
+// checkDiscovered checks everything in the store and writes the report.
+// Links whose host is not in startHosts are only checked when
+// allowExternal is set.
+func (o *Orchestrator) checkDiscovered(ctx context.Context, startHosts map[string]bool, allowExternal bool, stdout io.Writer) (*Report, error) {
 	discovered := o.store.AllDiscovered()
+	byURL := make(map[string]*domain.LinkMeta, len(discovered))
+	for _, m := range discovered {
+		byURL[m.URL] = m
+	}
 
 	// Decide what to check (skip externals unless allowed; skip skipped entries)
 	toCheck := make([]*domain.LinkMeta, 0, len(discovered))
+	var skipped []domain.SkippedLink
 	skippedCounts := map[domain.SkipReason]int{}
+	skip := func(m *domain.LinkMeta, reason domain.SkipReason) {
+		skipped = append(skipped, domain.SkippedLink{URL: m.URL, Reason: reason, Sources: sources(m)})
+		skippedCounts[reason]++
+	}
 
 	for _, m := range discovered {
 		if m.Skipped != "" {
-			skippedCounts[m.Skipped]++
+			skip(m, m.Skipped)
 			continue
 		}
 
@@ -75,10 +475,10 @@ func (o *Orchestrator) Run(ctx context.Context, startURL string, stdout io.Write
 			continue
 		}
 
-		host := strings.ToLower(u.Hostname())
-		isExternal := host != "" && host != startHost
-		if isExternal && !o.allowExternal {
-			skippedCounts[domain.SkipExternal]++
+		host := u.Hostname()
+		isExternal := host != "" && !startHosts[o.crawler.scopeKey(host)]
+		if isExternal && !allowExternal {
+			skip(m, domain.SkipExternal)
 			continue
 		}
 		toCheck = append(toCheck, m)
@@ -86,73 +486,465 @@ func (o *Orchestrator) Run(ctx context.Context, startURL string, stdout io.Write
 
 	sort.Slice(toCheck, func(i, j int) bool { return toCheck[i].URL < toCheck[j].URL })
 
-	// Worker pool
-	jobs := make(chan *domain.LinkMeta)
-	results := make(chan domain.Result, o.concurrency)
-
-	var wg sync.WaitGroup
-	worker := func() {
-		defer wg.Done()
-		for m := range jobs {
-			results <- o.checker.Check(ctx, m.URL)
+	// Text output is rendered once everything is in; ndjson streams each
+	// result as it arrives and closes with a summary line. JSON is one
+	// document written at the end. CSV is one row per result, in URL order.
+	textOut := stdout
+	var nd *ndjsonWriter
+	var js *jsonReport
+	var cw *csvWriter
+	var lyc *lycheeReport
+	if o.started.IsZero() {
+		o.started = time.Now()
+	}
+	switch o.format {
+	case FormatNDJSON:
+		textOut = io.Discard
+		nd = newNDJSONWriter(stdout)
+		nd.downgrade = o.downgrade
+	case FormatJSON:
+		textOut = io.Discard
+		js = &jsonReport{Results: make([]ndjsonResult, 0, len(toCheck))}
+	case FormatLychee:
+		textOut = io.Discard
+		lyc = newLycheeReport()
+	case FormatCSV:
+		textOut = io.Discard
+		var err error
+		if cw, err = newCSVWriter(stdout, o.columns); err != nil {
+			return nil, err
 		}
 	}
 
-	wg.Add(o.concurrency)
-	for i := 0; i < o.concurrency; i++ {
-		go worker()
+	// Results are kept by their index in toCheck (which is sorted by URL),
+	// either in memory or, in low-memory mode, spooled to a temp file.
+	var all []domain.Result
+	var spool *resultSpool
+	if o.lowMemory {
+		sp, err := newResultSpool(len(toCheck))
+		if err != nil {
+			return nil, err
+		}
+		defer sp.Close()
+		spool = sp
+	} else {
+		all = make([]domain.Result, len(toCheck))
 	}
 
-	go func() {
-		for _, m := range toCheck {
-			jobs <- m
+	var checked atomic.Int64
+	stop := startProgress(o.progress, o.progressEvery, func() string {
+		return fmt.Sprintf("[check] checked: %d/%d", checked.Load(), len(toCheck))
+	})
+	var writeErr error
+	// received marks the results in when a run stops at its first dead
+	// link; the rest were never checked.
+	var received []bool
+	stopped := false
+	if o.stopOnFirstDead {
+		received = make([]bool, len(toCheck))
+	}
+	o.runChecks(ctx, toCheck, func(idx int, r domain.Result) bool {
+		checked.Add(1)
+		if writeErr != nil {
+			return false
 		}
-		close(jobs)
-	}()
+		if spool != nil {
+			writeErr = spool.put(idx, r)
+		} else {
+			all[idx] = r
+		}
+		if nd != nil && writeErr == nil {
+			writeErr = nd.result(r, toCheck[idx])
+		}
+		if o.onResult != nil {
+			o.onResult(r)
+		}
+		if received == nil {
+			return false
+		}
+		received[idx] = true
+		stopped = r.IsDead() && o.downgrade(r) == "" && !matchAny(o.expectDead, r.URL)
+		return stopped
+	})
+	stop()
+	if writeErr != nil {
+		return nil, writeErr
+	}
 
-	// collect
-	all := make([]domain.Result, 0, len(toCheck))
-	for r := range results {
-		all = append(all, r)
+	var breakdown *DiscoveredBreakdown
+	if o.discoveredBreakdown {
+		breakdown = breakdownDiscovered(toCheck, skippedCounts)
 	}
 
-	sort.Slice(all, func(i, j int) bool { return all[i].URL < all[j].URL })
-	for _, r := range all {
-		if r.IsDead() {
-			fmt.Fprintf(stdout, "DEAD %-5s %s\n", codeOrErr(r), r.URL)
+	rep := &Report{
+		downgrade: o.downgrade,
+		links:     byURL,
+
+		StartHosts: sortedKeys(startHosts),
+		Crawled:    o.Crawled(),
+		Discovered: len(discovered),
+		Checked:    int(checked.Load()),
+		Results:    all,
+		Stopped:    stopped,
+
+		DiscoveredBreakdown: breakdown,
+
+		Skipped:       skipped,
+		SkippedCounts: skippedCounts,
+		Duplicates:    o.crawler.Duplicates(),
+		ExtractErrors: o.store.ExtractErrors(),
+		DepthLimited:  o.crawler.DepthLimited(),
+
+		RobotsDisallowed: o.crawler.RobotsDisallowed(),
+		Sitemap:          o.sitemapAudit,
+
+		ByKind: map[domain.LinkKind]*KindCounts{
+			domain.LinkKindPage:  {},
+			domain.LinkKindAsset: {},
+		},
+	}
+
+	var hosts hostStatsSet
+	if o.perHostStats {
+		hosts = hostStatsSet{}
+	}
+	stale := staleSet{top: o.staleTop}
+	var elapsed latencies
+	var targets redirectTargets
+	if o.reportRedirectTargets {
+		targets = redirectTargets{}
+	}
+	var viaRedirect *redirectHosts
+	if o.reportRedirectHosts {
+		viaRedirect = newRedirectHosts(func(host string) bool {
+			return host != "" && !startHosts[o.crawler.scopeKey(host)]
+		})
+	}
+	var deadSources brokenSources
+	if o.topBrokenSources > 0 {
+		deadSources = brokenSources{}
+	}
+	var bySource map[string][]pageEntry
+	if o.reportDir != "" {
+		bySource = map[string][]pageEntry{}
+	}
+	for i := range toCheck {
+		if received != nil && !received[i] {
+			continue
+		}
+		r, err := resultAt(all, spool, i)
+		if err != nil {
+			return nil, err
+		}
+		if cw != nil {
+			if err := cw.row(r, toCheck[i]); err != nil {
+				return nil, err
+			}
+		}
+		down := o.downgrade(r)
+		rep.count(r, toCheck[i].Kind, down)
+		if hosts != nil {
+			hosts.add(r)
+		}
+		stale.add(r)
+		elapsed.add(r)
+		if targets != nil {
+			targets.add(r)
+		}
+		if viaRedirect != nil {
+			viaRedirect.add(r)
+		}
+		if r.IsFlaky() {
+			rep.Flaky = append(rep.Flaky, r)
+		}
+
+		warned := len(rep.Warnings)
+		expected := matchAny(o.expectDead, r.URL)
+		dead := r.IsDead() && down == "" && !expected
+		if bySource != nil {
+			e := pageEntry{r: r, m: toCheck[i], down: down != "", dead: dead}
+			for src := range toCheck[i].Sources {
+				bySource[src] = append(bySource[src], e)
+			}
+		}
+		if lyc != nil {
+			lyc.add(r, toCheck[i], dead)
+		}
+		if js != nil {
+			js.add(r, toCheck[i], down != "")
+		}
+		switch {
+		case expected && r.IsDead():
+			rep.ExpectedDead++
+		case expected:
+			rep.UnexpectedAlive++
+			rep.Failures++
+			fmt.Fprintf(textOut, "ALIVE %-5s %s (expected dead)\n", codeOrErr(r), r.URL)
+			if src := foundOn(byURL[r.URL]); src != "" {
+				fmt.Fprintf(textOut, "       found on : %s\n", src)
+			}
+		case dead:
+			rep.Failures++
+			if deadSources != nil {
+				deadSources.add(toCheck[i])
+			}
+			if !o.inReportScope(r.URL, startHosts) {
+				break
+			}
+			fmt.Fprintf(textOut, "DEAD %-5s %s\n", codeOrErr(r), r.URL)
 			if r.Err != nil {
-				fmt.Fprintf(stdout, "      %v\n", r.Err)
+				fmt.Fprintf(textOut, "      %v\n", r.Err)
+			}
+			if r.Title != "" {
+				fmt.Fprintf(textOut, "     page title : %s\n", r.Title)
+			}
+
+			// Store meta is keyed by the same normalized URL the check used.
+			if src := foundOn(byURL[r.URL]); src != "" {
+				fmt.Fprintf(textOut, "       found on : %s\n", src)
+			}
+		default:
+			for _, w := range o.warningsFor(r, byURL[r.URL]) {
+				rep.Warnings = append(rep.Warnings, w)
+				fmt.Fprintf(textOut, "WARN %s %s\n      %s\n", w.Kind, w.URL, w.Detail)
+			}
+		}
+
+		// Problems with how the link was written apply whatever its status.
+		if w, ok := linkWarning(byURL[r.URL]); ok {
+			rep.Warnings = append(rep.Warnings, w)
+			fmt.Fprintf(textOut, "WARN %s %s\n      %s\n", w.Kind, w.URL, w.Detail)
+		}
+		if !expected && down == "" && !r.IsDead() && len(rep.Warnings) == warned {
+			rep.Healthy++
+		}
+	}
+	for _, r := range o.store.PageResults() {
+		// A page linked from a crawled page was checked as a link above.
+		if byURL[r.URL] != nil || !o.checker.accept(r).IsDead() {
+			continue
+		}
+		// After a warm-up the crawl's answer is stale: the page only counts
+		// when it is still dead.
+		if o.warmupDelay > 0 {
+			if r = o.checker.Check(ctx, r.URL, ""); !r.IsDead() {
+				continue
 			}
+		}
+		rep.BrokenPages = append(rep.BrokenPages, r)
+		rep.Failures++
 
-			// Find sources (store already has meta keyed by normalized URL).
-			// For simplicity, scan discovered list here (O(n)). We'll optimize later if needed.
-			if src := firstSourceFor(discovered, r.URL); src != "" {
-				fmt.Fprintf(stdout, "       found on : %s\n", src)
+		m := &domain.LinkMeta{URL: r.URL, Kind: domain.LinkKindPage}
+		if nd != nil {
+			if err := nd.result(r, m); err != nil {
+				return nil, err
+			}
+		}
+		if js != nil {
+			js.add(r, m, false)
+		}
+		if cw != nil {
+			if err := cw.row(r, m); err != nil {
+				return nil, err
+			}
+		}
+		if lyc != nil {
+			lyc.add(r, m, true)
+		}
+	}
+	for _, w := range o.crawler.Truncated() {
+		rep.Warnings = append(rep.Warnings, w)
+		fmt.Fprintf(textOut, "WARN %s %s\n      %s\n", w.Kind, w.URL, w.Detail)
+	}
+	if o.strict {
+		rep.Failures += len(rep.Warnings)
+	}
+	if hosts != nil {
+		rep.HostStats = hosts.sorted()
+	}
+	rep.Stale = stale.sorted()
+	if deadSources != nil {
+		rep.BrokenSources = deadSources.top(o.topBrokenSources)
+	}
+	rep.Latency = elapsed.percentiles()
+	if targets != nil {
+		rep.RedirectTargets = targets.sorted()
+	}
+	if viaRedirect != nil {
+		linked := map[string]bool{}
+		for _, m := range discovered {
+			if host := hostOf(m.URL); host != "" {
+				linked[host] = true
 			}
 		}
+		rep.RedirectHosts = viaRedirect.sorted(linked)
+	}
+	if received != nil && all != nil {
+		rep.Results = rep.Results[:0]
+		for i, r := range all {
+			if received[i] {
+				rep.Results = append(rep.Results, r)
+			}
+		}
+	}
+	if bySource != nil {
+		if err := o.writeReportDir(bySource); err != nil {
+			return nil, err
+		}
 	}
 
 	// summary
-	ok, redir, deadHTTP, errs := summarize(all)
-	fmt.Fprintf(stdout,
-		"\nCrawled pages: %d (max-pages=%d, max-depth=%d)\nDiscovered links: %d\nChecked links: %d\nOK: %d  Redirects: %d  DeadHTTP: %d  Errors: %d\n",
-		o.store.VisitedCount(), len(discovered), len(toCheck),
-		ok, redir, deadHTTP, errs,
+	discoveredLine := strconv.Itoa(rep.Discovered)
+	if rep.DiscoveredBreakdown != nil {
+		discoveredLine += " (" + rep.DiscoveredBreakdown.String() + ")"
+	}
+	fmt.Fprintf(textOut,
+		"\nCrawled pages: %d (max-pages=%d, max-depth=%d)\nDiscovered links: %s\nChecked links: %d\nOK: %d  Redirects: %d  DeadHTTP: %d  Errors: %d\n",
+		rep.Crawled, o.crawler.maxPages, o.crawler.maxDepth, discoveredLine, rep.Checked,
+		rep.OK, rep.Redirects, rep.DeadHTTP, rep.Errors,
 	)
+	if rep.Partial > 0 {
+		fmt.Fprintf(textOut, "Partial content (206, counted apart from OK): %d\n", rep.Partial)
+	}
+	if rep.Checked > 0 {
+		fmt.Fprintf(textOut, "Health: %.1f%% (%d/%d OK)\n", rep.HealthScore(), rep.Healthy, rep.Checked)
+	}
+	if o.authAsWarning {
+		fmt.Fprintf(textOut, "Auth required: %d\n", rep.AuthRequired)
+	}
+	if len(o.tolerateErrors) > 0 {
+		fmt.Fprintf(textOut, "Tolerated errors: %d\n", rep.Tolerated)
+	}
+	if rep.NetworkChecks > 0 {
+		fmt.Fprintf(textOut, "Latency: p50 %s  p90 %s  p99 %s\n",
+			rep.Latency.P50.Round(time.Millisecond), rep.Latency.P90.Round(time.Millisecond), rep.Latency.P99.Round(time.Millisecond))
+	}
+	if rep.Cached > 0 {
+		fmt.Fprintf(textOut, "Network checks: %d  Cached: %d\n", rep.NetworkChecks, rep.Cached)
+	}
+	if len(rep.DepthLimited) > 0 {
+		fmt.Fprintf(textOut, "Depth-limited (not crawled): %d pages beyond max-depth=%d\n", len(rep.DepthLimited), o.crawler.maxDepth)
+	}
+	if len(rep.RobotsDisallowed) > 0 {
+		fmt.Fprintf(textOut, "Disallowed by robots.txt (not crawled): %d pages\n", len(rep.RobotsDisallowed))
+	}
+	if len(rep.Warnings) > 0 {
+		fmt.Fprintf(textOut, "Warnings: %d\n", len(rep.Warnings))
+	}
+	if len(o.expectDead) > 0 {
+		fmt.Fprintf(textOut, "Expected dead: %d  Unexpectedly alive: %d\n", rep.ExpectedDead, rep.UnexpectedAlive)
+	}
+	if rep.Stopped {
+		fmt.Fprintf(textOut, "Stopped at the first dead link: %d of %d links checked\n", rep.Checked, len(toCheck))
+	}
+	if rep.Checked > 0 {
+		writeByKind(textOut, rep.ByKind)
+	}
+
+	if len(rep.Flaky) > 0 {
+		fmt.Fprintln(textOut, "\nFlaky links (outcome varied across retries):")
+		for _, r := range rep.Flaky {
+			fmt.Fprintf(textOut, "  %s  attempts: %s\n", r.URL, formatAttempts(r.Attempts))
+		}
+	}
+
+	if len(rep.Duplicates) > 0 {
+		fmt.Fprintln(textOut, "\nDuplicate links (same destination linked more than once on a page):")
+		page := ""
+		for _, d := range rep.Duplicates {
+			if d.Page != page {
+				page = d.Page
+				fmt.Fprintf(textOut, "  %s\n", page)
+			}
+			fmt.Fprintf(textOut, "    %s (x%d)\n", d.URL, d.Count)
+		}
+	}
+
+	if len(rep.RedirectTargets) > 0 {
+		writeRedirectTargets(textOut, rep.RedirectTargets)
+	}
+
+	if len(rep.RedirectHosts) > 0 {
+		writeRedirectHosts(textOut, rep.RedirectHosts)
+	}
+
+	if len(rep.Stale) > 0 {
+		writeStale(textOut, rep.Stale)
+	}
+
+	if len(rep.BrokenSources) > 0 {
+		writeBrokenSources(textOut, rep.BrokenSources)
+	}
+
+	if len(rep.ExtractErrors) > 0 {
+		fmt.Fprintln(textOut, "\nPages with extraction errors:")
+		for _, e := range rep.ExtractErrors {
+			fmt.Fprintf(textOut, "  %s: %v\n", e.Page, e.Err)
+		}
+	}
+
+	if rep.Sitemap != nil {
+		writeSitemapAudit(textOut, rep.Sitemap)
+	}
+
+	if len(rep.BrokenPages) > 0 {
+		fmt.Fprintln(textOut, "\nBroken pages (failed when crawled):")
+		for _, r := range rep.BrokenPages {
+			fmt.Fprintf(textOut, "  %-5s %s\n", codeOrErr(r), r.URL)
+			if r.Err != nil {
+				fmt.Fprintf(textOut, "        %v\n", r.Err)
+			}
+		}
+	}
 
 	if len(skippedCounts) > 0 {
-		fmt.Println(stdout, "\nSkipped links:")
+		fmt.Fprintln(textOut, "\nSkipped links:")
 		keys := make([]string, 0, len(skippedCounts))
 		for k := range skippedCounts {
 			keys = append(keys, string(k))
 		}
 		sort.Strings(keys)
 		for _, k := range keys {
-			fmt.Fprintf(stdout, "  %-20s %d\n", k+":", skippedCounts[domain.SkipReason(k)])
+			fmt.Fprintf(textOut, "  %-20s %d\n", k+":", skippedCounts[domain.SkipReason(k)])
+		}
+	}
+
+	if o.perHostStats {
+		writeHostStats(textOut, rep.HostStats)
+	}
+
+	if nd != nil {
+		if err := nd.summary(rep, o.perHostStats); err != nil {
+			return nil, err
 		}
 	}
+	if js != nil {
+		if err := js.write(stdout, rep, o.perHostStats); err != nil {
+			return nil, err
+		}
+	}
+	if cw != nil {
+		if err := cw.flush(); err != nil {
+			return nil, err
+		}
+	}
+	if lyc != nil {
+		lyc.skipped(skippedCounts)
+		if err := lyc.write(stdout, time.Since(o.started)); err != nil {
+			return nil, err
+		}
+	}
+
+	return rep, nil
+}
 
-	return nil
+func sortedKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
 }
 
 func codeOrErr(r domain.Result) string {
@@ -162,31 +954,251 @@ func codeOrErr(r domain.Result) string {
 	return fmt.Sprintf("%d", r.StatusCode)
 }
 
-func summarize(all []domain.Result) (ok, redir, deadHTTP, errs int) {
-	for _, r := range all {
-		if r.Err != nil {
-			errs++
+func formatAttempts(attempts []domain.Attempt) string {
+	parts := make([]string, 0, len(attempts))
+	for _, a := range attempts {
+		if a.Err != "" {
+			parts = append(parts, "ERR")
 			continue
 		}
-		switch {
-		case r.StatusCode >= 200 && r.StatusCode <= 299:
-			ok++
-		case r.StatusCode >= 300 && r.StatusCode <= 399:
-			redir++
-		case r.StatusCode >= 400:
-			deadHTTP++
-		}
+		parts = append(parts, fmt.Sprintf("%d", a.StatusCode))
 	}
-	return
+	return strings.Join(parts, ", ")
 }
 
-func firstSourceFor(discovered []*domain.LinkMeta, url string) string {
-	for _, m := range discovered {
-		if m.URL == url {
-			for s := range m.Sources {
-				return s
+// pageResult returns the crawler's fetch result for a crawled page link
+// when it can stand for checking the page, which saves fetching the page
+// a second time. After a warm-up the crawl's answers are stale, so every
+// page is checked afresh.
+func (o *Orchestrator) pageResult(m *domain.LinkMeta) (domain.Result, bool) {
+	if m.Kind != domain.LinkKindPage || o.warmupDelay > 0 {
+		return domain.Result{}, false
+	}
+	res, ok := o.store.PageResult(m.URL)
+	if !ok || !o.checker.Reuses(res) {
+		return domain.Result{}, false
+	}
+	return o.checker.accept(res), true
+}
+
+// runChecks checks toCheck on the worker pool, passing each result to
+// onResult as it completes. Once onResult returns true no more links are
+// started, checks in flight are cancelled, and their results are dropped.
+func (o *Orchestrator) runChecks(ctx context.Context, toCheck []*domain.LinkMeta, onResult func(idx int, r domain.Result) (stop bool)) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		idx  int
+		meta *domain.LinkMeta
+	}
+	type done struct {
+		idx int
+		res domain.Result
+	}
+
+	workers := o.checkConcurrency(toCheck)
+	jobs := make(chan job)
+	results := make(chan done, workers)
+
+	var gov *governor
+	if o.minConcurrency > 0 {
+		gov = newGovernor(o.minConcurrency, workers)
+	}
+
+	var wg sync.WaitGroup
+	worker := func() {
+		defer wg.Done()
+		for j := range jobs {
+			if res, ok := o.pageResult(j.meta); ok {
+				results <- done{idx: j.idx, res: res}
+				continue
 			}
+			gov.acquire()
+			referer := o.refererFor(j.meta)
+			res := o.checker.Check(ctx, j.meta.URL, referer)
+			if o.verifySRI && j.meta.Integrity != "" && !res.IsDead() {
+				res = o.checker.VerifyIntegrity(ctx, res, j.meta.Integrity, referer)
+			}
+			gov.observe(res)
+			gov.release()
+			results <- done{idx: j.idx, res: res}
+		}
+	}
+
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go worker()
+	}
+
+	stopc := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		for i, m := range toCheck {
+			select {
+			case jobs <- job{idx: i, meta: m}:
+			case <-stopc:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	stopped := false
+	for d := range results {
+		if !stopped && onResult(d.idx, d.res) {
+			stopped = true
+			close(stopc)
+			cancel()
+		}
+	}
+}
+
+func resultAt(all []domain.Result, spool *resultSpool, idx int) (domain.Result, error) {
+	if spool != nil {
+		return spool.get(idx)
+	}
+	return all[idx], nil
+}
+
+// HealthScore is the percentage of checked links that are healthy (see
+// Report.Healthy): dead links, network errors and links with a warning,
+// including downgraded ones, all count against it. A run that checked
+// nothing scores 100.
+func (rep *Report) HealthScore() float64 {
+	if rep.Checked == 0 {
+		return 100
+	}
+	return 100 * float64(rep.Healthy) / float64(rep.Checked)
+}
+
+// count adds r to the totals and to its kind's counts. Results downgraded
+// to a warning (see Orchestrator.downgrade) only go to AuthRequired or
+// Tolerated.
+func (rep *Report) count(r domain.Result, kind domain.LinkKind, down domain.WarningKind) {
+	byKind, ok := rep.ByKind[kind]
+	if !ok {
+		byKind = &KindCounts{}
+		rep.ByKind[kind] = byKind
+	}
+	if r.FromCache {
+		rep.Cached++
+	} else {
+		rep.NetworkChecks++
+	}
+
+	switch down {
+	case domain.WarnAuthRequired:
+		rep.AuthRequired++
+		return
+	case domain.WarnToleratedError:
+		rep.Tolerated++
+		return
+	}
+	if r.Err != nil {
+		rep.Errors++
+		byKind.Errors++
+		return
+	}
+	switch {
+	case r.StatusCode == http.StatusPartialContent:
+		rep.Partial++
+		byKind.Partial++
+	case r.Accepted:
+		rep.OK++
+		byKind.OK++
+	// 101 is a completed WebSocket handshake.
+	case r.StatusCode == http.StatusSwitchingProtocols, r.StatusCode >= 200 && r.StatusCode <= 299:
+		rep.OK++
+		byKind.OK++
+	case r.StatusCode >= 300 && r.StatusCode <= 399:
+		rep.Redirects++
+		byKind.Redirects++
+	case r.StatusCode >= 400:
+		rep.DeadHTTP++
+		byKind.DeadHTTP++
+	}
+}
+
+// writeByKind prints the per-kind counts: pages, assets, then any other
+// kind by name.
+func writeByKind(w io.Writer, byKind map[domain.LinkKind]*KindCounts) {
+	kinds := []string{string(domain.LinkKindPage), string(domain.LinkKindAsset)}
+	var other []string
+	for k := range byKind {
+		if k != domain.LinkKindPage && k != domain.LinkKindAsset {
+			other = append(other, string(k))
 		}
 	}
-	return ""
+	sort.Strings(other)
+	kinds = append(kinds, other...)
+
+	fmt.Fprintf(w, "\nBy link kind:\n  %-6s %6s %10s %9s %7s\n", "kind", "ok", "redirects", "dead_http", "errors")
+	for _, k := range kinds {
+		c := byKind[domain.LinkKind(k)]
+		fmt.Fprintf(w, "  %-6s %6d %10d %9d %7d\n", k, c.OK, c.Redirects, c.DeadHTTP, c.Errors)
+	}
+}
+
+// firstSource picks the lexically smallest source so output is stable.
+func firstSource(m *domain.LinkMeta) string {
+	if m == nil {
+		return ""
+	}
+	first := ""
+	for s := range m.Sources {
+		if first == "" || s < first {
+			first = s
+		}
+	}
+	return first
+}
+
+// foundOn is firstSource, followed by how many pages link m when the
+// store kept fewer sources than that.
+func foundOn(m *domain.LinkMeta) string {
+	src := firstSource(m)
+	if src != "" && m.SourceCount > len(m.Sources) {
+		src += fmt.Sprintf(" (and %d total occurrences)", m.SourceCount)
+	}
+	return src
+}
+
+// sources returns the pages m was found on, sorted.
+func sources(m *domain.LinkMeta) []string {
+	out := make([]string, 0, len(m.Sources))
+	for s := range m.Sources {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// inReportScope reports whether a dead link to rawURL should be listed.
+func (o *Orchestrator) inReportScope(rawURL string, startHosts map[string]bool) bool {
+	if o.reportScope == "" || o.reportScope == ScopeAll {
+		return true
+	}
+	internal := true
+	if u, err := url.Parse(rawURL); err == nil {
+		host := u.Hostname()
+		internal = host == "" || startHosts[o.crawler.scopeKey(host)]
+	}
+	return internal == (o.reportScope == ScopeInternal)
+}
+
+func (o *Orchestrator) refererFor(m *domain.LinkMeta) string {
+	if !o.sendReferer {
+		return ""
+	}
+	src := firstSource(m)
+	// Sources can be file paths when checking local files.
+	if u, err := url.Parse(src); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return src
 }
@@ -0,0 +1,1474 @@
+package usecase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/extractor"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/httpclient"
+	"github.com/rojanmagar2001/godeadlink/internal/infra/store"
+	"github.com/rojanmagar2001/godeadlink/internal/ports"
+)
+
+// noLimit lets every request through immediately.
+type noLimit struct{}
+
+func (noLimit) Take(context.Context, string) error { return nil }
+
+func newTestOrchestrator(cfg Config) *Orchestrator {
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	return NewOrchestrator(crawler, checker, store.NewMemory(), cfg)
+}
+
+func htmlHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(body))
+	}
+}
+
+func TestOrchestrator_ExpectDead(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/gone">gone</a><a href="/back">back</a>`))
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/back", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	t.Run("dead link expected dead passes", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{ExpectDead: []string{"*/gone"}})
+
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if rep.Failures != 0 || rep.ExpectedDead != 1 {
+			t.Fatalf("expected no failures and 1 expected dead, got %+v", rep)
+		}
+		if strings.Contains(out.String(), "DEAD") {
+			t.Fatalf("expected-dead link should not be reported dead:\n%s", out.String())
+		}
+	})
+
+	t.Run("live link expected dead fails", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{ExpectDead: []string{"*/gone", srv.URL + "/back"}})
+
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if rep.Failures != 1 || rep.UnexpectedAlive != 1 {
+			t.Fatalf("expected 1 unexpectedly alive failure, got %+v", rep)
+		}
+		if !strings.Contains(out.String(), "ALIVE 200   "+srv.URL+"/back (expected dead)") {
+			t.Fatalf("missing ALIVE line:\n%s", out.String())
+		}
+	})
+}
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"https://example.com/old", "https://example.com/old", true},
+		{"*/old", "https://example.com/old", true},
+		{"https://*.example.com/*", "https://docs.example.com/a/b", true},
+		{"*/old", "https://example.com/older", false},
+		{"*old*", "https://example.com/a/old/b", true},
+		{"https://example.com/", "https://example.com/x", false},
+	}
+	for _, c := range cases {
+		if got := matchGlob(c.pattern, c.s); got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.s, got, c.want)
+		}
+	}
+}
+
+func TestOrchestrator_ReportsFlakyLinks(t *testing.T) {
+	var calls int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/flap">flap</a><a href="/down">down</a>`))
+	mux.HandleFunc("/flap", func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		if calls%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	// max-depth 0 so only the checker touches /flap.
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, Retries: 2, RetryBackoff: time.Millisecond}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{Concurrency: 1})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(rep.Flaky) != 1 || rep.Flaky[0].URL != srv.URL+"/flap" {
+		t.Fatalf("expected only /flap to be flaky, got %+v", rep.Flaky)
+	}
+	if !strings.Contains(out.String(), srv.URL+"/flap  attempts: 503, 200") {
+		t.Fatalf("missing flaky section:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_SendReferer(t *testing.T) {
+	var srvURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<img src="/img.png">`))
+	mux.HandleFunc("/img.png", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Referer(), srvURL) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	srvURL = srv.URL
+
+	for _, send := range []bool{false, true} {
+		orch := newTestOrchestrator(Config{SendReferer: send})
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+
+		wantFailures := 1
+		if send {
+			wantFailures = 0
+		}
+		if rep.Failures != wantFailures {
+			t.Fatalf("send-referer=%v: expected %d failures, got %d\n%s", send, wantFailures, rep.Failures, out.String())
+		}
+	}
+}
+
+func TestOrchestrator_ReportsSkippedLinks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/ok">ok</a><a href="mailto:me@example.com">mail</a>`+
+		`<a href="https://external.test/x">ext</a><a href="#top">top</a>`))
+	mux.HandleFunc("/ok", htmlHandler(`<a href="https://external.test/x">ext</a>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{})
+	rep, err := orch.Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := []domain.SkippedLink{
+		{URL: "#top", Reason: domain.SkipFragmentOnly, Sources: []string{srv.URL + "/"}},
+		{URL: "https://external.test/x", Reason: domain.SkipExternal, Sources: []string{srv.URL + "/", srv.URL + "/ok"}},
+		{URL: "mailto:me@example.com", Reason: domain.SkipUnsupportedScheme, Sources: []string{srv.URL + "/"}},
+	}
+	if !reflect.DeepEqual(rep.Skipped, want) {
+		t.Fatalf("skipped links:\n got %+v\nwant %+v", rep.Skipped, want)
+	}
+	if rep.SkippedCounts[domain.SkipExternal] != 1 || len(rep.SkippedCounts) != 3 {
+		t.Fatalf("unexpected skipped counts %v", rep.SkippedCounts)
+	}
+}
+
+// stubSchemeChecker answers every link with status.
+type stubSchemeChecker struct {
+	status  int
+	checked []string
+}
+
+func (s *stubSchemeChecker) Check(_ context.Context, rawURL string) domain.Result {
+	s.checked = append(s.checked, rawURL)
+	return domain.Result{URL: rawURL, StatusCode: s.status}
+}
+
+func TestOrchestrator_ChecksNonHTTPSchemes(t *testing.T) {
+	srv := httptest.NewServer(htmlHandler(`<a href="ftp://127.0.0.1/pub/file.txt">ftp</a>`))
+	defer srv.Close()
+
+	ftp := &stubSchemeChecker{status: 550}
+	timeout := 2 * time.Second
+	ext := extractor.New(extractor.WithSchemes([]string{"http", "https", "ftp"}))
+	crawler := NewCrawler(httpclient.New(timeout), ext, noLimit{}, "test-bot", timeout, 2, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{
+		Timeout:        timeout,
+		HeadFirst:      true,
+		SchemeCheckers: map[string]ports.SchemeChecker{"ftp": ftp},
+	}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(ftp.checked) != 1 || ftp.checked[0] != "ftp://127.0.0.1/pub/file.txt" {
+		t.Fatalf("expected the ftp link to go to the ftp checker, got %v", ftp.checked)
+	}
+	if rep.Checked != 2 || rep.Failures != 1 {
+		t.Fatalf("expected 2 checked and 1 failure, got %+v", rep)
+	}
+	if !strings.Contains(out.String(), "DEAD 550   ftp://127.0.0.1/pub/file.txt") {
+		t.Fatalf("missing DEAD line for the ftp link:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_CountsByKind(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/ok">ok</a><a href="/gone">gone</a>`+
+		`<img src="/a.png"><img src="/missing.png"><script src="/missing.js"></script>`))
+	mux.HandleFunc("/ok", htmlHandler(`ok`))
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux) // anything else is a 404
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{})
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// The start page and /ok are pages too.
+	want := map[domain.LinkKind]KindCounts{
+		domain.LinkKindPage:  {OK: 2, DeadHTTP: 1},
+		domain.LinkKindAsset: {OK: 1, DeadHTTP: 2},
+	}
+	for kind, w := range want {
+		if got := rep.ByKind[kind]; got == nil || *got != w {
+			t.Fatalf("%s: expected %+v, got %+v", kind, w, got)
+		}
+	}
+	for _, line := range []string{
+		"  page        2          0         1       0\n",
+		"  asset       1          0         2       0\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Fatalf("missing by-kind row %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestOrchestrator_ReportScope(t *testing.T) {
+	ext := http.NewServeMux()
+	ext.HandleFunc("/{$}", htmlHandler(`external`))
+	extSrv := httptest.NewServer(ext) // anything else is a 404
+	defer extSrv.Close()
+	// Same server, different host name: "localhost" is not the start host.
+	external := strings.Replace(extSrv.URL, "127.0.0.1", "localhost", 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/gone">gone</a>`+
+		`<a href="`+external+`/">ext</a><a href="`+external+`/missing">missing</a>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	internalLine := "DEAD 404   " + srv.URL + "/gone"
+	externalLine := "DEAD 404   " + external + "/missing"
+
+	for _, tc := range []struct {
+		scope      string
+		wantListed []string
+		wantHidden []string
+	}{
+		{ScopeAll, []string{internalLine, externalLine}, nil},
+		{ScopeInternal, []string{internalLine}, []string{externalLine}},
+		{ScopeExternal, []string{externalLine}, []string{internalLine}},
+	} {
+		t.Run(tc.scope, func(t *testing.T) {
+			orch := newTestOrchestrator(Config{AllowExternal: true, ReportScope: tc.scope})
+			var out bytes.Buffer
+			rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if rep.DeadHTTP != 2 || rep.Failures != 2 {
+				t.Fatalf("every dead link should still be counted, got %+v", rep)
+			}
+			for _, line := range tc.wantListed {
+				if !strings.Contains(out.String(), line) {
+					t.Fatalf("missing %q:\n%s", line, out.String())
+				}
+			}
+			for _, line := range tc.wantHidden {
+				if strings.Contains(out.String(), line) {
+					t.Fatalf("%q is out of scope:\n%s", line, out.String())
+				}
+			}
+		})
+	}
+}
+
+// mapCache is an in-memory ports.ResultCache.
+type mapCache struct {
+	mu sync.Mutex
+	m  map[string]domain.Result
+}
+
+func (c *mapCache) Get(url string) (domain.Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.m[url]
+	return r, ok
+}
+
+func (c *mapCache) Put(url string, r domain.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[url] = r
+}
+
+func TestOrchestrator_CachedChecks(t *testing.T) {
+	var hits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cache := &mapCache{m: map[string]domain.Result{}}
+	newOrch := func(cfg Config) *Orchestrator {
+		timeout := 2 * time.Second
+		crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+		checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true, Cache: cache}, noLimit{})
+		return NewOrchestrator(crawler, checker, store.NewMemory(), cfg)
+	}
+	urls := []string{srv.URL + "/ok", srv.URL + "/gone"}
+
+	rep, err := newOrch(Config{}).RunURLs(context.Background(), urls, io.Discard)
+	if err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+	if rep.NetworkChecks != 2 || rep.Cached != 0 || hits.Load() != 2 {
+		t.Fatalf("first run should hit the network, got %+v (%d hits)", rep, hits.Load())
+	}
+
+	var out bytes.Buffer
+	rep, err = newOrch(Config{Format: FormatNDJSON}).RunURLs(context.Background(), urls, &out)
+	if err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if rep.NetworkChecks != 0 || rep.Cached != 2 || hits.Load() != 2 {
+		t.Fatalf("second run should be served from cache, got %+v (%d hits)", rep, hits.Load())
+	}
+	for _, r := range rep.Results {
+		if !r.FromCache {
+			t.Fatalf("%s: expected FromCache", r.URL)
+		}
+	}
+	if rep.DeadHTTP != 1 || rep.Failures != 1 {
+		t.Fatalf("cached results should still count, got %+v", rep)
+	}
+	if got := strings.Count(out.String(), `"cached":true`); got != 2 {
+		t.Fatalf("expected 2 cached result records, got %d:\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), `"network_checks":0,"cached_checks":2`) {
+		t.Fatalf("summary should report cache counts:\n%s", out.String())
+	}
+
+	out.Reset()
+	if _, err := newOrch(Config{}).RunURLs(context.Background(), urls, &out); err != nil {
+		t.Fatalf("third run: %v", err)
+	}
+	if !strings.Contains(out.String(), "Network checks: 0  Cached: 2\n") {
+		t.Fatalf("missing cache summary line:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_VerifySRI(t *testing.T) {
+	js := []byte("console.log('hi')")
+	sum := sha512.Sum384(js)
+	good := "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+	bad := "sha384-" + base64.StdEncoding.EncodeToString(make([]byte, sha512.Size384))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<script src="/good.js" integrity="`+good+`"></script>`+
+		`<script src="/tampered.js" integrity="`+bad+`"></script><script src="/plain.js"></script>`))
+	for _, p := range []string{"/good.js", "/tampered.js", "/plain.js"} {
+		mux.HandleFunc(p, func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write(js) })
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, verify := range []bool{false, true} {
+		orch := newTestOrchestrator(Config{VerifySRI: verify})
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("verify=%v: run: %v", verify, err)
+		}
+
+		wantFailures := 0
+		if verify {
+			wantFailures = 1
+		}
+		if rep.Failures != wantFailures {
+			t.Fatalf("verify=%v: expected %d failures, got %+v\n%s", verify, wantFailures, rep, out.String())
+		}
+		if verify && !strings.Contains(out.String(), "DEAD ERR   "+srv.URL+"/tampered.js\n      integrity mismatch") {
+			t.Fatalf("missing integrity failure:\n%s", out.String())
+		}
+	}
+}
+
+func TestOrchestrator_AuthAsWarning(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/members">members</a><a href="/admin">admin</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/members", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	mux.HandleFunc("/admin", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	tests := []struct {
+		name                     string
+		cfg                      Config
+		deadHTTP, auth, failures int
+		warnings                 int
+	}{
+		{"off", Config{}, 3, 0, 3, 0},
+		{"on", Config{AuthAsWarning: true}, 1, 2, 1, 2},
+		{"on and strict", Config{AuthAsWarning: true, Strict: true}, 1, 2, 3, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			rep, err := newTestOrchestrator(tt.cfg).Run(context.Background(), srv.URL+"/", &out)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if rep.DeadHTTP != tt.deadHTTP || rep.AuthRequired != tt.auth || rep.Failures != tt.failures || len(rep.Warnings) != tt.warnings {
+				t.Fatalf("got dead_http=%d auth=%d failures=%d warnings=%d\n%s",
+					rep.DeadHTTP, rep.AuthRequired, rep.Failures, len(rep.Warnings), out.String())
+			}
+			if tt.cfg.AuthAsWarning {
+				for _, want := range []string{
+					"WARN auth_required " + srv.URL + "/members\n      401 Unauthorized",
+					"WARN auth_required " + srv.URL + "/admin\n      403 Forbidden",
+					"Auth required: 2\n",
+				} {
+					if !strings.Contains(out.String(), want) {
+						t.Fatalf("missing %q:\n%s", want, out.String())
+					}
+				}
+				if strings.Contains(out.String(), "DEAD 401") || strings.Contains(out.String(), "DEAD 403") {
+					t.Fatalf("auth-required links should not be reported dead:\n%s", out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_HealthScore(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/b">b</a><a href="/moved">moved</a><a href="/members">members</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/a", htmlHandler(`a`))
+	mux.HandleFunc("/b", htmlHandler(`b`))
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/a", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/members", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	// Healthy: /, /a, /b and the redirect. Not: the 401 (a warning) and
+	// the 404.
+	var out bytes.Buffer
+	rep, err := newTestOrchestrator(Config{AuthAsWarning: true}).Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.Checked != 6 || rep.Healthy != 4 {
+		t.Fatalf("got checked=%d healthy=%d\n%s", rep.Checked, rep.Healthy, out.String())
+	}
+	if got, want := rep.HealthScore(), 100*4/6.0; got != want {
+		t.Fatalf("HealthScore = %v, want %v", got, want)
+	}
+	if !strings.Contains(out.String(), "Health: 66.7% (4/6 OK)\n") {
+		t.Fatalf("missing health line:\n%s", out.String())
+	}
+
+	out.Reset()
+	if _, err := newTestOrchestrator(Config{AuthAsWarning: true, Format: FormatNDJSON}).Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var sum struct {
+		HealthScore float64 `json:"health_score"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &sum); err != nil {
+		t.Fatal(err)
+	}
+	if sum.HealthScore != 100*4/6.0 {
+		t.Fatalf("health_score = %v\n%s", sum.HealthScore, out.String())
+	}
+
+	if score := (&Report{}).HealthScore(); score != 100 {
+		t.Fatalf("empty report scores %v, want 100", score)
+	}
+}
+
+func TestOrchestrator_FoundOnCappedSources(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/b">b</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/a", htmlHandler(`<a href="/gone">gone</a>`))
+	mux.HandleFunc("/b", htmlHandler(`<a href="/gone">gone</a>`))
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 1, 50, true, false)
+	crawler.NoSelfLinks = true // count the three linking pages only
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(store.WithMaxSources(1)), Config{})
+
+	var out bytes.Buffer
+	if _, err := orch.Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	want := "DEAD 404   " + srv.URL + "/gone\n       found on : " + srv.URL + "/ (and 3 total occurrences)\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("missing %q:\n%s", want, out.String())
+	}
+}
+
+func TestOrchestrator_ReportDuplicates(t *testing.T) {
+	var xHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/x">x</a><p><a href="/x#more">x again</a><a href="/y">y</a>`))
+	mux.HandleFunc("/x", func(w http.ResponseWriter, r *http.Request) {
+		xHits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/y", htmlHandler(`<a href="/x">x</a>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+	crawler.ReportDuplicates = true
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// /y links /x once, so only the start page is reported.
+	want := []domain.DuplicateLink{{Page: srv.URL + "/", URL: srv.URL + "/x", Count: 2}}
+	if !reflect.DeepEqual(rep.Duplicates, want) {
+		t.Fatalf("duplicates: got %+v, want %+v", rep.Duplicates, want)
+	}
+	if !strings.Contains(out.String(), "\n  "+srv.URL+"/\n    "+srv.URL+"/x (x2)\n") {
+		t.Fatalf("missing duplicate report:\n%s", out.String())
+	}
+	// /x is crawled, and its crawl fetch stands for its check.
+	if n := xHits.Load(); n != 1 {
+		t.Fatalf("/x should be fetched once, got %d requests", n)
+	}
+}
+
+func TestOrchestrator_ExtractErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/broken">broken</a><a href="/empty">empty</a>`))
+	mux.HandleFunc("/empty", htmlHandler(`no links here`))
+	// The body is cut short of its Content-Length, so reading it fails.
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Length", "1000")
+		_, _ = io.WriteString(w, `<a href="/lost">lost</a><p>trunc`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rep, err := newTestOrchestrator(Config{}).Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// /empty parsed fine and has no links; only /broken is reported.
+	if len(rep.ExtractErrors) != 1 || rep.ExtractErrors[0].Page != srv.URL+"/broken" {
+		t.Fatalf("extract errors: got %+v", rep.ExtractErrors)
+	}
+	text := out.String()
+	if !strings.Contains(text, "\nPages with extraction errors:\n  "+srv.URL+"/broken: ") {
+		t.Fatalf("missing extraction error section:\n%s", text)
+	}
+	if strings.Contains(text, srv.URL+"/empty: ") {
+		t.Fatalf("clean page reported as an extraction error:\n%s", text)
+	}
+}
+
+func TestOrchestrator_CrawlCSS(t *testing.T) {
+	css := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/css")
+			_, _ = io.WriteString(w, body)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<link rel="stylesheet" href="/css/site.css">`))
+	mux.HandleFunc("/css/site.css", css(`@import "more.css"; body { background: url(../img/ok.png) }`))
+	mux.HandleFunc("/css/more.css", css(`.hero { background-image: url("/img/missing.png") }`))
+	mux.HandleFunc("/img/ok.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	run := func(crawlCSS bool, depth int) (*Report, string) {
+		t.Helper()
+		timeout := 2 * time.Second
+		ext := extractor.New()
+		crawler := NewCrawler(httpclient.New(timeout), ext, noLimit{}, "test-bot", timeout, 0, 50, true, false)
+		if crawlCSS {
+			crawler.CSS = ext
+			crawler.CSSDepth = depth
+		}
+		checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+		orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{})
+
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return rep, out.String()
+	}
+
+	// Without CSS crawling only the page and its stylesheet are checked.
+	if rep, _ := run(false, 0); rep.Checked != 2 || rep.Failures != 0 {
+		t.Fatalf("default: checked %d, failures %d; want 2 and 0", rep.Checked, rep.Failures)
+	}
+
+	// One level: site.css is read, the stylesheet it imports is only checked.
+	if rep, _ := run(true, 1); rep.Checked != 4 || rep.Failures != 0 {
+		t.Fatalf("depth 1: checked %d, failures %d; want 4 and 0", rep.Checked, rep.Failures)
+	}
+
+	rep, text := run(true, 2)
+	if rep.Checked != 5 || rep.DeadHTTP != 1 {
+		t.Fatalf("depth 2: checked %d, dead %d; want 5 and 1", rep.Checked, rep.DeadHTTP)
+	}
+	want := "DEAD 404   " + srv.URL + "/img/missing.png\n       found on : " + srv.URL + "/css/more.css\n"
+	if !strings.Contains(text, want) {
+		t.Fatalf("missing dead image:\n%s", text)
+	}
+}
+
+func TestOrchestrator_CrawlCSSByContentType(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<link rel="stylesheet" href="/theme?v=3"><link rel="stylesheet" href="/styles"><img src="/logo">`))
+	mux.HandleFunc("/theme", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+		_, _ = io.WriteString(w, `body { background: url(/img/missing.png) }`)
+	})
+	mux.HandleFunc("/styles", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = io.WriteString(w, `@font-face { src: url(/fonts/gone.woff2) }`)
+	})
+	// Not a stylesheet, so it is never fetched as one.
+	var logoGets atomic.Int32
+	mux.HandleFunc("/logo", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			logoGets.Add(1)
+		}
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = io.WriteString(w, `url(/img/not-a-link.png)`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	ext := extractor.New()
+	crawler := NewCrawler(httpclient.New(timeout), ext, noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	crawler.CSS = ext
+	crawler.CSSDepth = 1
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	text := out.String()
+	if rep.Checked != 6 || rep.DeadHTTP != 2 {
+		t.Fatalf("checked %d, dead %d; want 6 and 2:\n%s", rep.Checked, rep.DeadHTTP, text)
+	}
+	for _, want := range []string{
+		"found on : " + srv.URL + "/theme?v=3\n",
+		"found on : " + srv.URL + "/styles\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "not-a-link") || logoGets.Load() != 0 {
+		t.Fatalf("non-stylesheet asset was fetched as CSS (%d GETs):\n%s", logoGets.Load(), text)
+	}
+}
+
+func TestOrchestrator_CrawledPagesFetchedOnce(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	count := func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[r.URL.Path]++
+			mu.Unlock()
+			h(w, r)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", count(htmlHandler(`<a href="/a">a</a><a href="/b">b</a>`)))
+	mux.HandleFunc("/a", count(htmlHandler(`<a href="/">home</a><a href="/b">b</a>`)))
+	mux.HandleFunc("/b", count(htmlHandler(`<a href="/a">a</a>`)))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	rep, err := newTestOrchestrator(Config{}).Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.Checked != 3 || rep.OK != 3 {
+		t.Fatalf("checked %d, ok %d; want 3 and 3", rep.Checked, rep.OK)
+	}
+	// Crawl and check together fetch each page once.
+	want := map[string]int{"/": 1, "/a": 1, "/b": 1}
+	if !reflect.DeepEqual(hits, want) {
+		t.Fatalf("requests per page: got %v, want %v", hits, want)
+	}
+}
+
+func TestOrchestrator_CrawledPageNotFetchedAgain(t *testing.T) {
+	var downHits atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/down">down</a>`))
+	mux.HandleFunc("/down", func(w http.ResponseWriter, _ *http.Request) {
+		downHits.Add(1)
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.WriteString(w, `<title>Server Error</title>`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 1, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	st := store.NewMemory()
+	orch := NewOrchestrator(crawler, checker, st, Config{})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if n := downHits.Load(); n != 1 {
+		t.Fatalf("/down fetched %d times, want 1 (the crawl)", n)
+	}
+	if res, ok := st.PageResult(srv.URL + "/down"); !ok || res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("page result: got %+v, %v", res, ok)
+	}
+	if rep.DeadHTTP != 1 || rep.Failures != 1 {
+		t.Fatalf("dead %d, failures %d; want 1 and 1", rep.DeadHTTP, rep.Failures)
+	}
+	text := out.String()
+	if !strings.Contains(text, "DEAD 500   "+srv.URL+"/down\n     page title : Server Error\n") {
+		t.Fatalf("missing dead page:\n%s", text)
+	}
+}
+
+func TestOrchestrator_NoSelfLinks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/missing">missing</a>`))
+	mux.HandleFunc("/a", htmlHandler(`no links here`))
+	// /warming fails until it has been fetched once.
+	var warmed atomic.Bool
+	mux.HandleFunc("/warming", func(w http.ResponseWriter, _ *http.Request) {
+		if !warmed.Swap(true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	runWith := func(noSelf bool, cfg Config, seeds ...string) (*Report, string) {
+		t.Helper()
+		orch := newTestOrchestrator(cfg)
+		orch.crawler.NoSelfLinks = noSelf
+
+		var out bytes.Buffer
+		rep, err := orch.RunSeeds(context.Background(), append([]string{srv.URL + "/"}, seeds...), &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return rep, out.String()
+	}
+	run := func(noSelf bool) (*Report, string) {
+		t.Helper()
+		return runWith(noSelf, Config{}, srv.URL+"/gone")
+	}
+
+	// By default the crawled pages (/, /a, /missing, /gone) are links of
+	// their own: /, /gone and /missing pad the count.
+	rep, _ := run(false)
+	if rep.Discovered != 4 || rep.Failures != 2 {
+		t.Fatalf("default: discovered %d, failures %d; want 4 and 2", rep.Discovered, rep.Failures)
+	}
+
+	// Only /a and /missing were found on pages. /missing is still checked
+	// as a link; the unlinked seed /gone is reported from its crawl fetch.
+	rep, text := run(true)
+	if rep.Discovered != 2 {
+		t.Fatalf("no-self-links: discovered %d, want 2", rep.Discovered)
+	}
+	if len(rep.BrokenPages) != 1 || rep.BrokenPages[0].URL != srv.URL+"/gone" || rep.BrokenPages[0].StatusCode != http.StatusNotFound {
+		t.Fatalf("broken pages: got %+v", rep.BrokenPages)
+	}
+	if rep.Failures != 2 {
+		t.Fatalf("no-self-links: failures %d, want 2", rep.Failures)
+	}
+	if !strings.Contains(text, "\nBroken pages (failed when crawled):\n  404   "+srv.URL+"/gone\n") {
+		t.Fatalf("missing broken pages section:\n%s", text)
+	}
+
+	// Every format carries the broken page as a result.
+	for _, format := range []string{FormatNDJSON, FormatJSON, FormatCSV, FormatLychee} {
+		_, text := runWith(true, Config{Format: format}, srv.URL+"/gone")
+		if !strings.Contains(text, srv.URL+"/gone") {
+			t.Errorf("%s: missing the broken page:\n%s", format, text)
+		}
+	}
+
+	// After a warm-up a page that failed when crawled is checked again.
+	rep, _ = runWith(true, Config{WarmupDelay: time.Millisecond}, srv.URL+"/warming", srv.URL+"/gone")
+	if len(rep.BrokenPages) != 1 || rep.BrokenPages[0].URL != srv.URL+"/gone" || rep.Failures != 2 {
+		t.Fatalf("warm-up: broken pages %+v, failures %d; want only /gone and 2", rep.BrokenPages, rep.Failures)
+	}
+}
+
+func TestOrchestrator_MaxLinksPerPage(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/1">1</a><a href="/2">2</a><a href="/3">3</a><a href="/4">4</a><a href="/5">5</a>`))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{})
+	orch.crawler.MaxLinksPerPage = 3
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var got []string
+	for _, m := range orch.store.AllDiscovered() {
+		got = append(got, strings.TrimPrefix(m.URL, srv.URL))
+	}
+	sort.Strings(got)
+	if want := []string{"/", "/1", "/2", "/3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("recorded %v, want %v", got, want)
+	}
+
+	if len(rep.Warnings) != 1 || rep.Warnings[0].Kind != domain.WarnLinksTruncated || rep.Warnings[0].URL != srv.URL+"/" {
+		t.Fatalf("warnings: got %+v", rep.Warnings)
+	}
+	if !strings.Contains(out.String(), "WARN links_truncated "+srv.URL+"/\n      5 links; only the first 3 were recorded\n") {
+		t.Fatalf("missing truncation warning:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_Stale(t *testing.T) {
+	lastModified := func(v string) http.HandlerFunc {
+		return func(w http.ResponseWriter, _ *http.Request) {
+			if v != "" {
+				w.Header().Set("Last-Modified", v)
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/new">n</a><a href="/old">o</a><a href="/mid">m</a><a href="/none">-</a><a href="/bad">b</a><a href="/gone">g</a>`))
+	mux.HandleFunc("/new", lastModified("Mon, 02 Mar 2026 10:00:00 GMT"))
+	mux.HandleFunc("/old", lastModified("Tue, 15 Nov 1994 08:12:31 GMT"))
+	mux.HandleFunc("/mid", lastModified("Fri, 01 Jan 2016 00:00:00 GMT"))
+	mux.HandleFunc("/none", lastModified(""))
+	mux.HandleFunc("/bad", lastModified("last tuesday"))
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Last-Modified", "Sat, 01 Jan 2000 00:00:00 GMT")
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{StaleTop: 2})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// Dead links and links without a usable date are left out.
+	var got []string
+	for _, r := range rep.Stale {
+		got = append(got, strings.TrimPrefix(r.URL, srv.URL))
+	}
+	if want := []string{"/old", "/mid"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("stale: got %v, want %v", got, want)
+	}
+	want := "\nOldest resources (by Last-Modified):\n  1994-11-15  " + srv.URL + "/old\n  2016-01-01  " + srv.URL + "/mid\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("missing stale section:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_RedirectTargets(t *testing.T) {
+	redirect := func(to string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, to, http.StatusMovedPermanently)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/x">x</a><a href="/final">f</a><a href="/other">o</a>`))
+	mux.HandleFunc("/a", redirect("/final"))
+	mux.HandleFunc("/b", redirect("/a"))
+	mux.HandleFunc("/c", redirect("/final"))
+	mux.HandleFunc("/x", redirect("/other"))
+	mux.HandleFunc("/final", htmlHandler(""))
+	mux.HandleFunc("/other", htmlHandler(""))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	orch := newTestOrchestrator(Config{ReportRedirectTargets: true})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// Links checked without a redirect are not grouped.
+	type target struct {
+		URL   string
+		Links []string
+	}
+	var got []target
+	for _, tg := range rep.RedirectTargets {
+		var links []string
+		for _, l := range tg.Links {
+			links = append(links, strings.TrimPrefix(l, srv.URL))
+		}
+		got = append(got, target{strings.TrimPrefix(tg.URL, srv.URL), links})
+	}
+	want := []target{
+		{"/final", []string{"/a", "/b", "/c"}},
+		{"/other", []string{"/x"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("redirect targets: got %v, want %v", got, want)
+	}
+	section := "\nRedirect targets (link to these directly):\n" +
+		"  " + srv.URL + "/final  (3 links redirect here)\n" +
+		"    " + srv.URL + "/a\n    " + srv.URL + "/b\n    " + srv.URL + "/c\n" +
+		"  " + srv.URL + "/other  (1 link redirects here)\n" +
+		"    " + srv.URL + "/x\n"
+	if !strings.Contains(out.String(), section) {
+		t.Fatalf("missing redirect targets section:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_StopOnFirstDead(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/d1">1</a><a href="/d2">2</a><a href="/d3">3</a>`))
+	for _, p := range []string{"/d1", "/d2", "/d3"} {
+		mux.HandleFunc(p, func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	}
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	// One worker checks in URL order: /, then /d1, which stops the run.
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{Concurrency: 1, StopOnFirstDead: true})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if !rep.Stopped || rep.Checked != 2 || rep.Failures != 1 || len(rep.Results) != 2 {
+		t.Fatalf("got stopped=%v checked=%d failures=%d results=%d, want a stop after 2 checks with 1 failure",
+			rep.Stopped, rep.Checked, rep.Failures, len(rep.Results))
+	}
+	text := out.String()
+	if !strings.Contains(text, "DEAD 404   "+srv.URL+"/d1\n") || strings.Contains(text, "/d2") || strings.Contains(text, "/d3") {
+		t.Fatalf("only /d1 should be reported:\n%s", text)
+	}
+	if !strings.Contains(text, "Stopped at the first dead link: 2 of 4 links checked\n") {
+		t.Fatalf("missing stop note:\n%s", text)
+	}
+}
+
+func TestOrchestrator_TolerateErrors(t *testing.T) {
+	// A port nothing listens on any more refuses connections.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String() + "/"
+	ln.Close()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="`+refused+`">down</a><a href="/gone">gone</a>`))
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	for _, patterns := range [][]string{nil, {"connection refused"}} {
+		var out bytes.Buffer
+		rep, err := newTestOrchestrator(Config{AllowExternal: true, TolerateErrors: patterns}).Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if patterns == nil {
+			if rep.Errors != 1 || rep.Failures != 2 {
+				t.Fatalf("untolerated: got errors=%d failures=%d\n%s", rep.Errors, rep.Failures, out.String())
+			}
+			continue
+		}
+
+		// Only the 404 still fails the run.
+		if rep.Errors != 0 || rep.Tolerated != 1 || rep.Failures != 1 {
+			t.Fatalf("tolerated: got errors=%d tolerated=%d failures=%d\n%s", rep.Errors, rep.Tolerated, rep.Failures, out.String())
+		}
+		if len(rep.Warnings) != 1 || rep.Warnings[0].Kind != domain.WarnToleratedError || rep.Warnings[0].URL != refused {
+			t.Fatalf("warnings: got %+v", rep.Warnings)
+		}
+		if strings.Contains(out.String(), "DEAD ERR   "+refused) || !strings.Contains(out.String(), "Tolerated errors: 1\n") {
+			t.Fatalf("refused link should be tolerated:\n%s", out.String())
+		}
+	}
+}
+
+func TestOrchestrator_ResultHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/b">b</a><a href="/gone">g</a>`))
+	mux.HandleFunc("/a", htmlHandler(""))
+	mux.HandleFunc("/b", htmlHandler(""))
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNotFound) })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// The handler is not synchronized: calls must not overlap.
+	var got []string
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{
+		Concurrency:   4,
+		ResultHandler: func(r domain.Result) { got = append(got, r.URL) },
+	})
+
+	rep, err := orch.Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	var want []string
+	for _, r := range rep.Results {
+		want = append(want, r.URL)
+	}
+	sort.Strings(got)
+	if len(want) != rep.Checked || !reflect.DeepEqual(got, want) {
+		t.Fatalf("handler got %v, want one call per checked link %v", got, want)
+	}
+}
+
+func TestOrchestrator_DeadPageTitle(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/missing">m</a><a href="/plain">p</a>`))
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "<html><head><title>\n  Oops! That   page\n  went fishing </title></head><body>404</body></html>")
+	})
+	mux.HandleFunc("/plain", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = io.WriteString(w, "<title>not html</title>")
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{})
+
+	var out bytes.Buffer
+	if _, err := orch.Run(context.Background(), srv.URL+"/", &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	want := "DEAD 404   " + srv.URL + "/missing\n     page title : Oops! That page went fishing\n"
+	if !strings.Contains(out.String(), want) {
+		t.Fatalf("missing page title:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "not html") {
+		t.Fatalf("title taken from a non-HTML body:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_RedirectHosts(t *testing.T) {
+	redirect := func(to string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, to, http.StatusMovedPermanently)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("site.example/{$}", htmlHandler(`<a href="/old">o</a><a href="/moved">m</a><a href="/local">l</a><a href="http://linked.example/home">h</a>`))
+	mux.HandleFunc("site.example/old", redirect("http://cdn-hidden.example/new"))
+	mux.HandleFunc("site.example/moved", redirect("http://linked.example/x"))
+	mux.HandleFunc("site.example/local", redirect("/"))
+	mux.HandleFunc("cdn-hidden.example/new", htmlHandler(""))
+	mux.HandleFunc("linked.example/", htmlHandler(""))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// Every host is served by srv.
+	tr := &http.Transport{DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, network, srv.Listener.Addr().String())
+	}}
+	defer tr.CloseIdleConnections()
+
+	timeout := 2 * time.Second
+	crawler := NewCrawler(httpclient.New(timeout, httpclient.WithTransport(tr)), extractor.New(), noLimit{}, "test-bot", timeout, 0, 50, true, false)
+	checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true, Transport: tr}, noLimit{})
+	orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{ReportRedirectHosts: true})
+
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), "http://site.example/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// linked.example is linked directly, and /local stays internal.
+	want := []RedirectHost{{Host: "cdn-hidden.example", Links: []string{"http://site.example/old"}}}
+	if !reflect.DeepEqual(rep.RedirectHosts, want) {
+		t.Fatalf("redirect hosts: got %+v, want %+v", rep.RedirectHosts, want)
+	}
+	section := "\nExternal hosts reached only through redirects:\n  cdn-hidden.example  (1 link)\n    http://site.example/old\n"
+	if !strings.Contains(out.String(), section) {
+		t.Fatalf("missing redirect hosts section:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_SitemapAudit(t *testing.T) {
+	var base string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a>`))
+	mux.HandleFunc("/a", htmlHandler(`<a href="/a">self</a><a href="/b">b</a>`))
+	mux.HandleFunc("/b", htmlHandler(`not in the sitemap`))
+	mux.HandleFunc("/orphan", htmlHandler(`nothing links here`))
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `<?xml version="1.0"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>`+base+`/pages.xml.gz</loc></sitemap>
+</sitemapindex>`)
+	})
+	mux.HandleFunc("/pages.xml.gz", func(w http.ResponseWriter, _ *http.Request) {
+		gz := gzip.NewWriter(w)
+		_, _ = io.WriteString(gz, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>`+base+`/</loc></url>
+  <url><loc>`+base+`/a</loc></url>
+  <url><loc> `+base+`/orphan </loc></url>
+</urlset>`)
+		_ = gz.Close()
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	base = srv.URL
+
+	orch := newTestOrchestrator(Config{Sitemap: "/sitemap.xml"})
+	var out bytes.Buffer
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	// /a links itself, but the start page links it too.
+	want := &SitemapAudit{
+		Sitemap:  srv.URL + "/sitemap.xml",
+		URLs:     3,
+		Orphans:  []string{srv.URL + "/orphan"},
+		Unlisted: []string{srv.URL + "/b"},
+	}
+	if !reflect.DeepEqual(rep.Sitemap, want) {
+		t.Fatalf("sitemap audit: got %+v, want %+v", rep.Sitemap, want)
+	}
+	section := "\nSitemap " + srv.URL + "/sitemap.xml (3 URLs):\n" +
+		"  Orphans (in the sitemap, not linked from any crawled page): 1\n    " + srv.URL + "/orphan\n" +
+		"  Not in the sitemap (crawled pages it does not list): 1\n    " + srv.URL + "/b\n"
+	if !strings.Contains(out.String(), section) {
+		t.Fatalf("missing sitemap section:\n%s", out.String())
+	}
+
+	orch = newTestOrchestrator(Config{Sitemap: srv.URL + "/missing.xml"})
+	if _, err := orch.Run(context.Background(), srv.URL+"/", io.Discard); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected an error for a missing sitemap, got %v", err)
+	}
+}
+
+func TestOrchestrator_SitemapAuditNotModified(t *testing.T) {
+	st := store.NewMemory()
+	st.RecordPageResult("http://site.example/", domain.Result{URL: "http://site.example/", StatusCode: http.StatusOK})
+	st.RecordPageResult("http://site.example/kept", domain.Result{URL: "http://site.example/kept", StatusCode: http.StatusNotModified})
+	st.RecordPageResult("http://site.example/gone", domain.Result{URL: "http://site.example/gone", StatusCode: http.StatusNotFound})
+
+	// A page reused from an earlier crawl (304) was crawled all the same.
+	a := (&Orchestrator{store: st}).auditSitemap([]string{"http://site.example/"}, []string{"http://site.example/"})
+	if want := []string{"http://site.example/kept"}; !reflect.DeepEqual(a.Unlisted, want) {
+		t.Fatalf("unlisted: got %v, want %v", a.Unlisted, want)
+	}
+}
+
+func TestOrchestrator_PartialContent(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/full">full</a><img src="/video.mp4">`))
+	mux.HandleFunc("/full", htmlHandler(`full`))
+	mux.HandleFunc("/video.mp4", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-1/100")
+		w.WriteHeader(http.StatusPartialContent)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rep, err := newTestOrchestrator(Config{}).Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// / and /full are plain OK; the 206 is OK-partial and still healthy.
+	if rep.OK != 2 || rep.Partial != 1 || rep.Failures != 0 || rep.Healthy != 3 {
+		t.Fatalf("got ok=%d partial=%d failures=%d healthy=%d\n%s", rep.OK, rep.Partial, rep.Failures, rep.Healthy, out.String())
+	}
+	if !strings.Contains(out.String(), "Partial content (206, counted apart from OK): 1\n") {
+		t.Fatalf("missing partial content line:\n%s", out.String())
+	}
+	// The per-kind counts keep it apart too, so their OK adds up to rep.OK.
+	if pages, assets := rep.ByKind[domain.LinkKindPage], rep.ByKind[domain.LinkKindAsset]; pages.OK+assets.OK != rep.OK || assets.Partial != 1 {
+		t.Fatalf("by kind: page %+v, asset %+v", *pages, *assets)
+	}
+}
+
+func TestOrchestrator_WarmupDelay(t *testing.T) {
+	var crawled, checked atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, _ *http.Request) {
+		crawled.CompareAndSwap(0, time.Now().UnixNano()) // the crawl; the page is checked again later
+		htmlHandler(`<img src="/logo.png">`)(w, nil)
+	})
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, _ *http.Request) {
+		checked.CompareAndSwap(0, time.Now().UnixNano())
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	const delay = 200 * time.Millisecond
+	if _, err := newTestOrchestrator(Config{WarmupDelay: delay}).Run(context.Background(), srv.URL+"/", io.Discard); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if gap := time.Duration(checked.Load() - crawled.Load()); gap < delay {
+		t.Fatalf("first check %s after the crawl, want at least %s", gap, delay)
+	}
+
+	// Cancelling during the warm-up ends the run without checking.
+	checked.Store(0)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := newTestOrchestrator(Config{WarmupDelay: time.Minute}).Run(ctx, srv.URL+"/", io.Discard); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline to end the warm-up, got %v", err)
+	}
+	if checked.Load() != 0 {
+		t.Fatal("links were checked despite the cancelled warm-up")
+	}
+}
+
+func TestOrchestrator_WarmupDelayRechecksPages(t *testing.T) {
+	// /app answers 503 until it has warmed up: its first request, which
+	// is the crawl's.
+	var hits atomic.Int64
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/app">app</a>`))
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		htmlHandler(`warm`)(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	rep, err := newTestOrchestrator(Config{WarmupDelay: 10 * time.Millisecond}).Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if rep.DeadHTTP != 0 || rep.Failures != 0 || hits.Load() < 2 {
+		t.Fatalf("expected /app checked again after the warm-up: dead_http=%d failures=%d requests=%d", rep.DeadHTTP, rep.Failures, hits.Load())
+	}
+}
+
+func TestOrchestrator_DiscoveredBreakdown(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><img src="/logo.png"><a href="https://ext.example/">ext</a><a href="mailto:me@site.example">mail</a>`))
+	mux.HandleFunc("/a", htmlHandler(`a`))
+	mux.HandleFunc("/logo.png", func(w http.ResponseWriter, _ *http.Request) {})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rep, err := newTestOrchestrator(Config{DiscoveredBreakdown: true}).Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// The start page is found only on itself.
+	want := DiscoveredBreakdown{Pages: 1, Assets: 1, SelfOnly: 1, External: 1, Skipped: 1}
+	b := rep.DiscoveredBreakdown
+	if b == nil || *b != want {
+		t.Fatalf("breakdown: got %+v, want %+v", b, want)
+	}
+	if sum := b.Pages + b.Assets + b.SelfOnly + b.External + b.Skipped; sum != rep.Discovered {
+		t.Fatalf("breakdown adds up to %d, discovered %d", sum, rep.Discovered)
+	}
+	if !strings.Contains(out.String(), "Discovered links: 5 (pages 1, assets 1, self-only 1, external 1, skipped 1)\n") {
+		t.Fatalf("missing breakdown:\n%s", out.String())
+	}
+}
+
+func TestOrchestrator_TopBrokenSources(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/one">one</a><a href="/three">three</a>`))
+	mux.HandleFunc("/one", htmlHandler(`<a href="/gone/1">x</a>`))
+	mux.HandleFunc("/three", htmlHandler(`<a href="/gone/1">x</a><a href="/gone/2">x</a><a href="/gone/3">x</a><a href="/gone/3">again</a>`))
+	srv := httptest.NewServer(mux) // /gone/* are 404s
+	defer srv.Close()
+
+	var out bytes.Buffer
+	rep, err := newTestOrchestrator(Config{TopBrokenSources: 5}).Run(context.Background(), srv.URL+"/", &out)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	// A link repeated on a page counts once there.
+	want := []BrokenSource{{Page: srv.URL + "/three", Dead: 3}, {Page: srv.URL + "/one", Dead: 1}}
+	if !reflect.DeepEqual(rep.BrokenSources, want) {
+		t.Fatalf("broken sources: got %+v, want %+v", rep.BrokenSources, want)
+	}
+	if !strings.Contains(out.String(), "Top broken-link sources") {
+		t.Fatalf("missing section:\n%s", out.String())
+	}
+
+	rep, err = newTestOrchestrator(Config{TopBrokenSources: 1}).Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(rep.BrokenSources) != 1 || rep.BrokenSources[0].Page != srv.URL+"/three" {
+		t.Fatalf("top 1: got %+v", rep.BrokenSources)
+	}
+}
+
+func TestOrchestrator_OKStatusCodes(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/private">private</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/private", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	for _, lowMemory := range []bool{false, true} {
+		timeout := 2 * time.Second
+		crawler := NewCrawler(httpclient.New(timeout), extractor.New(), noLimit{}, "test-bot", timeout, 2, 50, true, false)
+		checker := NewLinkChecker(CheckerConfig{Timeout: timeout, HeadFirst: true, OKStatusCodes: []int{401, 403}}, noLimit{})
+		orch := NewOrchestrator(crawler, checker, store.NewMemory(), Config{LowMemory: lowMemory})
+
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("low-memory %v: run: %v", lowMemory, err)
+		}
+		// The 401 counts as OK; the 404 is still dead.
+		if rep.Failures != 1 || rep.DeadHTTP != 1 || rep.OK != 2 || rep.ByKind[domain.LinkKindPage].OK != 2 {
+			t.Fatalf("low-memory %v: got failures=%d dead_http=%d ok=%d by kind %+v\n%s", lowMemory, rep.Failures, rep.DeadHTTP, rep.OK, rep.ByKind[domain.LinkKindPage], out.String())
+		}
+		if strings.Contains(out.String(), "DEAD 401") || len(rep.BrokenPages) != 0 {
+			t.Fatalf("low-memory %v: the 401 page should not be reported dead:\n%s", lowMemory, out.String())
+		}
+	}
+}
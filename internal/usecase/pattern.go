@@ -0,0 +1,48 @@
+package usecase
+
+// matchGlob reports whether s matches pattern, where '*' matches any run of
+// characters (including '/'). All other characters match literally.
+func matchGlob(pattern, s string) bool {
+	// Classic two-pointer wildcard match with backtracking to the last '*'.
+	p, i := 0, 0
+	star, mark := -1, 0
+	for i < len(s) {
+		switch {
+		case p < len(pattern) && pattern[p] == '*':
+			star, mark = p, i
+			p++
+		case p < len(pattern) && pattern[p] == s[i]:
+			p++
+			i++
+		case star >= 0:
+			p = star + 1
+			mark++
+			i = mark
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matchGlob(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsPatterns turns patterns into ones that match anywhere in a
+// string, as for error messages.
+func containsPatterns(patterns []string) []string {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		out = append(out, "*"+p+"*")
+	}
+	return out
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// startProgress writes status() to w as a line every interval until the
+// returned stop is called. stop waits for the last write, so nothing is
+// written after it returns. A nil w disables progress.
+func startProgress(w io.Writer, every time.Duration, status func() string) (stop func()) {
+	if w == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		t := time.NewTicker(every)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				fmt.Fprintln(w, status())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// RedirectTarget is a final URL that checked links redirect to.
+type RedirectTarget struct {
+	URL   string
+	Links []string // sorted
+}
+
+// redirectTargets groups redirected links by where they end up.
+type redirectTargets map[string][]string
+
+func (t redirectTargets) add(r domain.Result) {
+	if len(r.RedirectChain) == 0 || r.FinalURL == "" || r.FinalURL == r.URL {
+		return
+	}
+	t[r.FinalURL] = append(t[r.FinalURL], r.URL)
+}
+
+// sorted returns the targets with the most links first, then by URL.
+func (t redirectTargets) sorted() []RedirectTarget {
+	out := make([]RedirectTarget, 0, len(t))
+	for u, links := range t {
+		sort.Strings(links)
+		out = append(out, RedirectTarget{URL: u, Links: links})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Links) != len(out[j].Links) {
+			return len(out[i].Links) > len(out[j].Links)
+		}
+		return out[i].URL < out[j].URL
+	})
+	return out
+}
+
+func writeRedirectTargets(w io.Writer, targets []RedirectTarget) {
+	fmt.Fprintln(w, "\nRedirect targets (link to these directly):")
+	for _, t := range targets {
+		noun := "links redirect"
+		if len(t.Links) == 1 {
+			noun = "link redirects"
+		}
+		fmt.Fprintf(w, "  %s  (%d %s here)\n", t.URL, len(t.Links), noun)
+		for _, l := range t.Links {
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+}
+
+// RedirectHost is an external host that internal links reach only through
+// redirects: no discovered link points at it directly.
+type RedirectHost struct {
+	Host  string
+	Links []string // the internal links redirecting there, sorted
+}
+
+// redirectHosts collects the external hosts internal links redirect to.
+type redirectHosts struct {
+	external func(host string) bool
+	byHost   map[string]map[string]bool
+}
+
+func newRedirectHosts(external func(host string) bool) *redirectHosts {
+	return &redirectHosts{external: external, byHost: map[string]map[string]bool{}}
+}
+
+// add records the external hosts on r's redirect chain, if r is an
+// internal link.
+func (h *redirectHosts) add(r domain.Result) {
+	if len(r.RedirectChain) == 0 || h.external(hostOf(r.URL)) {
+		return
+	}
+	reached := []string{r.FinalURL}
+	for _, hop := range r.RedirectChain[1:] {
+		reached = append(reached, hop.URL)
+	}
+	for _, u := range reached {
+		host := hostOf(u)
+		if host == "" || !h.external(host) {
+			continue
+		}
+		if h.byHost[host] == nil {
+			h.byHost[host] = map[string]bool{}
+		}
+		h.byHost[host][r.URL] = true
+	}
+}
+
+// sorted returns the hosts, by name, leaving out the linked ones.
+func (h *redirectHosts) sorted(linked map[string]bool) []RedirectHost {
+	var out []RedirectHost
+	for host, links := range h.byHost {
+		if linked[host] {
+			continue
+		}
+		rh := RedirectHost{Host: host}
+		for l := range links {
+			rh.Links = append(rh.Links, l)
+		}
+		sort.Strings(rh.Links)
+		out = append(out, rh)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Host < out[j].Host })
+	return out
+}
+
+// hostOf returns raw's lowercase host name, or "" if it has none.
+func hostOf(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+func writeRedirectHosts(w io.Writer, hosts []RedirectHost) {
+	fmt.Fprintln(w, "\nExternal hosts reached only through redirects:")
+	for _, h := range hosts {
+		noun := "links"
+		if len(h.Links) == 1 {
+			noun = "link"
+		}
+		fmt.Fprintf(w, "  %s  (%d %s)\n", h.Host, len(h.Links), noun)
+		for _, l := range h.Links {
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// maxReportName caps the length of a per-page report file name, before
+// any disambiguating suffix.
+const maxReportName = 120
+
+// pageEntry is one result listed in a per-page report: the link it
+// checked, whether it was downgraded to a warning, and whether the run
+// counted it as dead.
+type pageEntry struct {
+	r    domain.Result
+	m    *domain.LinkMeta
+	down bool
+	dead bool
+}
+
+// reportExts is the file extension of each format's per-page reports.
+var reportExts = map[string]string{
+	FormatText:   ".txt",
+	FormatNDJSON: ".ndjson",
+	FormatJSON:   ".json",
+	FormatCSV:    ".csv",
+	FormatLychee: ".txt",
+}
+
+// writeReportDir writes one file per source page into the report dir,
+// holding the results of the links found on that page, in the run's
+// format.
+func (o *Orchestrator) writeReportDir(bySource map[string][]pageEntry) error {
+	if err := os.MkdirAll(o.reportDir, 0o755); err != nil {
+		return fmt.Errorf("report dir: %w", err)
+	}
+
+	srcs := make([]string, 0, len(bySource))
+	for src := range bySource {
+		srcs = append(srcs, src)
+	}
+	sort.Strings(srcs)
+
+	ext, ok := reportExts[o.format]
+	if !ok {
+		ext = ".txt"
+	}
+	for i, name := range reportNames(srcs) {
+		if err := o.writePageReport(filepath.Join(o.reportDir, name+ext), srcs[i], bySource[srcs[i]]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *Orchestrator) writePageReport(path, source string, entries []pageEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("report dir: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	err = o.pageReport(w, source, entries)
+
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("report dir: %w", err)
+	}
+	return nil
+}
+
+// pageReport writes source's entries to w the way the run's format
+// writes results.
+func (o *Orchestrator) pageReport(w io.Writer, source string, entries []pageEntry) error {
+	switch o.format {
+	case FormatNDJSON:
+		nd := newNDJSONWriter(w)
+		nd.downgrade = o.downgrade
+		for _, e := range entries {
+			if err := nd.result(e.r, e.m); err != nil {
+				return err
+			}
+		}
+		return nil
+	case FormatJSON:
+		rep := &Report{
+			Discovered:    len(entries),
+			Checked:       len(entries),
+			SkippedCounts: map[domain.SkipReason]int{},
+			ByKind:        map[domain.LinkKind]*KindCounts{},
+		}
+		js := &jsonReport{}
+		for _, e := range entries {
+			rep.count(e.r, e.m.Kind, o.downgrade(e.r))
+			if e.dead {
+				rep.Failures++
+			}
+			js.add(e.r, e.m, e.down)
+		}
+		return js.write(w, rep, false)
+	case FormatCSV:
+		cw, err := newCSVWriter(w, o.columns)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := cw.row(e.r, e.m); err != nil {
+				return err
+			}
+		}
+		return cw.flush()
+	case FormatLychee:
+		lyc := newLycheeReport()
+		for _, e := range entries {
+			lyc.add(e.r, e.m, e.dead)
+		}
+		return lyc.write(w, time.Since(o.started))
+	}
+
+	fmt.Fprintf(w, "# %s\n", source)
+	for _, e := range entries {
+		label := "OK"
+		if e.dead {
+			label = "DEAD"
+		}
+		fmt.Fprintf(w, "%-4s %-5s %s\n", label, codeOrErr(e.r), e.r.URL)
+		if e.r.Err != nil {
+			fmt.Fprintf(w, "      %v\n", e.r.Err)
+		}
+	}
+	return nil
+}
+
+// reportNames returns a file name (without extension) for each source,
+// in order. Names are the URL without its scheme, with anything but
+// letters, digits, '.', '-' and '_' replaced by '_'. Sources that end up
+// with the same name get "-2", "-3", ... appended.
+func reportNames(srcs []string) []string {
+	names := make([]string, len(srcs))
+	used := make(map[string]bool, len(srcs))
+	for i, src := range srcs {
+		base := sanitizeReportName(src)
+		name := base
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func sanitizeReportName(src string) string {
+	if _, rest, ok := strings.Cut(src, "://"); ok {
+		src = rest
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, src)
+	name = strings.Trim(name, "_.")
+	if len(name) > maxReportName {
+		name = name[:maxReportName]
+	}
+	if name == "" {
+		name = "page"
+	}
+	return name
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrchestrator_ReportDir(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/a">a</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/a", htmlHandler(`<a href="/b">b</a><a href="/gone">gone</a>`))
+	mux.HandleFunc("/b", htmlHandler(`leaf`))
+	srv := httptest.NewServer(mux) // /gone is a 404
+	defer srv.Close()
+
+	dir := filepath.Join(t.TempDir(), "reports")
+	orch := newTestOrchestrator(Config{ReportDir: dir})
+	rep, err := orch.Run(context.Background(), srv.URL+"/", io.Discard)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != rep.Crawled {
+		t.Fatalf("expected one file per crawled page (%d), got %d", rep.Crawled, len(entries))
+	}
+
+	// Each page is a source of itself, so its own result is listed too.
+	host := sanitizeReportName(srv.URL)
+	want := map[string]string{
+		host + ".txt": "# " + srv.URL + "/\n" +
+			"OK   200   " + srv.URL + "/\n" +
+			"OK   200   " + srv.URL + "/a\n" +
+			"DEAD 404   " + srv.URL + "/gone\n",
+		host + "_a.txt": "# " + srv.URL + "/a\n" +
+			"OK   200   " + srv.URL + "/a\n" +
+			"OK   200   " + srv.URL + "/b\n" +
+			"DEAD 404   " + srv.URL + "/gone\n",
+	}
+	for name, content := range want {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if string(b) != content {
+			t.Fatalf("%s:\n got %q\nwant %q", name, b, content)
+		}
+	}
+}
+
+func TestOrchestrator_ReportDirFormats(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`<a href="/gone">gone</a><a href="/retired">retired</a>`))
+	srv := httptest.NewServer(mux) // both links are 404s
+	defer srv.Close()
+
+	host := sanitizeReportName(srv.URL)
+	for format, want := range map[string]string{
+		FormatText:   "# " + srv.URL + "/\nOK   200   " + srv.URL + "/\nDEAD 404   " + srv.URL + "/gone\nOK   404   " + srv.URL + "/retired\n",
+		FormatNDJSON: `"url":"` + srv.URL + `/gone"`,
+		FormatJSON:   `"summary":`,
+		FormatCSV:    srv.URL + "/gone,404",
+		FormatLychee: "[404] " + srv.URL + "/gone |",
+	} {
+		t.Run(format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "reports")
+			orch := newTestOrchestrator(Config{ReportDir: dir, Format: format, ExpectDead: []string{"*/retired"}})
+			if _, err := orch.Run(context.Background(), srv.URL+"/", io.Discard); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			b, err := os.ReadFile(filepath.Join(dir, host+reportExts[format]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), want) {
+				t.Fatalf("expected %q in:\n%s", want, b)
+			}
+			if format == FormatLychee && strings.Contains(string(b), "/retired") {
+				t.Fatalf("expected-dead link listed as a failure:\n%s", b)
+			}
+		})
+	}
+}
+
+func TestReportNames(t *testing.T) {
+	got := reportNames([]string{
+		"https://example.com/a?b",
+		"https://example.com/a_b",
+		"https://example.com/a/b",
+		"https://example.com/",
+		"file:///",
+		"https://example.com/" + strings.Repeat("x", 200),
+	})
+	want := []string{
+		"example.com_a_b",
+		"example.com_a_b-2",
+		"example.com_a_b-3",
+		"example.com",
+		"page",
+		"example.com_" + strings.Repeat("x", maxReportName-len("example.com_")),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q\nwant %q", got, want)
+	}
+}
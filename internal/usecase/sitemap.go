@@ -0,0 +1,206 @@
+package usecase
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Sitemap limits: the protocol caps a sitemap file at 50 MB and 50,000
+// URLs, and a sitemap index at 50,000 sitemaps; an index is followed this
+// many files deep in all.
+const (
+	maxSitemapSize  = 50 << 20
+	maxSitemapFiles = 100
+)
+
+// SitemapAudit compares a sitemap with what a crawl found.
+type SitemapAudit struct {
+	Sitemap string
+	URLs    int
+
+	// Orphans are sitemap URLs no crawled page links to (start URLs
+	// aside), sorted: pages a visitor cannot reach by following links.
+	Orphans []string
+
+	// Unlisted are crawled pages that answered 2xx (or 304, reused from an
+	// earlier crawl) but are not in the sitemap, sorted.
+	Unlisted []string
+}
+
+// sitemapFile is either a <urlset> or a <sitemapindex>.
+type sitemapFile struct {
+	XMLName  xml.Name
+	URLs     []sitemapLoc `xml:"url"`
+	Sitemaps []sitemapLoc `xml:"sitemap"`
+}
+
+type sitemapLoc struct {
+	Loc string `xml:"loc"`
+}
+
+// LoadSitemap fetches the sitemap at rawURL and returns the page URLs it
+// lists, following sitemap indexes. Files may be gzip-compressed.
+func (c *Crawler) LoadSitemap(ctx context.Context, rawURL string) ([]string, error) {
+	var pages []string
+	seen := map[string]bool{}
+	queue := []string{rawURL}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		if len(seen) == maxSitemapFiles {
+			return nil, fmt.Errorf("sitemap %s: more than %d sitemap files", rawURL, maxSitemapFiles)
+		}
+		seen[next] = true
+
+		f, err := c.fetchSitemap(ctx, next)
+		if err != nil {
+			return nil, fmt.Errorf("sitemap %s: %w", next, err)
+		}
+		switch f.XMLName.Local {
+		case "urlset":
+			for _, u := range f.URLs {
+				if loc := strings.TrimSpace(u.Loc); loc != "" {
+					pages = append(pages, loc)
+				}
+			}
+		case "sitemapindex":
+			for _, s := range f.Sitemaps {
+				if loc := strings.TrimSpace(s.Loc); loc != "" {
+					queue = append(queue, loc)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("sitemap %s: unexpected <%s> (want <urlset> or <sitemapindex>)", next, f.XMLName.Local)
+		}
+	}
+	return pages, nil
+}
+
+func (c *Crawler) fetchSitemap(ctx context.Context, rawURL string) (*sitemapFile, error) {
+	_ = c.limiter.Take(ctx, rawURL)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("answered %s", resp.Status)
+	}
+
+	// A .xml.gz file is gzip data, whatever the Content-Type says; peek at
+	// the magic number rather than trusting the name.
+	body := bufio.NewReader(io.LimitReader(resp.Body, maxSitemapSize))
+	var r io.Reader = body
+	if magic, _ := body.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = io.LimitReader(gz, maxSitemapSize)
+	}
+
+	var f sitemapFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// auditSitemap compares the sitemap's URLs with what the crawl from seeds
+// put in the store.
+func (o *Orchestrator) auditSitemap(urls, seeds []string) *SitemapAudit {
+	a := &SitemapAudit{Sitemap: o.sitemap, URLs: len(urls)}
+
+	// Crawled pages are keyed as the store normalizes them.
+	isSeed := map[string]bool{}
+	for _, s := range seeds {
+		if r, ok := o.store.PageResult(s); ok {
+			isSeed[r.URL] = true
+		}
+	}
+	listed := map[string]bool{}
+	orphans := map[string]bool{}
+	for _, u := range urls {
+		if r, ok := o.store.PageResult(u); ok {
+			listed[r.URL] = true
+			if isSeed[r.URL] {
+				continue
+			}
+		}
+		if !o.linkedFromElsewhere(u) {
+			orphans[u] = true
+		}
+	}
+	a.Orphans = sortedKeys(orphans)
+
+	for _, r := range o.store.PageResults() {
+		ok := r.StatusCode >= 200 && r.StatusCode <= 299 || r.StatusCode == http.StatusNotModified
+		if r.Err == nil && ok && !listed[r.URL] {
+			a.Unlisted = append(a.Unlisted, r.URL)
+		}
+	}
+	sort.Strings(a.Unlisted)
+	return a
+}
+
+// linkedFromElsewhere reports whether a page other than rawURL itself
+// links to it.
+func (o *Orchestrator) linkedFromElsewhere(rawURL string) bool {
+	m, ok := o.store.Discovered(rawURL)
+	if !ok {
+		return false
+	}
+	for src := range m.Sources {
+		if src != rawURL && src != m.URL {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSitemapAudit prints the sitemap URLs the crawl never reached and the
+// crawled pages the sitemap leaves out.
+func writeSitemapAudit(w io.Writer, a *SitemapAudit) {
+	fmt.Fprintf(w, "\nSitemap %s (%d URLs):\n", a.Sitemap, a.URLs)
+	fmt.Fprintf(w, "  Orphans (in the sitemap, not linked from any crawled page): %d\n", len(a.Orphans))
+	for _, u := range a.Orphans {
+		fmt.Fprintf(w, "    %s\n", u)
+	}
+	fmt.Fprintf(w, "  Not in the sitemap (crawled pages it does not list): %d\n", len(a.Unlisted))
+	for _, u := range a.Unlisted {
+		fmt.Fprintf(w, "    %s\n", u)
+	}
+}
+
+// resolveSitemap resolves a sitemap given as a path ("/sitemap.xml")
+// against the first seed.
+func resolveSitemap(sitemap string, seeds []string) string {
+	u, err := url.Parse(sitemap)
+	if err != nil || u.IsAbs() || len(seeds) == 0 {
+		return sitemap
+	}
+	base, err := url.Parse(seeds[0])
+	if err != nil {
+		return sitemap
+	}
+	return base.ResolveReference(u).String()
+}
@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// resultSpool keeps check results in a temp file so a huge run only holds
+// one file offset per link in memory. Results can be written in any order
+// and read back by index.
+type resultSpool struct {
+	f       *os.File
+	w       *bufio.Writer
+	offsets []int64 // by index; -1 until written
+	size    int64
+}
+
+// spooledResult is domain.Result in a form JSON can round-trip.
+type spooledResult struct {
+	URL           string            `json:"url"`
+	StatusCode    int               `json:"status"`
+	Err           string            `json:"err,omitempty"`
+	Elapsed       time.Duration     `json:"elapsed"`
+	FinalURL      string            `json:"final_url,omitempty"`
+	RedirectChain []domain.Hop      `json:"redirect_chain,omitempty"`
+	Attempts      []domain.Attempt  `json:"attempts,omitempty"`
+	Empty         bool              `json:"empty,omitempty"`
+	Method        string            `json:"method,omitempty"`
+	LastModified  time.Time         `json:"last_modified,omitzero"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	CheckedAt     time.Time         `json:"checked_at"`
+	FromCache     bool              `json:"from_cache,omitempty"`
+	Accepted      bool              `json:"accepted,omitempty"`
+}
+
+func newResultSpool(n int) (*resultSpool, error) {
+	f, err := os.CreateTemp("", "deadlink-results-*.ndjson")
+	if err != nil {
+		return nil, fmt.Errorf("create result spool: %w", err)
+	}
+	offsets := make([]int64, n)
+	for i := range offsets {
+		offsets[i] = -1
+	}
+	return &resultSpool{f: f, w: bufio.NewWriter(f), offsets: offsets}, nil
+}
+
+func (s *resultSpool) put(idx int, r domain.Result) error {
+	rec := spooledResult{
+		URL:           r.URL,
+		StatusCode:    r.StatusCode,
+		Elapsed:       r.Elapsed,
+		FinalURL:      r.FinalURL,
+		RedirectChain: r.RedirectChain,
+		Attempts:      r.Attempts,
+		Empty:         r.Empty,
+		Method:        r.Method,
+		LastModified:  r.LastModified,
+		Headers:       r.Headers,
+		Title:         r.Title,
+		CheckedAt:     r.CheckedAt,
+		FromCache:     r.FromCache,
+		Accepted:      r.Accepted,
+	}
+	if r.Err != nil {
+		rec.Err = r.Err.Error()
+	}
+
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("spool result: %w", err)
+	}
+	b = append(b, '\n')
+	if _, err := s.w.Write(b); err != nil {
+		return fmt.Errorf("spool result: %w", err)
+	}
+	s.offsets[idx] = s.size
+	s.size += int64(len(b))
+	return nil
+}
+
+func (s *resultSpool) get(idx int) (domain.Result, error) {
+	if err := s.w.Flush(); err != nil {
+		return domain.Result{}, fmt.Errorf("spool flush: %w", err)
+	}
+	off := s.offsets[idx]
+	if off < 0 {
+		return domain.Result{}, fmt.Errorf("spool: no result for index %d", idx)
+	}
+
+	line, err := bufio.NewReader(io.NewSectionReader(s.f, off, s.size-off)).ReadBytes('\n')
+	if err != nil {
+		return domain.Result{}, fmt.Errorf("spool read: %w", err)
+	}
+	var rec spooledResult
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return domain.Result{}, fmt.Errorf("spool decode: %w", err)
+	}
+
+	r := domain.Result{
+		URL:           rec.URL,
+		StatusCode:    rec.StatusCode,
+		Elapsed:       rec.Elapsed,
+		FinalURL:      rec.FinalURL,
+		RedirectChain: rec.RedirectChain,
+		Attempts:      rec.Attempts,
+		Empty:         rec.Empty,
+		Method:        rec.Method,
+		LastModified:  rec.LastModified,
+		Headers:       rec.Headers,
+		Title:         rec.Title,
+		CheckedAt:     rec.CheckedAt,
+		FromCache:     rec.FromCache,
+		Accepted:      rec.Accepted,
+	}
+	if rec.Err != "" {
+		r.Err = errors.New(rec.Err)
+	}
+	return r, nil
+}
+
+// Close removes the temp file.
+func (s *resultSpool) Close() error {
+	name := s.f.Name()
+	err := s.f.Close()
+	if rmErr := os.Remove(name); err == nil {
+		err = rmErr
+	}
+	return err
+}
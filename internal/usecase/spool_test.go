@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOrchestrator_LowMemoryMatchesInMemory(t *testing.T) {
+	const n = 300
+
+	var page strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&page, `<a href="/l/%d">%d</a>`, i, i)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(page.String()))
+	mux.HandleFunc("/l/", func(w http.ResponseWriter, r *http.Request) {
+		var i int
+		_, _ = fmt.Sscanf(r.URL.Path, "/l/%d", &i)
+		if i%3 == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	run := func(lowMemory bool) (*Report, string) {
+		orch := newTestOrchestrator(Config{LowMemory: lowMemory})
+		orch.crawler.maxDepth = 0
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run (low-memory=%v): %v", lowMemory, err)
+		}
+		return rep, withoutLatency(out.String())
+	}
+
+	memRep, memOut := run(false)
+	lowRep, lowOut := run(true)
+
+	if lowOut != memOut {
+		t.Fatalf("low-memory output differs:\n--- in-memory\n%s\n--- low-memory\n%s", memOut, lowOut)
+	}
+	if lowRep.Results != nil {
+		t.Fatalf("low-memory report should not hold results, got %d", len(lowRep.Results))
+	}
+	if len(memRep.Results) != n+1 || lowRep.Checked != n+1 || lowRep.DeadHTTP != n/3 {
+		t.Fatalf("unexpected counts: mem=%d checked=%d dead=%d", len(memRep.Results), lowRep.Checked, lowRep.DeadHTTP)
+	}
+}
+
+// withoutLatency drops the summary's latency line, which varies between
+// runs.
+func withoutLatency(out string) string {
+	lines := strings.SplitAfter(out, "\n")
+	kept := lines[:0]
+	for _, l := range lines {
+		if !strings.HasPrefix(l, "Latency: ") {
+			kept = append(kept, l)
+		}
+	}
+	return strings.Join(kept, "")
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// staleSet keeps the top live results with the oldest Last-Modified.
+// It holds at most twice top between trims, so low-memory runs stay
+// bounded.
+type staleSet struct {
+	top int
+	out []domain.Result
+}
+
+func (s *staleSet) add(r domain.Result) {
+	if s.top <= 0 || r.IsDead() || r.LastModified.IsZero() {
+		return
+	}
+	s.out = append(s.out, r)
+	if len(s.out) >= 2*s.top {
+		s.trim()
+	}
+}
+
+// sorted returns the kept results, oldest first.
+func (s *staleSet) sorted() []domain.Result {
+	s.trim()
+	return s.out
+}
+
+func (s *staleSet) trim() {
+	sort.Slice(s.out, func(i, j int) bool {
+		a, b := s.out[i], s.out[j]
+		if !a.LastModified.Equal(b.LastModified) {
+			return a.LastModified.Before(b.LastModified)
+		}
+		return a.URL < b.URL
+	})
+	if len(s.out) > s.top {
+		s.out = s.out[:s.top]
+	}
+}
+
+// writeStale prints the oldest resources with their Last-Modified date.
+func writeStale(w io.Writer, stale []domain.Result) {
+	fmt.Fprintln(w, "\nOldest resources (by Last-Modified):")
+	for _, r := range stale {
+		fmt.Fprintf(w, "  %s  %s\n", r.LastModified.UTC().Format("2006-01-02"), r.URL)
+	}
+}
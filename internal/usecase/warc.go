@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// maxWARCRecord caps the size of one WARC record's block we read.
+const maxWARCRecord = 64 << 20
+
+// RunWARC checks every URL a WARC (Web ARChive) file holds a response or
+// resource record for, as RunHAR does for a HAR capture: found on the
+// page named by the Referer of its request record, and with extractHTML
+// also the links of its HTML responses. The file may be gzipped, as
+// .warc.gz files are (one gzip member per record).
+func (o *Orchestrator) RunWARC(ctx context.Context, warc io.Reader, extractHTML bool, stdout io.Writer) (*Report, error) {
+	entries, err := readWARC(warc)
+	if err != nil {
+		return nil, fmt.Errorf("read warc: %w", err)
+	}
+	return o.checkCapture(ctx, entries, extractHTML, stdout)
+}
+
+// readWARC returns the response and resource records of a WARC file, in
+// order. A response whose HTTP message cannot be read keeps its URL, with
+// the error as its BodyErr.
+func readWARC(r io.Reader) ([]captureEntry, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+	tp := textproto.NewReader(br)
+
+	var entries []captureEntry
+	referers := map[string]string{} // target URI -> Referer of its request
+	for n := 0; ; n++ {
+		version, err := tp.ReadLine()
+		for err == nil && version == "" { // records end with two CRLFs
+			version, err = tp.ReadLine()
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", n, err)
+		}
+		if !strings.HasPrefix(version, "WARC/") {
+			return nil, fmt.Errorf("record %d: not a WARC record: %q", n, version)
+		}
+		hdr, err := tp.ReadMIMEHeader()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", n, err)
+		}
+		length, err := strconv.ParseInt(hdr.Get("Content-Length"), 10, 64)
+		if err != nil || length < 0 || length > maxWARCRecord {
+			return nil, fmt.Errorf("record %d: bad Content-Length %q", n, hdr.Get("Content-Length"))
+		}
+		block := make([]byte, length)
+		if _, err := io.ReadFull(br, block); err != nil {
+			return nil, fmt.Errorf("record %d: %w", n, err)
+		}
+
+		// WARC 1.0 writers may wrap the URI in angle brackets.
+		target := strings.Trim(hdr.Get("WARC-Target-URI"), "<>")
+		name := fmt.Sprintf("warc record %d", n)
+		switch hdr.Get("WARC-Type") {
+		case "request":
+			req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(block)))
+			if err == nil && isHTTPURL(req.Referer()) {
+				referers[target] = req.Referer()
+			}
+		case "resource":
+			entries = append(entries, captureEntry{name: name, URL: target, MimeType: hdr.Get("Content-Type"), Body: string(block)})
+		case "response":
+			entries = append(entries, warcResponse(name, target, block))
+		}
+	}
+	for i := range entries {
+		entries[i].Source = referers[entries[i].URL]
+	}
+	return entries, nil
+}
+
+// warcResponse reads the HTTP response a response record holds.
+func warcResponse(name, target string, block []byte) captureEntry {
+	e := captureEntry{name: name, URL: target}
+	if !isHTTPURL(target) { // dns: records and the like
+		return e
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(block)), nil)
+	if err != nil {
+		e.BodyErr = fmt.Errorf("read response: %w", err)
+		return e
+	}
+	defer resp.Body.Close()
+	e.MimeType = resp.Header.Get("Content-Type")
+
+	body := io.Reader(resp.Body)
+	switch enc := strings.ToLower(resp.Header.Get("Content-Encoding")); enc {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			e.BodyErr = fmt.Errorf("decode body: %w", err)
+			return e
+		}
+		defer gz.Close()
+		body = gz
+	default:
+		e.BodyErr = fmt.Errorf("decode body: unsupported content encoding %q", enc)
+		return e
+	}
+	b, err := io.ReadAll(body)
+	if err != nil {
+		e.BodyErr = fmt.Errorf("decode body: %w", err)
+		return e
+	}
+	e.Body = string(b)
+	return e
+}
@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// warcRecord formats one WARC record holding block.
+func warcRecord(typ, target, contentType, block string) string {
+	return fmt.Sprintf("WARC/1.1\r\nWARC-Type: %s\r\nWARC-Target-URI: %s\r\nContent-Type: %s\r\nContent-Length: %d\r\n\r\n%s\r\n\r\n",
+		typ, target, contentType, len(block), block)
+}
+
+// warcFixture is a crawl of one page that linked a stylesheet which has
+// since disappeared, plus the crawler's DNS lookup.
+func warcFixture(base string) []string {
+	page := `<a href="/about">about</a><a href="/old">old</a>`
+	return []string{
+		warcRecord("warcinfo", "", "application/warc-fields", "software: test\r\n"),
+		warcRecord("response", "dns:example.com", "text/dns", "20260101000000\nexample.com. 300 IN A 127.0.0.1\n"),
+		warcRecord("request", base+"/", "application/http; msgtype=request",
+			"GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"),
+		warcRecord("response", base+"/", "application/http; msgtype=response",
+			fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Length: %d\r\n\r\n%s", len(page), page)),
+		warcRecord("request", base+"/style.css", "application/http; msgtype=request",
+			"GET /style.css HTTP/1.1\r\nHost: example.com\r\nReferer: "+base+"/\r\n\r\n"),
+		warcRecord("response", base+"/style.css", "application/http; msgtype=response",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/css\r\nTransfer-Encoding: chunked\r\n\r\n6\r\nbody{}\r\n0\r\n\r\n"),
+	}
+}
+
+func TestOrchestrator_RunWARC(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{$}", htmlHandler(`home`))
+	mux.HandleFunc("/about", htmlHandler(`about`))
+	srv := httptest.NewServer(mux) // /style.css and /old are 404s now
+	defer srv.Close()
+
+	plain := strings.Join(warcFixture(srv.URL), "")
+	var gzipped bytes.Buffer
+	for _, rec := range warcFixture(srv.URL) { // one gzip member per record
+		zw := gzip.NewWriter(&gzipped)
+		zw.Write([]byte(rec))
+		zw.Close()
+	}
+
+	for name, warc := range map[string]string{"plain": plain, "gzipped": gzipped.String()} {
+		t.Run(name, func(t *testing.T) {
+			orch := newTestOrchestrator(Config{})
+			var out bytes.Buffer
+			rep, err := orch.RunWARC(context.Background(), strings.NewReader(warc), true, &out)
+			if err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if rep.Checked != 4 || rep.DeadHTTP != 2 || rep.SkippedCounts[domain.SkipUnsupportedScheme] != 1 {
+				t.Fatalf("expected 4 checked, 2 dead, 1 skipped dns: record, got %+v", rep)
+			}
+			for _, want := range []string{
+				"DEAD 404   " + srv.URL + "/old\n       found on : " + srv.URL + "/",
+				"DEAD 404   " + srv.URL + "/style.css\n       found on : " + srv.URL + "/",
+			} {
+				if !strings.Contains(out.String(), want) {
+					t.Fatalf("missing %q in:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	t.Run("undecodable body", func(t *testing.T) {
+		bad := warcRecord("response", srv.URL+"/", "application/http; msgtype=response",
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html\r\nContent-Encoding: br\r\n\r\n...")
+		orch := newTestOrchestrator(Config{})
+		rep, err := orch.RunWARC(context.Background(), strings.NewReader(bad), true, &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		if rep.Checked != 1 || len(rep.ExtractErrors) != 1 || !strings.Contains(rep.ExtractErrors[0].Err.Error(), "warc record 0: decode body") {
+			t.Fatalf("expected the page checked and its body reported, got %+v", rep)
+		}
+	})
+
+	t.Run("not a warc file", func(t *testing.T) {
+		orch := newTestOrchestrator(Config{})
+		if _, err := orch.RunWARC(context.Background(), strings.NewReader("<html>"), false, &bytes.Buffer{}); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+// downgrade returns the warning a dead result is reported as instead of
+// failing the run (auth-required or a tolerated error), or "" if it stays
+// dead.
+func (o *Orchestrator) downgrade(r domain.Result) domain.WarningKind {
+	switch {
+	case o.authAsWarning && authRequired(r):
+		return domain.WarnAuthRequired
+	case r.Err != nil && matchAny(o.tolerateErrors, r.Err.Error()):
+		return domain.WarnToleratedError
+	}
+	return ""
+}
+
+// warningsFor inspects a live result for non-fatal problems.
+// m may be nil when the result has no stored metadata.
+func (o *Orchestrator) warningsFor(r domain.Result, m *domain.LinkMeta) []domain.Warning {
+	var out []domain.Warning
+
+	switch o.downgrade(r) {
+	case domain.WarnAuthRequired:
+		out = append(out, domain.Warning{
+			URL:    r.URL,
+			Kind:   domain.WarnAuthRequired,
+			Detail: fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		})
+	case domain.WarnToleratedError:
+		out = append(out, domain.Warning{
+			URL:    r.URL,
+			Kind:   domain.WarnToleratedError,
+			Detail: r.Err.Error(),
+		})
+	}
+
+	if o.flagEmpty && r.Empty && r.StatusCode < 300 && m != nil && m.Kind == domain.LinkKindAsset {
+		out = append(out, domain.Warning{
+			URL:    r.URL,
+			Kind:   domain.WarnEmptyResource,
+			Detail: fmt.Sprintf("%d with an empty body", r.StatusCode),
+		})
+	}
+
+	for _, h := range r.RedirectChain {
+		switch h.StatusCode {
+		case http.StatusFound, http.StatusSeeOther, http.StatusTemporaryRedirect:
+			out = append(out, domain.Warning{
+				URL:    r.URL,
+				Kind:   domain.WarnTemporaryRedirect,
+				Detail: fmt.Sprintf("%d at %s", h.StatusCode, h.URL),
+			})
+		}
+	}
+
+	if o.warnRedirectHops > 0 && len(r.RedirectChain) > o.warnRedirectHops {
+		out = append(out, domain.Warning{
+			URL:    r.URL,
+			Kind:   domain.WarnLongRedirectChain,
+			Detail: fmt.Sprintf("%d redirect hops to %s", len(r.RedirectChain), r.FinalURL),
+		})
+	}
+
+	if o.httpsAudit {
+		out = append(out, httpsWarnings(r)...)
+	}
+
+	return out
+}
+
+// authRequired reports whether r was refused for lack of credentials or
+// permission (401 or 403).
+func authRequired(r domain.Result) bool {
+	return r.Err == nil && (r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden)
+}
+
+// httpsWarnings scans r's redirect chain for plain-http URLs. An http URL
+// before the end of the chain is an insecure hop; ending at http after
+// passing through https is a downgrade.
+func httpsWarnings(r domain.Result) []domain.Warning {
+	if len(r.RedirectChain) == 0 {
+		return nil
+	}
+
+	var out []domain.Warning
+	sawHTTPS := false
+	for _, h := range r.RedirectChain {
+		switch urlScheme(h.URL) {
+		case "https":
+			sawHTTPS = true
+		case "http":
+			out = append(out, domain.Warning{
+				URL:    r.URL,
+				Kind:   domain.WarnInsecureHop,
+				Detail: fmt.Sprintf("%d from http URL %s", h.StatusCode, h.URL),
+			})
+		}
+	}
+
+	if urlScheme(r.FinalURL) == "http" && sawHTTPS {
+		out = append(out, domain.Warning{
+			URL:    r.URL,
+			Kind:   domain.WarnHTTPDowngrade,
+			Detail: "redirects from https to " + r.FinalURL,
+		})
+	}
+	return out
+}
+
+func urlScheme(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+// linkWarning turns a warning recorded at extraction time into a report
+// warning.
+func linkWarning(m *domain.LinkMeta) (domain.Warning, bool) {
+	if m == nil || m.Warning == "" {
+		return domain.Warning{}, false
+	}
+
+	detail := ""
+	switch m.Warning {
+	case domain.WarnSchemeLessHost:
+		detail = "href has no scheme but looks like a host name; it resolved as a relative path"
+	}
+	return domain.Warning{URL: m.URL, Kind: m.Warning, Detail: detail}, true
+}
@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rojanmagar2001/godeadlink/internal/domain"
+)
+
+func TestOrchestrator_FlagEmptyAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<img src="/empty.png"><img src="/full.png"><a href="/blank">blank</a>`))
+	mux.HandleFunc("/empty.png", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/full.png", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("PNG..."))
+	})
+	mux.HandleFunc("/blank", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, headFirst := range []bool{true, false} {
+		orch := newTestOrchestrator(Config{FlagEmpty: true})
+		orch.checker.chk.HeadFirst = headFirst
+		orch.checker.chk.ConfirmEmpty = true
+
+		var out bytes.Buffer
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &out)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+
+		if len(rep.Warnings) != 1 {
+			t.Fatalf("head-first=%v: expected 1 warning, got %+v\n%s", headFirst, rep.Warnings, out.String())
+		}
+		w := rep.Warnings[0]
+		if w.Kind != domain.WarnEmptyResource || w.URL != srv.URL+"/empty.png" {
+			t.Fatalf("head-first=%v: unexpected warning %+v", headFirst, w)
+		}
+	}
+
+	// Off by default.
+	orch := newTestOrchestrator(Config{})
+	rep, err := orch.Run(context.Background(), srv.URL+"/", &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(rep.Warnings) != 0 {
+		t.Fatalf("expected no warnings without FlagEmpty, got %+v", rep.Warnings)
+	}
+}
+
+func TestWarningsFor_HTTPSAudit(t *testing.T) {
+	cases := []struct {
+		name  string
+		r     domain.Result
+		kinds []domain.WarningKind
+	}{
+		{
+			name: "http to https warns on the http hop",
+			r: domain.Result{
+				URL:           "http://example.com/a",
+				FinalURL:      "https://example.com/a",
+				StatusCode:    200,
+				RedirectChain: []domain.Hop{{URL: "http://example.com/a", StatusCode: 301}},
+			},
+			kinds: []domain.WarningKind{domain.WarnInsecureHop},
+		},
+		{
+			name: "https to http is a downgrade",
+			r: domain.Result{
+				URL:           "https://example.com/b",
+				FinalURL:      "http://example.com/b",
+				StatusCode:    200,
+				RedirectChain: []domain.Hop{{URL: "https://example.com/b", StatusCode: 301}},
+			},
+			kinds: []domain.WarningKind{domain.WarnHTTPDowngrade},
+		},
+		{
+			name: "https all the way",
+			r: domain.Result{
+				URL:           "https://example.com/c",
+				FinalURL:      "https://www.example.com/c",
+				StatusCode:    200,
+				RedirectChain: []domain.Hop{{URL: "https://example.com/c", StatusCode: 301}},
+			},
+		},
+		{
+			name: "no redirects",
+			r:    domain.Result{URL: "http://example.com/d", FinalURL: "http://example.com/d", StatusCode: 200},
+		},
+	}
+
+	for _, tc := range cases {
+		audit := &Orchestrator{httpsAudit: true}
+		got := audit.warningsFor(tc.r, nil)
+		if len(got) != len(tc.kinds) {
+			t.Fatalf("%s: expected %v, got %+v", tc.name, tc.kinds, got)
+		}
+		for i, w := range got {
+			if w.Kind != tc.kinds[i] || w.URL != tc.r.URL {
+				t.Fatalf("%s: expected %v, got %+v", tc.name, tc.kinds, got)
+			}
+		}
+
+		if off := (&Orchestrator{}).warningsFor(tc.r, nil); len(off) != 0 {
+			t.Fatalf("%s: expected no warnings without the audit, got %+v", tc.name, off)
+		}
+	}
+}
+
+func TestOrchestrator_WarnRedirectHops(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", htmlHandler(`<a href="/r1">chain</a><a href="/end">direct</a>`))
+	for from, to := range map[string]string{"/r1": "/r2", "/r2": "/r3", "/r3": "/end"} {
+		mux.HandleFunc(from, func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, to, http.StatusMovedPermanently)
+		})
+	}
+	mux.HandleFunc("/end", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	cases := []struct {
+		hops   int
+		strict bool
+		warn   bool
+	}{
+		{hops: 0},
+		{hops: 3},
+		{hops: 2, warn: true},
+		{hops: 2, strict: true, warn: true},
+	}
+	for _, tc := range cases {
+		orch := newTestOrchestrator(Config{WarnRedirectHops: tc.hops, Strict: tc.strict})
+		rep, err := orch.Run(context.Background(), srv.URL+"/", &bytes.Buffer{})
+		if err != nil {
+			t.Fatalf("hops=%d: run: %v", tc.hops, err)
+		}
+
+		if !tc.warn {
+			if len(rep.Warnings) != 0 {
+				t.Fatalf("hops=%d: expected no warnings, got %+v", tc.hops, rep.Warnings)
+			}
+			continue
+		}
+		if len(rep.Warnings) != 1 {
+			t.Fatalf("hops=%d: expected 1 warning, got %+v", tc.hops, rep.Warnings)
+		}
+		w := rep.Warnings[0]
+		if w.Kind != domain.WarnLongRedirectChain || w.URL != srv.URL+"/r1" {
+			t.Fatalf("hops=%d: unexpected warning %+v", tc.hops, w)
+		}
+		want := 0
+		if tc.strict {
+			want = 1
+		}
+		if rep.Failures != want {
+			t.Fatalf("hops=%d strict=%v: expected %d failures, got %d", tc.hops, tc.strict, want, rep.Failures)
+		}
+	}
+}